@@ -17,13 +17,311 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// +kubebuilder:object:generate=true
+
 // OAIConfigSpec defines the desired state of OAIConfig
 type OAIConfigSpec struct {
 	//image defines the image location for the OAI NF
 	Image string `json:"image"`
+	//e2Agent enables the E2 agent of the NF towards a near-RT RIC
+	// +optional
+	E2Agent *E2AgentConfig `json:"e2Agent,omitempty"`
+	//networks defines the NetworkAttachmentDefinitions generated by the controller for the interfaces of the NF,
+	//interfaces without an entry rely on NetworkAttachmentDefinitions provisioned outside of the controller
+	// +optional
+	Networks []NetworkConfig `json:"networks,omitempty"`
+	//telnet defines the telnet server of the NF, also serving the O1 module of the DU,
+	//the DU serves it with the defaults and the CU-CP and CU-UP don't when not set
+	// +optional
+	Telnet *TelnetConfig `json:"telnet,omitempty"`
+	//runtime defines the command-line options and environment of the softmodem, merged with the ones set by the controller
+	// +optional
+	Runtime *RuntimeOptions `json:"runtime,omitempty"`
+	//compute defines the compute resources of the NF container and the placement of its pod
+	// +optional
+	Compute *ComputeConfig `json:"compute,omitempty"`
+	//security defines the security profile of the containers of the NF
+	// +optional
+	Security *SecurityConfig `json:"security,omitempty"`
+	//probes defines the startup, readiness and liveness probes of the NF container
+	// +optional
+	Probes *ProbesConfig `json:"probes,omitempty"`
+}
+
+// NetworkType defines the CNI plugin of a NetworkAttachmentDefinition
+// +kubebuilder:validation:Enum=macvlan;ipvlan;sriov;bridge
+type NetworkType string
+
+const (
+	NetworkTypeMacvlan NetworkType = "macvlan"
+	NetworkTypeIpvlan  NetworkType = "ipvlan"
+	NetworkTypeSriov   NetworkType = "sriov"
+	NetworkTypeBridge  NetworkType = "bridge"
+)
+
+// NetworkConfig defines the NetworkAttachmentDefinition of an interface of the NF
+type NetworkConfig struct {
+	//interface defines the interface of the NFDeployment attached to the network, e.g. n2 or f1
+	Interface string `json:"interface"`
+	//type defines the CNI plugin of the network
+	// +optional
+	// +kubebuilder:default=macvlan
+	Type NetworkType `json:"type,omitempty"`
+	//master defines the host interface of macvlan and ipvlan networks, or the bridge of bridge networks,
+	//the VLAN ID of the interface is appended to the host interface of macvlan and ipvlan networks
+	// +optional
+	Master string `json:"master,omitempty"`
+	//resourceName defines the SR-IOV device plugin resource of sriov networks
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+	//mtu defines the MTU of the interface, the MTU of the host interface when not set
+	// +optional
+	// +kubebuilder:validation:Minimum=68
+	// +kubebuilder:validation:Maximum=9216
+	MTU *int32 `json:"mtu,omitempty"`
+	//mac pins the MAC address of the interface, not supported by ipvlan networks
+	// +optional
+	// +kubebuilder:validation:Pattern=`^([0-9a-fA-F]{2}:){5}[0-9a-fA-F]{2}$`
+	MAC string `json:"mac,omitempty"`
+	//routes defines the static routes through the interface, on top of the default route towards its gateway if any
+	// +optional
+	Routes []StaticRoute `json:"routes,omitempty"`
+}
+
+// StaticRoute defines a static route through an interface of the NF
+type StaticRoute struct {
+	//destination defines the destination of the route in CIDR notation
+	Destination string `json:"destination"`
+	//gateway defines the next hop of the route, the destination is directly connected when not set
+	// +optional
+	Gateway *string `json:"gateway,omitempty"`
+}
+
+// E2ServiceModel defines an E2 service model of the FlexRIC agent
+// +kubebuilder:validation:Enum=KPM;RC;MAC;RLC;PDCP;GTP;SLICE;TC
+type E2ServiceModel string
+
+// +kubebuilder:object:generate=true
+
+// E2AgentConfig defines the E2 agent of an OAI NF
+type E2AgentConfig struct {
+	//ricAddress defines the IP address of the near-RT RIC
+	RICAddress string `json:"ricAddress"`
+	//ricPort defines the E2 port of the near-RT RIC, set as port of the e2_agent section of the configuration when it is not 36421
+	// +optional
+	// +kubebuilder:default=36421
+	// +kubebuilder:validation:Minimum=1
+	RICPort uint16 `json:"ricPort,omitempty"`
+	//serviceModels defines the service models loaded by the E2 agent, all the ones shipped in smDir when empty
+	// +optional
+	ServiceModels []E2ServiceModel `json:"serviceModels,omitempty"`
+	//smDir defines the directory of the service model libraries in the image
+	// +optional
+	// +kubebuilder:default="/usr/local/lib/flexric/"
+	SMDir string `json:"smDir,omitempty"`
+}
+
+// TelnetExposure defines the Service exposing the telnet server of a NF
+// +kubebuilder:validation:Enum=None;ClusterIP;NodePort;LoadBalancer
+type TelnetExposure string
+
+const (
+	TelnetExposureNone         TelnetExposure = "None"
+	TelnetExposureClusterIP    TelnetExposure = "ClusterIP"
+	TelnetExposureNodePort     TelnetExposure = "NodePort"
+	TelnetExposureLoadBalancer TelnetExposure = "LoadBalancer"
+)
+
+// TelnetConfig defines the telnet server of an OAI NF
+type TelnetConfig struct {
+	//listenInterface defines the interface of the NFDeployment the telnet server listens on, the pod IP when not set
+	// +optional
+	ListenInterface string `json:"listenInterface,omitempty"`
+	//port defines the port of the telnet server
+	// +optional
+	// +kubebuilder:default=9090
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+	//exposure defines the type of the Service exposing the telnet server, None for no Service
+	// +optional
+	// +kubebuilder:default=LoadBalancer
+	Exposure TelnetExposure `json:"exposure,omitempty"`
+	//nodePort defines the node port of NodePort and LoadBalancer Services, allocated by Kubernetes when not set
+	// +optional
+	// +kubebuilder:validation:Minimum=30000
+	// +kubebuilder:validation:Maximum=32767
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+// LogLevel defines the global log level of the softmodem
+// +kubebuilder:validation:Enum=error;warn;analysis;info;debug;trace
+type LogLevel string
+
+// LogOption defines a global log option of the softmodem
+// +kubebuilder:validation:Enum=nocolor;level;thread;line_num;function;time;thread_id;wall_clock;utc_time
+type LogOption string
+
+// EnvVar defines an environment variable of the softmodem container
+type EnvVar struct {
+	//name defines the name of the environment variable
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+	//value defines the value of the environment variable
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// RuntimeOptions defines the command-line options and environment of the softmodem of an OAI NF
+type RuntimeOptions struct {
+	//extraArgs defines the options appended to the command line of the softmodem, e.g. --gNBs.[0].min_rxtxtime 6,
+	//the options set by the controller or through the other fields cannot be overridden
+	// +optional
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+	//env defines the environment variables added to the softmodem container
+	// +optional
+	Env []EnvVar `json:"env,omitempty"`
+	//timezone defines the TZ of the softmodem container
+	// +optional
+	// +kubebuilder:default="Europe/Paris"
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_+\-/]+$`
+	Timezone string `json:"timezone,omitempty"`
+	//logLevel defines the global log level of the softmodem, the OAI default when not set
+	// +optional
+	LogLevel LogLevel `json:"logLevel,omitempty"`
+	//logOptions defines the global log options of the softmodem
+	// +optional
+	// +kubebuilder:default={level,nocolor,time}
+	LogOptions []LogOption `json:"logOptions,omitempty"`
+	//threadPool defines the cores of the L1 thread pool of the DU, e.g. -1,-1,-1 for three unpinned threads
+	// +optional
+	// +kubebuilder:validation:Pattern=`^-?[0-9]+(,-?[0-9]+)*$`
+	ThreadPool string `json:"threadPool,omitempty"`
+	//usrpTxThread enables the dedicated USRP transmit thread of the DU
+	// +optional
+	USRPTxThread bool `json:"usrpTxThread,omitempty"`
+}
+
+// ComputeProfile defines how the compute resources of a NF are requested
+// +kubebuilder:validation:Enum=default;realtime
+type ComputeProfile string
+
+const (
+	ComputeProfileDefault ComputeProfile = "default"
+	// ComputeProfileRealtime requests the guaranteed QoS class, exclusive CPUs and hugepages
+	ComputeProfileRealtime ComputeProfile = "realtime"
+)
+
+// HugepagesConfig defines the hugepages of an OAI NF
+type HugepagesConfig struct {
+	//pageSize defines the size of the hugepages
+	// +optional
+	// +kubebuilder:default="1Gi"
+	// +kubebuilder:validation:Enum="2Mi";"1Gi"
+	PageSize string `json:"pageSize,omitempty"`
+	//size defines the amount of hugepages memory of the NF container
+	Size resource.Quantity `json:"size"`
+}
+
+// ComputeConfig defines the compute resources and the placement of an OAI NF
+type ComputeConfig struct {
+	//profile defines how the compute resources are requested, the realtime profile requests the guaranteed QoS class
+	//with exclusive CPUs and hugepages, 1Gi of 1Gi hugepages when hugepages is not set
+	// +optional
+	// +kubebuilder:default=default
+	Profile ComputeProfile `json:"profile,omitempty"`
+	//resources defines the compute resources of the NF container, replacing the defaults of the NF when set
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	//hugepages defines the hugepages of the NF container
+	// +optional
+	Hugepages *HugepagesConfig `json:"hugepages,omitempty"`
+	//nodeSelector defines the node labels the pod is scheduled on, e.g. of performance-tuned nodes
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	//affinity defines the scheduling constraints of the pod
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	//tolerations defines the tolerations of the pod
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	//runtimeClassName defines the RuntimeClass of the pod
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+}
+
+// SecurityProfile defines the privileges of the containers of a NF
+// +kubebuilder:validation:Enum=privileged;capabilities;restricted
+type SecurityProfile string
+
+const (
+	// SecurityProfilePrivileged runs the NF container privileged, its namespace must allow the privileged Pod Security level
+	SecurityProfilePrivileged SecurityProfile = "privileged"
+	// SecurityProfileCapabilities only adds the NET_ADMIN, SYS_NICE and IPC_LOCK capabilities to the NF container, which
+	// the baseline Pod Security level doesn't allow: its namespace must allow the privileged level
+	SecurityProfileCapabilities SecurityProfile = "capabilities"
+	// SecurityProfileRestricted complies with the restricted Pod Security Standard, the only profile running under the
+	// baseline and restricted levels
+	SecurityProfileRestricted SecurityProfile = "restricted"
+)
+
+// SecurityConfig defines the security profile of an OAI NF
+type SecurityConfig struct {
+	//profile defines the privileges of the containers, privileged on the DU and capabilities on the CU-CP and CU-UP when not set
+	// +optional
+	Profile SecurityProfile `json:"profile,omitempty"`
+	//capabilities defines the capabilities added to the NF container on top of the ones of the profile,
+	//only NET_BIND_SERVICE can be added by the restricted profile
+	// +optional
+	Capabilities []corev1.Capability `json:"capabilities,omitempty"`
+	//runAsUser defines the user of the containers, needed by the restricted profile when the image runs as root
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+}
+
+// ProbeThresholds defines the timing and the thresholds of a probe, the defaults of the probe when not set
+type ProbeThresholds struct {
+	//initialDelaySeconds defines the delay before the first probe
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+	//periodSeconds defines the interval between the probes
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+	//timeoutSeconds defines the timeout of a probe
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+	//failureThreshold defines the number of failed probes after which the probe fails
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// ProbesConfig defines the probes of the NF container of an OAI NF
+type ProbesConfig struct {
+	//disabled removes all the probes of the NF container
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	//processCheck makes the liveness probe check the state of the softmodem process instead of its telnet server or its interfaces
+	// +optional
+	ProcessCheck bool `json:"processCheck,omitempty"`
+	//startup defines the thresholds of the startup probe, checking the interface the NF serves is bound
+	// +optional
+	Startup *ProbeThresholds `json:"startup,omitempty"`
+	//readiness defines the thresholds of the readiness probe, checking the SCTP association of the NF towards its peer
+	// +optional
+	Readiness *ProbeThresholds `json:"readiness,omitempty"`
+	//liveness defines the thresholds of the liveness probe
+	// +optional
+	Liveness *ProbeThresholds `json:"liveness,omitempty"`
 }
 
 // OAIConfigStatus defines the observed state of OAIConfig
@@ -20,20 +20,97 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// RadioMode defines how the DU drives its radio unit
+// +kubebuilder:validation:Enum=rfsim;usrp
+type RadioMode string
+
+const (
+	// RadioModeRFSim runs the DU against the OAI rfsimulator
+	RadioModeRFSim RadioMode = "rfsim"
+	// RadioModeUSRP runs the DU against a real USRP radio (e.g. B210, N310)
+	RadioModeUSRP RadioMode = "usrp"
+)
+
+// +kubebuilder:object:generate=true
+
 // RANNfConfigSpec defines the desired state of RANNfConfig
 type RANConfigSpec struct {
+	// The primary cell of the DU is defined inline
+	RANCellConfig `json:",inline"`
+	//cells defines the additional cells served by the same DU, each one is rendered as its own gNB entry
+	// +optional
+	Cells []RANCellConfig `json:"cells,omitempty"`
+	//radioMode selects between the rfsimulator and a real radio unit for the DU
+	// +optional
+	// +kubebuilder:default=rfsim
+	RadioMode RadioMode `json:"radioMode,omitempty"`
+	//radioUnit defines the radio unit parameters, required when radioMode is usrp
+	// +optional
+	RadioUnit *RadioUnit `json:"radioUnit,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+
+// RANCellConfig defines the radio parameters of a single cell
+type RANCellConfig struct {
 	//cellIdentity defines the cell identity of a cell
 	CellIdentity string `json:"cellIdentity"`
 	//physicalCellId defines the physical cell identity of a cell
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=503
-	PhysicalCellID            uint32 `json:"physicalCellID"`
-	DownlinkFrequencyBand     uint32 `json:"downlinkFrequencyBand"`
-	DownlinkSubCarrierSpacing uint16 `json:"downlinkSubCarrierSpacing"`
-	DownlinkCarrierBandwidth  uint32 `json:"downlinkCarrierBandwidth"`
-	UplinkFrequencyBand       uint32 `json:"uplinkFrequencyBand"`
-	UplinkSubCarrierSpacing   uint16 `json:"uplinkSubCarrierSpacing"`
-	UplinkCarrierBandwidth    uint32 `json:"uplinkCarrierBandwidth"`
+	PhysicalCellID uint32 `json:"physicalCellID"`
+	//absoluteFrequencySSB defines the NR-ARFCN of the SSB of the cell
+	// +optional
+	// +kubebuilder:default=640704
+	AbsoluteFrequencySSB uint32 `json:"absoluteFrequencySSB,omitempty"`
+	//downlinkAbsoluteFrequencyPointA defines the NR-ARFCN of the downlink point A of the cell
+	// +optional
+	// +kubebuilder:default=639996
+	DownlinkAbsoluteFrequencyPointA uint32 `json:"downlinkAbsoluteFrequencyPointA,omitempty"`
+	DownlinkFrequencyBand           uint32 `json:"downlinkFrequencyBand"`
+	DownlinkSubCarrierSpacing       uint16 `json:"downlinkSubCarrierSpacing"`
+	DownlinkCarrierBandwidth        uint32 `json:"downlinkCarrierBandwidth"`
+	UplinkFrequencyBand             uint32 `json:"uplinkFrequencyBand"`
+	UplinkSubCarrierSpacing         uint16 `json:"uplinkSubCarrierSpacing"`
+	UplinkCarrierBandwidth          uint32 `json:"uplinkCarrierBandwidth"`
+}
+
+// GetCells returns all the cells of the RANConfig, starting with the primary cell
+func (spec *RANConfigSpec) GetCells() []RANCellConfig {
+	return append([]RANCellConfig{spec.RANCellConfig}, spec.Cells...)
+}
+
+// +kubebuilder:object:generate=true
+
+// RadioUnit defines the RU section of the DU configuration
+type RadioUnit struct {
+	//sdrAddrs defines the UHD device arguments, e.g. "type=b200" or "addr=192.168.10.2"
+	SdrAddrs string `json:"sdrAddrs"`
+	//clockSource defines the reference clock of the radio
+	// +optional
+	// +kubebuilder:validation:Enum=internal;external;gpsdo
+	// +kubebuilder:default=internal
+	ClockSource string `json:"clockSource,omitempty"`
+	//nbTx defines the number of transmit antennas
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	NbTx uint32 `json:"nbTx,omitempty"`
+	//nbRx defines the number of receive antennas
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	NbRx uint32 `json:"nbRx,omitempty"`
+	//attTx defines the transmit attenuation in dB
+	// +optional
+	AttTx uint32 `json:"attTx,omitempty"`
+	//attRx defines the receive attenuation in dB
+	// +optional
+	AttRx uint32 `json:"attRx,omitempty"`
+	//maxRxGain defines the maximum receive gain in dB
+	// +optional
+	// +kubebuilder:default=114
+	MaxRxGain uint32 `json:"maxRxGain,omitempty"`
 }
 
 // RANConfigStatus defines the observed state of RANConfig
@@ -21,9 +21,110 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComputeConfig) DeepCopyInto(out *ComputeConfig) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hugepages != nil {
+		in, out := &in.Hugepages, &out.Hugepages
+		*out = new(HugepagesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RuntimeClassName != nil {
+		in, out := &in.RuntimeClassName, &out.RuntimeClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputeConfig.
+func (in *ComputeConfig) DeepCopy() *ComputeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ComputeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *E2AgentConfig) DeepCopyInto(out *E2AgentConfig) {
+	*out = *in
+	if in.ServiceModels != nil {
+		in, out := &in.ServiceModels, &out.ServiceModels
+		*out = make([]E2ServiceModel, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new E2AgentConfig.
+func (in *E2AgentConfig) DeepCopy() *E2AgentConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(E2AgentConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvVar) DeepCopyInto(out *EnvVar) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvVar.
+func (in *EnvVar) DeepCopy() *EnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvVar)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HugepagesConfig) DeepCopyInto(out *HugepagesConfig) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HugepagesConfig.
+func (in *HugepagesConfig) DeepCopy() *HugepagesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HugepagesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NSSAI) DeepCopyInto(out *NSSAI) {
 	*out = *in
@@ -44,12 +145,39 @@ func (in *NSSAI) DeepCopy() *NSSAI {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
+	*out = *in
+	if in.MTU != nil {
+		in, out := &in.MTU, &out.MTU
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]StaticRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfig.
+func (in *NetworkConfig) DeepCopy() *NetworkConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OAIConfig) DeepCopyInto(out *OAIConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -103,6 +231,58 @@ func (in *OAIConfigList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OAIConfigSpec) DeepCopyInto(out *OAIConfigSpec) {
+	*out = *in
+	if in.E2Agent != nil {
+		in, out := &in.E2Agent, &out.E2Agent
+		*out = new(E2AgentConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Networks != nil {
+		in, out := &in.Networks, &out.Networks
+		*out = make([]NetworkConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Telnet != nil {
+		in, out := &in.Telnet, &out.Telnet
+		*out = new(TelnetConfig)
+		**out = **in
+	}
+	if in.Runtime != nil {
+		in, out := &in.Runtime, &out.Runtime
+		*out = new(RuntimeOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Compute != nil {
+		in, out := &in.Compute, &out.Compute
+		*out = new(ComputeConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Security != nil {
+		in, out := &in.Security, &out.Security
+		*out = new(SecurityConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(ProbesConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OAIConfigSpec.
+func (in *OAIConfigSpec) DeepCopy() *OAIConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OAIConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PLMN) DeepCopyInto(out *PLMN) {
 	*out = *in
@@ -222,12 +402,92 @@ func (in *PLMNSpec) DeepCopy() *PLMNSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeThresholds) DeepCopyInto(out *ProbeThresholds) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeThresholds.
+func (in *ProbeThresholds) DeepCopy() *ProbeThresholds {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeThresholds)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbesConfig) DeepCopyInto(out *ProbesConfig) {
+	*out = *in
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(ProbeThresholds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(ProbeThresholds)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(ProbeThresholds)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbesConfig.
+func (in *ProbesConfig) DeepCopy() *ProbesConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbesConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RANCellConfig) DeepCopyInto(out *RANCellConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RANCellConfig.
+func (in *RANCellConfig) DeepCopy() *RANCellConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RANCellConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RANConfig) DeepCopyInto(out *RANConfig) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 }
 
@@ -280,3 +540,134 @@ func (in *RANConfigList) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RANConfigSpec) DeepCopyInto(out *RANConfigSpec) {
+	*out = *in
+	out.RANCellConfig = in.RANCellConfig
+	if in.Cells != nil {
+		in, out := &in.Cells, &out.Cells
+		*out = make([]RANCellConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.RadioUnit != nil {
+		in, out := &in.RadioUnit, &out.RadioUnit
+		*out = new(RadioUnit)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RANConfigSpec.
+func (in *RANConfigSpec) DeepCopy() *RANConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RANConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RadioUnit) DeepCopyInto(out *RadioUnit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RadioUnit.
+func (in *RadioUnit) DeepCopy() *RadioUnit {
+	if in == nil {
+		return nil
+	}
+	out := new(RadioUnit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeOptions) DeepCopyInto(out *RuntimeOptions) {
+	*out = *in
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]EnvVar, len(*in))
+		copy(*out, *in)
+	}
+	if in.LogOptions != nil {
+		in, out := &in.LogOptions, &out.LogOptions
+		*out = make([]LogOption, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeOptions.
+func (in *RuntimeOptions) DeepCopy() *RuntimeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityConfig) DeepCopyInto(out *SecurityConfig) {
+	*out = *in
+	if in.Capabilities != nil {
+		in, out := &in.Capabilities, &out.Capabilities
+		*out = make([]v1.Capability, len(*in))
+		copy(*out, *in)
+	}
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityConfig.
+func (in *SecurityConfig) DeepCopy() *SecurityConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticRoute) DeepCopyInto(out *StaticRoute) {
+	*out = *in
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticRoute.
+func (in *StaticRoute) DeepCopy() *StaticRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelnetConfig) DeepCopyInto(out *TelnetConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelnetConfig.
+func (in *TelnetConfig) DeepCopy() *TelnetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TelnetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
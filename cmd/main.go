@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -26,6 +27,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -53,11 +55,20 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var dryRun bool
+	var telnetMetricsInterval time.Duration
+	var macStatsCommand string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":9443", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&dryRun, "dry-run", false,
+		"Report the changes to the resources of the NFDeployments in their status and events instead of making them.")
+	flag.DurationVar(&telnetMetricsInterval, "telnet-metrics-interval", 30*time.Second,
+		"The period of the polls of the telnet servers of the NFs for their state and the cell metrics of the DUs, 0 disables them.")
+	flag.StringVar(&macStatsCommand, "telnet-mac-stats-command", "",
+		"The telnet command printing the MAC statistics of the UEs of the DUs for the BLER, MCS and bytes of their cell, empty disables it.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -109,13 +120,31 @@ func main() {
 		setupLog.Error(err, "Not able to register workload/v1alpha1 NFDeployment kind")
 	}
 
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create the clientset reading the logs")
+		os.Exit(1)
+	}
 	if err = (&controller.RANDeploymentReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		DryRun:    dryRun,
+		Recorder:  mgr.GetEventRecorderFor("ran-deployment-controller"),
+		LogReader: controller.NewPodLogReader(clientset),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "RANDeployment")
 		os.Exit(1)
 	}
+	if telnetMetricsInterval > 0 {
+		if err := mgr.Add(&controller.TelnetMetricsPoller{
+			Client:          mgr.GetClient(),
+			Interval:        telnetMetricsInterval,
+			MacStatsCommand: macStatsCommand,
+		}); err != nil {
+			setupLog.Error(err, "unable to add the telnet metrics poller")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// pathAnnotation is the file of the package an item is written to by kpt
+	pathAnnotation = "internal.config.kubernetes.io/path"
+	// legacyPathAnnotation is pathAnnotation for the kpt and kustomize versions before the ResourceList v1
+	legacyPathAnnotation = "config.kubernetes.io/path"
+	// renderedFromAnnotation marks the items rendered by the function with the name of their NFDeployment,
+	// they are replaced each time the function runs
+	renderedFromAnnotation = "workload.nephio.org/rendered-from"
+	// localConfigAnnotation keeps the rendered items out of the resources applied to the cluster: the controller
+	// creates them itself and would fail on the ones applied with the package
+	localConfigAnnotation = "config.kubernetes.io/local-config"
+)
+
+// resourceList is the input and output of a KRM function, see
+// https://github.com/kubernetes-sigs/kustomize/blob/master/cmd/config/docs/api-conventions/functions-spec.md
+type resourceList struct {
+	APIVersion     string           `json:"apiVersion"`
+	Kind           string           `json:"kind"`
+	Items          []map[string]any `json:"items"`
+	FunctionConfig map[string]any   `json:"functionConfig,omitempty"`
+	Results        []result         `json:"results,omitempty"`
+}
+
+// result reports an error of the function on an item
+type result struct {
+	Message     string       `json:"message"`
+	Severity    string       `json:"severity"`
+	ResourceRef *resourceRef `json:"resourceRef,omitempty"`
+}
+
+type resourceRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+// runFunction renders the NFDeployments of the ResourceList read from reader and writes it to writer along with the
+// rendered resources, marked as local config so that they are reviewed in the package but not applied. The data of the functionConfig may select the NFDeployment to render with nf.
+// It returns an error when a NFDeployment cannot be rendered, after writing the ResourceList with the error results
+func runFunction(reader io.Reader, writer io.Writer) error {
+	input, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	list := &resourceList{}
+	if err := yaml.Unmarshal(input, list); err != nil {
+		return fmt.Errorf("cannot decode the ResourceList: %w", err)
+	}
+	nfName, _, _ := unstructured.NestedString(list.FunctionConfig, "data", "nf")
+
+	items := []map[string]any{}
+	nfPackage := &nfPackage{}
+	for _, item := range list.Items {
+		document := &unstructured.Unstructured{Object: item}
+		if _, found := document.GetAnnotations()[renderedFromAnnotation]; found {
+			continue
+		}
+		items = append(items, item)
+		if err := nfPackage.add(document, ""); err != nil {
+			list.Results = append(list.Results, errorResult(document, "cannot decode: "+err.Error()))
+		}
+	}
+
+	for _, nfDeployment := range nfPackage.nfDeployments() {
+		if nfName != "" && nfDeployment.Name != nfName {
+			continue
+		}
+		document := &unstructured.Unstructured{}
+		document.SetGroupVersionKind(nfDeployment.GroupVersionKind())
+		document.SetName(nfDeployment.Name)
+		document.SetNamespace(nfDeployment.Namespace)
+
+		rendered, err := nfPackage.render(context.Background(), nfDeployment)
+		if err != nil {
+			list.Results = append(list.Results, errorResult(document, err.Error()))
+			continue
+		}
+		for _, object := range renderedObjects(rendered) {
+			item, err := toItem(object)
+			if err != nil {
+				list.Results = append(list.Results, errorResult(document, err.Error()))
+				continue
+			}
+			renderedItem := &unstructured.Unstructured{Object: item}
+			annotations := renderedItem.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			path := fmt.Sprintf("%s/%s_%s.yaml", nfDeployment.Name, strings.ToLower(renderedItem.GetKind()), renderedItem.GetName())
+			annotations[pathAnnotation] = path
+			annotations[legacyPathAnnotation] = path
+			annotations[renderedFromAnnotation] = nfDeployment.Name
+			annotations[localConfigAnnotation] = "true"
+			renderedItem.SetAnnotations(annotations)
+			items = append(items, item)
+		}
+	}
+
+	list.Items = items
+	output, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(output); err != nil {
+		return err
+	}
+	if len(list.Results) > 0 {
+		return fmt.Errorf("%d NFDeployment(s) cannot be rendered", len(list.Results))
+	}
+	return nil
+}
+
+func errorResult(document *unstructured.Unstructured, message string) result {
+	return result{
+		Message:  message,
+		Severity: "error",
+		ResourceRef: &resourceRef{
+			APIVersion: document.GetAPIVersion(),
+			Kind:       document.GetKind(),
+			Name:       document.GetName(),
+			Namespace:  document.GetNamespace(),
+		},
+	}
+}
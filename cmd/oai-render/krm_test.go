@@ -0,0 +1,203 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const cuCpResourceList = `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: workload.nephio.org/v1alpha1
+  kind: NFDeployment
+  metadata:
+    name: cucp-regional
+  spec:
+    provider: cucp.openairinterface.org
+    interfaces:
+    - name: n2
+      ipv4:
+        address: 172.6.0.254/24
+    - name: e1
+      ipv4:
+        address: 172.5.1.3/24
+    - name: f1c
+      ipv4:
+        address: 172.6.0.7/24
+    parametersRefs:
+    - apiVersion: ref.nephio.org/v1alpha1
+      kind: Config
+      name: amf-core
+- apiVersion: ref.nephio.org/v1alpha1
+  kind: Config
+  metadata:
+    name: amf-core
+  spec:
+    config:
+      apiVersion: workload.nephio.org/v1alpha1
+      kind: NFDeployment
+      metadata:
+        name: amf-core
+      spec:
+        provider: amf.openairinterface.org
+        interfaces:
+        - name: n2
+          ipv4:
+            address: 172.2.0.254/24
+- apiVersion: workload.nephio.org/v1alpha1
+  kind: PLMN
+  metadata:
+    name: plmn
+  spec:
+    PLMNInfo:
+    - plmnID:
+        mcc: "001"
+        mnc: "01"
+      tac: 1
+      nssai:
+      - sst: 1
+        sd: ffffff
+- apiVersion: workload.nephio.org/v1alpha1
+  kind: RANConfig
+  metadata:
+    name: ran
+  spec:
+    cellIdentity: "12345678L"
+    physicalCellID: 0
+    downlinkFrequencyBand: 78
+    downlinkSubCarrierSpacing: 1
+    downlinkCarrierBandwidth: 106
+    uplinkFrequencyBand: 78
+    uplinkSubCarrierSpacing: 1
+    uplinkCarrierBandwidth: 106
+- apiVersion: workload.nephio.org/v1alpha1
+  kind: OAIConfig
+  metadata:
+    name: oai
+  spec:
+    image: docker.io/oaisoftwarealliance/oai-gnb:develop
+`
+
+const secondPlmn = `- apiVersion: workload.nephio.org/v1alpha1
+  kind: PLMN
+  metadata:
+    name: plmn-208
+  spec:
+    PLMNInfo:
+    - plmnID:
+        mcc: "208"
+        mnc: "95"
+      tac: 1
+      nssai:
+      - sst: 1
+        sd: ffffff
+`
+
+func TestRunFunction(t *testing.T) {
+	cases := map[string]struct {
+		input      string
+		wantItems  int
+		wantMcc    string
+		wantsError bool
+	}{
+		"CU-CP": {
+			input:     cuCpResourceList,
+			wantItems: 8,
+			wantMcc:   "001",
+		},
+		"PLMN Referenced By The NFDeployment": {
+			input: strings.Replace(cuCpResourceList+secondPlmn, "      name: amf-core\n- apiVersion",
+				"      name: amf-core\n    - apiVersion: workload.nephio.org/v1alpha1\n      kind: PLMN\n      name: plmn-208\n- apiVersion", 1),
+			wantItems: 9,
+			wantMcc:   "208",
+		},
+		"Several PLMNs Not Referenced": {
+			input:      cuCpResourceList + secondPlmn,
+			wantItems:  6,
+			wantsError: true,
+		},
+		"PLMN Without Slice": {
+			input:      strings.Replace(cuCpResourceList, "      nssai:\n      - sst: 1\n        sd: ffffff\n", "", 1),
+			wantItems:  5,
+			wantsError: true,
+		},
+		"Other NF Selected": {
+			input:     cuCpResourceList + "functionConfig:\n  apiVersion: v1\n  kind: ConfigMap\n  data:\n    nf: du-edge\n",
+			wantItems: 5,
+		},
+		"PLMN Missing": {
+			input:      strings.Replace(cuCpResourceList, "kind: PLMN", "kind: PLMNs", 1),
+			wantItems:  5,
+			wantsError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			err := runFunction(strings.NewReader(tc.input), output)
+			if (err != nil) != tc.wantsError {
+				t.Fatalf("runFunction returned error %v, wants error %v: %s", err, tc.wantsError, output)
+			}
+			list := &resourceList{}
+			if err := yaml.Unmarshal(output.Bytes(), list); err != nil {
+				t.Fatalf("runFunction wrote an invalid ResourceList: %v", err)
+			}
+			if len(list.Items) != tc.wantItems {
+				t.Errorf("runFunction wrote %d items, expected %d", len(list.Items), tc.wantItems)
+			}
+			if tc.wantsError {
+				if len(list.Results) != 1 || list.Results[0].ResourceRef.Name != "cucp-regional" {
+					t.Errorf("runFunction wrote the results %v, expected an error on cucp-regional", list.Results)
+				}
+				return
+			}
+
+			// The rendered items follow the items of the input
+			for _, item := range list.Items[strings.Count(tc.input, "\n- apiVersion"):] {
+				document := &unstructured.Unstructured{Object: item}
+				if document.GetAnnotations()[renderedFromAnnotation] != "cucp-regional" || !strings.HasPrefix(document.GetAnnotations()[pathAnnotation], "cucp-regional/") {
+					t.Errorf("%s %s is not annotated as rendered from cucp-regional: %v", document.GetKind(), document.GetName(), document.GetAnnotations())
+				}
+				if document.GetAnnotations()[localConfigAnnotation] != "true" {
+					t.Errorf("%s %s is not marked as local config, it would be applied next to the one of the controller", document.GetKind(), document.GetName())
+				}
+				if document.GetKind() == "ConfigMap" {
+					gnbConf, _, _ := unstructured.NestedString(item, "data", "gnb.conf")
+					if !strings.Contains(gnbConf, "mcc = "+tc.wantMcc) || !strings.Contains(gnbConf, "\"172.2.0.254\"") {
+						t.Errorf("gnb.conf doesn't render the PLMN and the AMF of the package: %s", gnbConf)
+					}
+				}
+			}
+
+			// The rendered items are replaced when the function runs again
+			rerun := &bytes.Buffer{}
+			if err := runFunction(bytes.NewReader(output.Bytes()), rerun); err != nil {
+				t.Fatalf("runFunction returned error %v on its output", err)
+			}
+			if rerun.String() != output.String() {
+				t.Errorf("runFunction is not idempotent, wrote %s then %s", output, rerun)
+			}
+		})
+	}
+}
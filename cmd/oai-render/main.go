@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// oai-render prints the resources the controller creates for a NFDeployment, reading the
+// NFDeployment and the Config and NFConfig it references from YAML files instead of a cluster.
+// With -krm it runs as a KRM function, rendering the NFDeployments of the ResourceList read on stdin
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/yaml"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+)
+
+// readPackage reads the objects the controller reads from the YAML files of paths, the directories are read
+// recursively. The objects without namespace are put in namespace
+func readPackage(paths []string, namespace string) (*nfPackage, error) {
+	nfPackage := &nfPackage{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (path != root && !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml")) {
+				return nil
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+			for {
+				document := &unstructured.Unstructured{}
+				if err := decoder.Decode(&document.Object); err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				if len(document.Object) == 0 {
+					continue
+				}
+				if err := nfPackage.add(document, namespace); err != nil {
+					return fmt.Errorf("%s: cannot decode %s %s: %w", path, document.GetKind(), document.GetName(), err)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nfPackage, nil
+}
+
+// selectNfDeployment returns the NFDeployment named name, or the only NFDeployment of the package when name is empty
+func selectNfDeployment(nfPackage *nfPackage, name string) (*workloadv1alpha1.NFDeployment, error) {
+	nfDeployments := slices.DeleteFunc(nfPackage.nfDeployments(), func(nfDeployment *workloadv1alpha1.NFDeployment) bool {
+		return name != "" && nfDeployment.Name != name
+	})
+	switch {
+	case len(nfDeployments) == 1:
+		return nfDeployments[0], nil
+	case name != "":
+		return nil, fmt.Errorf("NFDeployment %q not found", name)
+	case len(nfDeployments) == 0:
+		return nil, fmt.Errorf("no NFDeployment found")
+	default:
+		return nil, fmt.Errorf("%d NFDeployments found, select one with -nf", len(nfDeployments))
+	}
+}
+
+// writeObjects writes objects as a YAML stream
+func writeObjects(writer io.Writer, objects []client.Object) error {
+	for _, object := range objects {
+		item, err := toItem(object)
+		if err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(writer, "---\n%s", out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func render(paths []string, namespace string, nfName string, gnbConf bool, writer io.Writer) error {
+	nfPackage, err := readPackage(paths, namespace)
+	if err != nil {
+		return err
+	}
+	nfDeployment, err := selectNfDeployment(nfPackage, nfName)
+	if err != nil {
+		return err
+	}
+	rendered, err := nfPackage.render(context.Background(), nfDeployment)
+	if err != nil {
+		return err
+	}
+
+	if gnbConf {
+		_, err := io.WriteString(writer, rendered.ConfigMaps[0].Data["gnb.conf"])
+		return err
+	}
+	return writeObjects(writer, renderedObjects(rendered))
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run parses the flags and renders the NFDeployment, it returns the exit code so that the output file is closed
+// before exiting
+func run() int {
+	var namespace string
+	var nfName string
+	var gnbConf bool
+	var output string
+	var krm bool
+	flag.StringVar(&namespace, "namespace", "default", "The namespace of the objects of the files without namespace.")
+	flag.StringVar(&nfName, "nf", "", "The name of the NFDeployment to render, when the files have several.")
+	flag.BoolVar(&gnbConf, "gnb-conf", false, "Write only the gnb.conf of the NF instead of its resources.")
+	flag.StringVar(&output, "o", "", "The file to write to, the standard output when not set.")
+	flag.BoolVar(&krm, "krm", false, "Run as a KRM function, reading a ResourceList on stdin and writing it on stdout.")
+	opts := zap.Options{
+		Development: true,
+		DestWriter:  os.Stderr,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] FILE|DIR...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	if krm {
+		if err := runFunction(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		return 2
+	}
+
+	writer := io.Writer(os.Stdout)
+	var file *os.File
+	if output != "" {
+		var err error
+		file, err = os.Create(output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer file.Close()
+		writer = file
+	}
+	if err := render(flag.Args(), namespace, nfName, gnbConf, writer); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if file != nil {
+		// The error of the last write to the file may only be reported when closing it
+		if err := file.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	refv1alpha1 "github.com/nephio-project/api/references/v1alpha1"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	runscheme "sigs.k8s.io/controller-runtime/pkg/scheme"
+
+	"workload.nephio.org/ran_deployment/internal/controller"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+	schemeBuilder := &runscheme.Builder{GroupVersion: refv1alpha1.GroupVersion}
+	schemeBuilder.Register(&refv1alpha1.Config{}, &refv1alpha1.ConfigList{})
+	utilruntime.Must(schemeBuilder.AddToScheme(scheme))
+
+	schemeBuilder = &runscheme.Builder{GroupVersion: workloadv1alpha1.GroupVersion}
+	schemeBuilder.Register(&workloadv1alpha1.NFConfig{}, &workloadv1alpha1.NFConfigList{})
+	schemeBuilder.Register(&workloadv1alpha1.NFDeployment{}, &workloadv1alpha1.NFDeploymentList{})
+	utilruntime.Must(schemeBuilder.AddToScheme(scheme))
+}
+
+// nfPackage holds the objects of a package the controller reads
+type nfPackage struct {
+	// objects are the Config, NFConfig and NFDeployment of the package
+	objects []client.Object
+	// nfConfigs are the PLMN, RANConfig and OAIConfig given as objects of the package instead of in a NFConfig
+	nfConfigs []*unstructured.Unstructured
+}
+
+// add adds a document of a package when it is of a kind the controller reads. The objects without namespace are put in namespace
+func (nfPackage *nfPackage) add(document *unstructured.Unstructured, namespace string) error {
+	gvk := document.GroupVersionKind()
+	if gvk.GroupVersion() != refv1alpha1.GroupVersion && gvk.GroupVersion() != workloadv1alpha1.GroupVersion {
+		return nil
+	}
+	if slices.Contains(controller.GetMandatoryNfKinds(), gvk.Kind) {
+		nfPackage.nfConfigs = append(nfPackage.nfConfigs, document)
+		return nil
+	}
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		// Other kinds of the Nephio APIs, e.g. the claims of a package
+		return nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(document.Object, typed); err != nil {
+		return err
+	}
+	object := typed.(client.Object)
+	if object.GetNamespace() == "" {
+		object.SetNamespace(namespace)
+	}
+	nfPackage.objects = append(nfPackage.objects, object)
+	return nil
+}
+
+// nfDeployments returns the NFDeployments of the package of the providers of the controller
+func (nfPackage *nfPackage) nfDeployments() []*workloadv1alpha1.NFDeployment {
+	nfDeployments := []*workloadv1alpha1.NFDeployment{}
+	for _, object := range nfPackage.objects {
+		if nfDeployment, ok := object.(*workloadv1alpha1.NFDeployment); ok && slices.Contains(controller.GetSupportedProviders(), nfDeployment.Spec.Provider) {
+			nfDeployments = append(nfDeployments, nfDeployment)
+		}
+	}
+	return nfDeployments
+}
+
+// hasNfConfig tells whether the package has the NFConfig namespace/name
+func (nfPackage *nfPackage) hasNfConfig(namespace string, name string) bool {
+	return slices.ContainsFunc(nfPackage.objects, func(object client.Object) bool {
+		_, ok := object.(*workloadv1alpha1.NFConfig)
+		return ok && object.GetNamespace() == namespace && object.GetName() == name
+	})
+}
+
+// nfConfigsOf returns the PLMN, RANConfig and OAIConfig objects of the package a NFDeployment uses: for each kind, the
+// one its parametersRefs name, or the only one of the package when it names none. The references to these objects are
+// removed from the NFDeployment, which must be a copy
+func (nfPackage *nfPackage) nfConfigsOf(nfDeployment *workloadv1alpha1.NFDeployment) ([]runtime.RawExtension, error) {
+	named := map[string]*unstructured.Unstructured{}
+	refs := []workloadv1alpha1.ObjectReference{}
+	for _, ref := range nfDeployment.Spec.ParametersRefs {
+		if ref.APIVersion != workloadv1alpha1.GroupVersion.String() || !slices.Contains(controller.GetMandatoryNfKinds(), ref.Kind) {
+			refs = append(refs, ref)
+			continue
+		}
+		index := slices.IndexFunc(nfPackage.nfConfigs, func(nfConfig *unstructured.Unstructured) bool {
+			return nfConfig.GetKind() == ref.Kind && ref.Name != nil && nfConfig.GetName() == *ref.Name
+		})
+		if index < 0 {
+			return nil, fmt.Errorf("%s %s referenced by %s is not in the package", ref.Kind, ptr.Deref(ref.Name, ""), nfDeployment.Name)
+		}
+		named[ref.Kind] = nfPackage.nfConfigs[index]
+	}
+	nfDeployment.Spec.ParametersRefs = refs
+
+	configRefs := []runtime.RawExtension{}
+	for _, kind := range controller.GetMandatoryNfKinds() {
+		nfConfig, found := named[kind]
+		if !found {
+			var ofKind []*unstructured.Unstructured
+			for _, candidate := range nfPackage.nfConfigs {
+				if candidate.GetKind() == kind {
+					ofKind = append(ofKind, candidate)
+				}
+			}
+			if len(ofKind) > 1 {
+				return nil, fmt.Errorf("the package has %d %s, the parametersRefs of %s must name the one it uses", len(ofKind), kind, nfDeployment.Name)
+			}
+			if len(ofKind) == 0 {
+				continue
+			}
+			nfConfig = ofKind[0]
+		}
+		raw, err := nfConfig.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		configRefs = append(configRefs, runtime.RawExtension{Raw: raw})
+	}
+	return configRefs, nil
+}
+
+// render renders the resources of a NFDeployment of the package. The PLMN, RANConfig and OAIConfig objects of the
+// package the NFDeployment uses are bundled in the NFConfigs it references and the package doesn't have, or in a
+// NFConfig named after the NFDeployment when it references none
+func (nfPackage *nfPackage) render(ctx context.Context, nfDeployment *workloadv1alpha1.NFDeployment) (*controller.RenderedResources, error) {
+	objects := slices.Clone(nfPackage.objects)
+	nfDeployment = nfDeployment.DeepCopy()
+	configRefs, err := nfPackage.nfConfigsOf(nfDeployment)
+	if err != nil {
+		return nil, err
+	}
+	if len(configRefs) > 0 {
+		nfConfigNames := []string{}
+		for _, ref := range nfDeployment.Spec.ParametersRefs {
+			if ref.APIVersion == workloadv1alpha1.GroupVersion.String() && ref.Name != nil &&
+				!nfPackage.hasNfConfig(nfDeployment.Namespace, *ref.Name) {
+				nfConfigNames = append(nfConfigNames, *ref.Name)
+			}
+		}
+		if !slices.ContainsFunc(nfDeployment.Spec.ParametersRefs, func(ref workloadv1alpha1.ObjectReference) bool {
+			return ref.APIVersion == workloadv1alpha1.GroupVersion.String()
+		}) {
+			nfDeployment.Spec.ParametersRefs = append(nfDeployment.Spec.ParametersRefs, workloadv1alpha1.ObjectReference{
+				APIVersion: workloadv1alpha1.GroupVersion.String(),
+				Kind:       "NFConfig",
+				Name:       ptr.To(nfDeployment.Name),
+			})
+			nfConfigNames = append(nfConfigNames, nfDeployment.Name)
+		}
+		for _, name := range nfConfigNames {
+			objects = append(objects, &workloadv1alpha1.NFConfig{
+				ObjectMeta: metav1.ObjectMeta{Namespace: nfDeployment.Namespace, Name: name},
+				Spec:       workloadv1alpha1.NFConfigSpec{ConfigRefs: configRefs},
+			})
+		}
+	}
+
+	reconciler := &controller.RANDeploymentReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Scheme: scheme,
+	}
+	return reconciler.Render(ctx, nfDeployment)
+}
+
+// renderedObjects returns the resources of a NFDeployment in the order CreateAll creates them
+func renderedObjects(rendered *controller.RenderedResources) []client.Object {
+	objects := []client.Object{}
+	for _, resource := range rendered.NetworkAttachmentDefinitions {
+		objects = append(objects, resource)
+	}
+	for _, resource := range rendered.ServiceAccounts {
+		objects = append(objects, resource)
+	}
+	for _, resource := range rendered.ConfigMaps {
+		objects = append(objects, resource)
+	}
+	for _, resource := range rendered.Deployments {
+		objects = append(objects, resource)
+	}
+	for _, resource := range rendered.Services {
+		objects = append(objects, resource)
+	}
+	return objects
+}
+
+// toItem returns the fields of an object as written to a package, without its empty creation timestamp and status
+func toItem(object client.Object) (map[string]any, error) {
+	if object.GetObjectKind().GroupVersionKind().Empty() {
+		gvk, err := apiutil.GVKForObject(object, scheme)
+		if err != nil {
+			return nil, err
+		}
+		object.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	item := map[string]any{}
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(item, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(item, "status")
+	return item, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+const (
+	defaultAbsoluteFrequencySSB            = 640704 // 3610.56 MHz
+	defaultDownlinkAbsoluteFrequencyPointA = 639996 // 3599.94 MHz
+)
+
+// getCellTemplateValues returns the template values of every cell of the DU, the primary cell keeps the name "oai-du"
+func getCellTemplateValues(ranConfigSpec *workloadnfconfig.RANConfigSpec) []configurationTemplateValuesForCell {
+	cells := ranConfigSpec.GetCells()
+	cellTemplateValues := make([]configurationTemplateValuesForCell, 0, len(cells))
+	for index, cell := range cells {
+		gnbName := "oai-du"
+		if index > 0 {
+			gnbName = fmt.Sprintf("oai-du-%d", index)
+		}
+		absoluteFrequencySSB := cell.AbsoluteFrequencySSB
+		if absoluteFrequencySSB == 0 {
+			absoluteFrequencySSB = defaultAbsoluteFrequencySSB
+		}
+		downlinkPointA := cell.DownlinkAbsoluteFrequencyPointA
+		if downlinkPointA == 0 {
+			downlinkPointA = defaultDownlinkAbsoluteFrequencyPointA
+		}
+		cellTemplateValues = append(cellTemplateValues, configurationTemplateValuesForCell{
+			GNB_NAME:      gnbName,
+			CELL_ID:       cell.CellIdentity,
+			PHY_CELL_ID:   cell.PhysicalCellID,
+			ABS_FREQ_SSB:  absoluteFrequencySSB,
+			DL_POINT_A:    downlinkPointA,
+			DL_FREQ_BAND:  cell.DownlinkFrequencyBand,
+			DL_SCS:        cell.DownlinkSubCarrierSpacing,
+			DL_CARRIER_BW: cell.DownlinkCarrierBandwidth,
+			UL_FREQ_BAND:  cell.UplinkFrequencyBand,
+			UL_SCS:        cell.UplinkSubCarrierSpacing,
+			UL_CARRIER_BW: cell.UplinkCarrierBandwidth,
+		})
+	}
+	return cellTemplateValues
+}
+
+// normalizeCellIdentity makes "12345678L", "0xBC614E" and "12345678" comparable
+func normalizeCellIdentity(cellIdentity string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(cellIdentity), "lL")
+	if value, err := strconv.ParseUint(trimmed, 0, 64); err == nil {
+		return strconv.FormatUint(value, 10)
+	}
+	return cellIdentity
+}
+
+// CheckCellUniqueness returns an error if a cell identity or a physical cell id is used twice across the cells of the given DUs
+func CheckCellUniqueness(cellsByDu map[string][]workloadnfconfig.RANCellConfig) error {
+	duNames := make([]string, 0, len(cellsByDu))
+	for duName := range cellsByDu {
+		duNames = append(duNames, duName)
+	}
+	sort.Strings(duNames) // ensure consistent error messages
+
+	cellIdentityOwners := make(map[string]string)
+	physicalCellIdOwners := make(map[uint32]string)
+	for _, duName := range duNames {
+		for _, cell := range cellsByDu[duName] {
+			cellIdentity := normalizeCellIdentity(cell.CellIdentity)
+			if owner, found := cellIdentityOwners[cellIdentity]; found {
+				return fmt.Errorf("cell identity %s of %q is already used by %q", cell.CellIdentity, duName, owner)
+			}
+			cellIdentityOwners[cellIdentity] = duName
+
+			if owner, found := physicalCellIdOwners[cell.PhysicalCellID]; found {
+				return fmt.Errorf("physical cell id %d of %q is already used by %q", cell.PhysicalCellID, duName, owner)
+			}
+			physicalCellIdOwners[cell.PhysicalCellID] = duName
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestGetCellTemplateValues(t *testing.T) {
+	ranConfigSpec := workloadnfconfig.RANConfigSpec{
+		RANCellConfig: workloadnfconfig.RANCellConfig{
+			CellIdentity:          "12345678L",
+			PhysicalCellID:        1,
+			DownlinkFrequencyBand: 78,
+		},
+		Cells: []workloadnfconfig.RANCellConfig{
+			{
+				CellIdentity:                    "12345679L",
+				PhysicalCellID:                  2,
+				AbsoluteFrequencySSB:            641280,
+				DownlinkAbsoluteFrequencyPointA: 640008,
+				DownlinkFrequencyBand:           78,
+			},
+		},
+	}
+
+	want := []configurationTemplateValuesForCell{
+		{
+			GNB_NAME:     "oai-du",
+			CELL_ID:      "12345678L",
+			PHY_CELL_ID:  1,
+			ABS_FREQ_SSB: 640704,
+			DL_POINT_A:   639996,
+			DL_FREQ_BAND: 78,
+		},
+		{
+			GNB_NAME:     "oai-du-1",
+			CELL_ID:      "12345679L",
+			PHY_CELL_ID:  2,
+			ABS_FREQ_SSB: 641280,
+			DL_POINT_A:   640008,
+			DL_FREQ_BAND: 78,
+		},
+	}
+
+	got := getCellTemplateValues(&ranConfigSpec)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getCellTemplateValues returned %v wanted %v", got, want)
+	}
+}
+
+func TestCheckCellUniqueness(t *testing.T) {
+	cases := map[string]struct {
+		cellsByDu map[string][]workloadnfconfig.RANCellConfig
+		wantError bool
+	}{
+		"Distinct Cells": {
+			cellsByDu: map[string][]workloadnfconfig.RANCellConfig{
+				"du-a": {{CellIdentity: "12345678L", PhysicalCellID: 0}, {CellIdentity: "12345679L", PhysicalCellID: 1}},
+				"du-b": {{CellIdentity: "12345680L", PhysicalCellID: 2}},
+			},
+			wantError: false,
+		},
+		"Duplicate PCI Within a DU": {
+			cellsByDu: map[string][]workloadnfconfig.RANCellConfig{
+				"du-a": {{CellIdentity: "12345678L", PhysicalCellID: 0}, {CellIdentity: "12345679L", PhysicalCellID: 0}},
+			},
+			wantError: true,
+		},
+		"Duplicate PCI Across DUs": {
+			cellsByDu: map[string][]workloadnfconfig.RANCellConfig{
+				"du-a": {{CellIdentity: "12345678L", PhysicalCellID: 0}},
+				"du-b": {{CellIdentity: "12345679L", PhysicalCellID: 0}},
+			},
+			wantError: true,
+		},
+		"Duplicate Cell Identity In Another Notation": {
+			cellsByDu: map[string][]workloadnfconfig.RANCellConfig{
+				"du-a": {{CellIdentity: "12345678L", PhysicalCellID: 0}},
+				"du-b": {{CellIdentity: "0xBC614E", PhysicalCellID: 1}},
+			},
+			wantError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := CheckCellUniqueness(tc.cellsByDu)
+			if tc.wantError && err == nil {
+				t.Errorf("CheckCellUniqueness returned nil for %v, expecting error", tc.cellsByDu)
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("CheckCellUniqueness returned error %v", err)
+			}
+		})
+	}
+}
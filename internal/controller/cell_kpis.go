@@ -0,0 +1,225 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+// o1Stats is the output of the "o1 stats" command of the O1 module of the telnet server of the DU
+type o1Stats struct {
+	Config struct {
+		NrCellDu *struct {
+			Pci int    `json:"nrcelldu3gpp:nRPCI"`
+			Mcc string `json:"nrcelldu3gpp:mcc"`
+			Mnc string `json:"nrcelldu3gpp:mnc"`
+		} `json:"NRCELLDU"`
+	} `json:"o1-config"`
+	Operational struct {
+		NumUes int `json:"num-ues"`
+		// Load is the percentage of the PRBs used
+		Load       int `json:"load"`
+		Throughput []struct {
+			Rnti int `json:"rnti"`
+			// Downlink and Uplink are in kbit/s
+			Downlink float64 `json:"dl"`
+			Uplink   float64 `json:"ul"`
+		} `json:"ues-thp"`
+	} `json:"O1-Operational"`
+}
+
+// parseO1Stats parses the JSON output of "o1 stats", which is followed by the status of the command
+func parseO1Stats(output string) (*o1Stats, error) {
+	start := strings.Index(output, "{")
+	end := strings.LastIndex(output, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no statistics in the output of o1 stats: %q", output)
+	}
+	stats := &o1Stats{}
+	if err := json.Unmarshal([]byte(output[start:end+1]), stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// LogicalChannelStats are the bytes of a logical channel of a UE, i.e. of its RLC entity
+type LogicalChannelStats struct {
+	TxBytes uint64
+	RxBytes uint64
+}
+
+// UeMacStats are the MAC statistics of a UE dumped by the scheduler of the DU
+type UeMacStats struct {
+	Rnti string
+	// DownlinkBler and UplinkBler are the block error rates of the DLSCH and the ULSCH
+	DownlinkBler float64
+	UplinkBler   float64
+	DownlinkMcs  int
+	UplinkMcs    int
+	MacTxBytes   uint64
+	MacRxBytes   uint64
+	// LogicalChannels are the bytes of the logical channels of the UE by LCID
+	LogicalChannels map[int]LogicalChannelStats
+}
+
+var (
+	// "UE RNTI 5a47 CU-UE-ID 1 in-sync PH 48 dB PCMAX 20 dBm, average RSRP -74 (16 meas)"
+	macStatsUePattern = regexp.MustCompile(`^UE RNTI ([0-9a-f]+)`)
+	// "UE 5a47: dlsch_rounds 1183/1/0/0, dlsch_errors 0, pucch0_DTX 0, BLER 0.00000 MCS (0) 9"
+	macStatsBlerPattern = regexp.MustCompile(`^UE ([0-9a-f]+): (dlsch|ulsch)_rounds .*BLER ([0-9.]+) MCS \(\d+\) (\d+)`)
+	// "UE 5a47: MAC:    TX         114123 RX        1282497 bytes"
+	macStatsBytesPattern = regexp.MustCompile(`^UE ([0-9a-f]+): MAC:\s+TX\s+(\d+)\s+RX\s+(\d+) bytes`)
+	// "UE 5a47: LCID 4: TX          86712 RX        1172930 bytes"
+	macStatsLcidPattern = regexp.MustCompile(`^UE ([0-9a-f]+): LCID (\d+): TX\s+(\d+)\s+RX\s+(\d+) bytes`)
+)
+
+// parseMacStats parses the MAC statistics of the UEs dumped by the scheduler of the DU, in the format of its
+// nrMAC_stats.log. The lines of other layers and of unknown UEs are skipped
+func parseMacStats(output string) ([]UeMacStats, error) {
+	var ues []UeMacStats
+	findUe := func(rnti string) *UeMacStats {
+		for index := range ues {
+			if ues[index].Rnti == rnti {
+				return &ues[index]
+			}
+		}
+		return nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if match := macStatsUePattern.FindStringSubmatch(line); match != nil {
+			ues = append(ues, UeMacStats{Rnti: match[1], LogicalChannels: map[int]LogicalChannelStats{}})
+			continue
+		}
+		if match := macStatsBlerPattern.FindStringSubmatch(line); match != nil {
+			ue := findUe(match[1])
+			if ue == nil {
+				continue
+			}
+			bler, err := strconv.ParseFloat(match[3], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BLER of UE %s: %w", match[1], err)
+			}
+			mcs, _ := strconv.Atoi(match[4])
+			if match[2] == "dlsch" {
+				ue.DownlinkBler, ue.DownlinkMcs = bler, mcs
+			} else {
+				ue.UplinkBler, ue.UplinkMcs = bler, mcs
+			}
+			continue
+		}
+		if match := macStatsBytesPattern.FindStringSubmatch(line); match != nil {
+			if ue := findUe(match[1]); ue != nil {
+				ue.MacTxBytes, _ = strconv.ParseUint(match[2], 10, 64)
+				ue.MacRxBytes, _ = strconv.ParseUint(match[3], 10, 64)
+			}
+			continue
+		}
+		if match := macStatsLcidPattern.FindStringSubmatch(line); match != nil {
+			if ue := findUe(match[1]); ue != nil {
+				lcid, _ := strconv.Atoi(match[2])
+				txBytes, _ := strconv.ParseUint(match[3], 10, 64)
+				rxBytes, _ := strconv.ParseUint(match[4], 10, 64)
+				ue.LogicalChannels[lcid] = LogicalChannelStats{TxBytes: txBytes, RxBytes: rxBytes}
+			}
+		}
+	}
+	return ues, nil
+}
+
+// CellKpis are the KPIs of a cell of a DU. PDCP KPIs are out of scope: with the F1 split the PDCP layer runs in the
+// CU-CP and CU-UP, whose telnet servers print no PDCP statistics, so the bytes of the UEs stop at their RLC entities
+type CellKpis struct {
+	// CellId is the cell identity configured in the RANConfig, or the physical cell id prefixed by "pci-" when unknown
+	CellId string
+	// Plmn is the MCC followed by the MNC
+	Plmn         string
+	ConnectedUes int
+	// PrbUsage is the ratio of the PRBs used
+	PrbUsage float64
+	// DownlinkThroughput and UplinkThroughput are the sums of the throughputs of the UEs in bit/s
+	DownlinkThroughput float64
+	UplinkThroughput   float64
+	// DownlinkBler and UplinkBler are the averages of the block error rates of the UEs, nil without MAC statistics
+	DownlinkBler *float64
+	UplinkBler   *float64
+	// DownlinkMcs and UplinkMcs are the averages of the MCS of the UEs, nil without MAC statistics
+	DownlinkMcs *float64
+	UplinkMcs   *float64
+	// MacTxBytes and MacRxBytes are the sums of the bytes sent and received by the MAC of the DU for the UEs
+	MacTxBytes uint64
+	MacRxBytes uint64
+	// RlcBytes are the sums of the bytes of the logical channels of the UEs by LCID, nil without MAC statistics
+	RlcBytes map[int]LogicalChannelStats
+}
+
+// getCellKpis aggregates the statistics of a DU into the KPIs of its cell, nil when the O1 module reports no cell.
+// The cell identity is the one of the configured cell with the physical cell id reported. The O1 module only reports
+// the first cell of the DU, so the KPIs of a DU serving several cells are the ones of its first cell and of all its UEs
+func getCellKpis(stats *o1Stats, ues []UeMacStats, cells []workloadnfconfig.RANCellConfig) *CellKpis {
+	if stats.Config.NrCellDu == nil {
+		return nil
+	}
+	nrCellDu := stats.Config.NrCellDu
+	kpis := &CellKpis{
+		CellId:       "pci-" + strconv.Itoa(nrCellDu.Pci),
+		Plmn:         nrCellDu.Mcc + nrCellDu.Mnc,
+		ConnectedUes: stats.Operational.NumUes,
+		PrbUsage:     float64(stats.Operational.Load) / 100,
+	}
+	for _, cell := range cells {
+		if int(cell.PhysicalCellID) == nrCellDu.Pci {
+			kpis.CellId = normalizeCellIdentity(cell.CellIdentity)
+		}
+	}
+	for _, throughput := range stats.Operational.Throughput {
+		kpis.DownlinkThroughput += throughput.Downlink * 1000
+		kpis.UplinkThroughput += throughput.Uplink * 1000
+	}
+	if len(ues) > 0 {
+		var downlinkBler, uplinkBler, downlinkMcs, uplinkMcs float64
+		kpis.RlcBytes = map[int]LogicalChannelStats{}
+		for _, ue := range ues {
+			downlinkBler += ue.DownlinkBler
+			uplinkBler += ue.UplinkBler
+			downlinkMcs += float64(ue.DownlinkMcs)
+			uplinkMcs += float64(ue.UplinkMcs)
+			kpis.MacTxBytes += ue.MacTxBytes
+			kpis.MacRxBytes += ue.MacRxBytes
+			for lcid, logicalChannel := range ue.LogicalChannels {
+				rlcBytes := kpis.RlcBytes[lcid]
+				rlcBytes.TxBytes += logicalChannel.TxBytes
+				rlcBytes.RxBytes += logicalChannel.RxBytes
+				kpis.RlcBytes[lcid] = rlcBytes
+			}
+		}
+		downlinkBler /= float64(len(ues))
+		uplinkBler /= float64(len(ues))
+		downlinkMcs /= float64(len(ues))
+		uplinkMcs /= float64(len(ues))
+		kpis.DownlinkBler, kpis.UplinkBler = &downlinkBler, &uplinkBler
+		kpis.DownlinkMcs, kpis.UplinkMcs = &downlinkMcs, &uplinkMcs
+	}
+	return kpis
+}
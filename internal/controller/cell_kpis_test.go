@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestParseO1Stats(t *testing.T) {
+	cases := map[string]struct {
+		transcript string
+		wantCell   bool
+		wantUes    int
+		wantThpUes int
+		wantErr    bool
+	}{
+		"Cell With UEs": {transcript: "du_o1_stats.txt", wantCell: true, wantUes: 2, wantThpUes: 2},
+		"No Cell":       {transcript: "du_o1_stats_no_cell.txt"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			output, err := runTelnetCommand(newTranscriptServer(t, tc.transcript), "o1 stats")
+			if err != nil {
+				t.Fatalf("runTelnetCommand returned error %v", err)
+			}
+			stats, err := parseO1Stats(output)
+			if err != nil {
+				t.Fatalf("parseO1Stats returned error %v", err)
+			}
+			if (stats.Config.NrCellDu != nil) != tc.wantCell || stats.Operational.NumUes != tc.wantUes || len(stats.Operational.Throughput) != tc.wantThpUes {
+				t.Errorf("parseO1Stats returned %+v", stats)
+			}
+		})
+	}
+	if _, err := parseO1Stats("Unknown command o1"); err == nil {
+		t.Errorf("parseO1Stats returned no error without statistics")
+	}
+}
+
+func TestParseMacStats(t *testing.T) {
+	// The transcript is written after the format of nrMAC_stats.log, it is not captured from a softmodem
+	output, err := runTelnetCommand(newTranscriptServer(t, "du_mac_stats_synthetic.txt"), "mac stats")
+	if err != nil {
+		t.Fatalf("runTelnetCommand returned error %v", err)
+	}
+	ues, err := parseMacStats(output)
+	if err != nil {
+		t.Fatalf("parseMacStats returned error %v", err)
+	}
+	wantUes := []UeMacStats{
+		{
+			Rnti: "1adc", DownlinkBler: 0.02, UplinkBler: 0, DownlinkMcs: 9, UplinkMcs: 9, MacTxBytes: 114123, MacRxBytes: 1282497,
+			LogicalChannels: map[int]LogicalChannelStats{1: {TxBytes: 531, RxBytes: 304}, 4: {TxBytes: 86712, RxBytes: 1172930}},
+		},
+		{
+			Rnti: "4621", DownlinkBler: 0.08, UplinkBler: 0.1, DownlinkMcs: 20, UplinkMcs: 16, MacTxBytes: 52210, MacRxBytes: 412022,
+			LogicalChannels: map[int]LogicalChannelStats{1: {TxBytes: 498, RxBytes: 312}, 4: {TxBytes: 40110, RxBytes: 398775}},
+		},
+	}
+	if !reflect.DeepEqual(ues, wantUes) {
+		t.Errorf("parseMacStats returned %+v, expected %+v", ues, wantUes)
+	}
+}
+
+func TestGetCellKpis(t *testing.T) {
+	output, err := runTelnetCommand(newTranscriptServer(t, "du_o1_stats.txt"), "o1 stats")
+	if err != nil {
+		t.Fatalf("runTelnetCommand returned error %v", err)
+	}
+	stats, err := parseO1Stats(output)
+	if err != nil {
+		t.Fatalf("parseO1Stats returned error %v", err)
+	}
+	ues := []UeMacStats{
+		{
+			Rnti: "1adc", DownlinkBler: 0.02, DownlinkMcs: 9, UplinkMcs: 9, MacTxBytes: 114123, MacRxBytes: 1282497,
+			LogicalChannels: map[int]LogicalChannelStats{1: {TxBytes: 531, RxBytes: 304}, 4: {TxBytes: 86712, RxBytes: 1172930}},
+		},
+		{
+			Rnti: "4621", DownlinkBler: 0.08, UplinkBler: 0.1, DownlinkMcs: 20, UplinkMcs: 16, MacTxBytes: 52210, MacRxBytes: 412022,
+			LogicalChannels: map[int]LogicalChannelStats{1: {TxBytes: 498, RxBytes: 312}},
+		},
+	}
+
+	cases := map[string]struct {
+		ues   []UeMacStats
+		cells []workloadnfconfig.RANCellConfig
+		want  *CellKpis
+	}{
+		"Configured Cell": {
+			ues:   ues,
+			cells: []workloadnfconfig.RANCellConfig{{CellIdentity: "12345678L", PhysicalCellID: 0}, {CellIdentity: "0xBC614F", PhysicalCellID: 1}},
+			want: &CellKpis{
+				CellId: "12345679", Plmn: "00101", ConnectedUes: 2, PrbUsage: 0.09,
+				DownlinkThroughput: 4800000, UplinkThroughput: 3200000,
+				DownlinkBler: ptr.To(0.05), UplinkBler: ptr.To(0.05), DownlinkMcs: ptr.To(14.5), UplinkMcs: ptr.To(12.5),
+				MacTxBytes: 166333, MacRxBytes: 1694519,
+				RlcBytes: map[int]LogicalChannelStats{1: {TxBytes: 1029, RxBytes: 616}, 4: {TxBytes: 86712, RxBytes: 1172930}},
+			},
+		},
+		"Unknown Cell Without MAC Statistics": {
+			want: &CellKpis{CellId: "pci-1", Plmn: "00101", ConnectedUes: 2, PrbUsage: 0.09, DownlinkThroughput: 4800000, UplinkThroughput: 3200000},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if kpis := getCellKpis(stats, tc.ues, tc.cells); !reflect.DeepEqual(kpis, tc.want) {
+				t.Errorf("getCellKpis returned %+v, expected %+v", kpis, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+const (
+	defaultHugepagesPageSize = "1Gi"
+	hugepagesMountPath       = "/dev/hugepages"
+)
+
+var (
+	// helperContainerCpu and helperContainerMemory are the guaranteed resources of the sidecars and init containers of
+	// a realtime NF without limits of their own, they run from the shared CPU pool of the node
+	helperContainerCpu    = resourcev1.MustParse("100m")
+	helperContainerMemory = resourcev1.MustParse("128Mi")
+)
+
+// getHugepages returns the hugepages of the compute config with their defaults applied, nil if the NF doesn't use any
+func getHugepages(compute *workloadnfconfig.ComputeConfig) (*workloadnfconfig.HugepagesConfig, error) {
+	hugepages := compute.Hugepages.DeepCopy()
+	if hugepages == nil {
+		if compute.Profile != workloadnfconfig.ComputeProfileRealtime {
+			return nil, nil
+		}
+		hugepages = &workloadnfconfig.HugepagesConfig{Size: resourcev1.MustParse("1Gi")}
+	}
+	if hugepages.PageSize == "" {
+		hugepages.PageSize = defaultHugepagesPageSize
+	}
+	if hugepages.PageSize != "2Mi" && hugepages.PageSize != "1Gi" {
+		return nil, fmt.Errorf("not supported hugepages size %s", hugepages.PageSize)
+	}
+	if hugepages.Size.Sign() <= 0 {
+		return nil, fmt.Errorf("hugepages memory must be positive")
+	}
+	return hugepages, nil
+}
+
+// addHugepages requests the hugepages in the NF container and mounts them on /dev/hugepages
+func addHugepages(podSpec *corev1.PodSpec, hugepages *workloadnfconfig.HugepagesConfig) error {
+	container := &podSpec.Containers[0]
+	_, hasCpu := container.Resources.Limits[corev1.ResourceCPU]
+	_, hasMemory := container.Resources.Limits[corev1.ResourceMemory]
+	if !hasCpu && !hasMemory {
+		return fmt.Errorf("hugepages need the CPU or memory limits of the NF container")
+	}
+
+	resourceName := corev1.ResourceName(corev1.ResourceHugePagesPrefix + hugepages.PageSize)
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
+	}
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	container.Resources.Limits[resourceName] = hugepages.Size
+	container.Resources.Requests[resourceName] = hugepages.Size
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "hugepages",
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium: corev1.StorageMedium(string(corev1.StorageMediumHugePagesPrefix) + hugepages.PageSize),
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      "hugepages",
+		MountPath: hugepagesMountPath,
+	})
+	return nil
+}
+
+// setGuaranteedResources requests the CPU and memory limits of the NF container, which gets exclusive CPUs from the
+// static CPU manager of the node. The sidecars and init containers request their own limits, or small fixed ones, so
+// that the pod gets the guaranteed QoS class without reserving the CPUs of the NF container for each of them
+func setGuaranteedResources(podSpec *corev1.PodSpec) error {
+	container := &podSpec.Containers[0]
+	cpu, hasCpu := container.Resources.Limits[corev1.ResourceCPU]
+	memory, hasMemory := container.Resources.Limits[corev1.ResourceMemory]
+	if !hasCpu || !hasMemory {
+		return fmt.Errorf("the realtime profile needs the CPU and memory limits of the NF container")
+	}
+	if cpu.MilliValue()%1000 != 0 {
+		return fmt.Errorf("the realtime profile needs whole CPUs, not %s", cpu.String())
+	}
+
+	if container.Resources.Requests == nil {
+		container.Resources.Requests = corev1.ResourceList{}
+	}
+	container.Resources.Requests[corev1.ResourceCPU] = cpu
+	container.Resources.Requests[corev1.ResourceMemory] = memory
+	for index := range podSpec.Containers[1:] {
+		setHelperResources(&podSpec.Containers[index+1])
+	}
+	for index := range podSpec.InitContainers {
+		setHelperResources(&podSpec.InitContainers[index])
+	}
+	return nil
+}
+
+// setHelperResources requests the CPU and memory limits of a sidecar or init container, helperContainerCpu and
+// helperContainerMemory when it has none
+func setHelperResources(container *corev1.Container) {
+	cpu, hasCpu := container.Resources.Limits[corev1.ResourceCPU]
+	if !hasCpu {
+		cpu = helperContainerCpu
+	}
+	memory, hasMemory := container.Resources.Limits[corev1.ResourceMemory]
+	if !hasMemory {
+		memory = helperContainerMemory
+	}
+	container.Resources = guaranteedResources(cpu, memory)
+}
+
+func guaranteedResources(cpu resourcev1.Quantity, memory resourcev1.Quantity) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory},
+		Requests: corev1.ResourceList{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory},
+	}
+}
+
+// applyCompute applies the compute config of the OAIConfig to the pod of the NF, on top of the default resources of the NF container
+func applyCompute(podSpec *corev1.PodSpec, compute *workloadnfconfig.ComputeConfig) error {
+	if compute == nil {
+		return nil
+	}
+	switch compute.Profile {
+	case "", workloadnfconfig.ComputeProfileDefault, workloadnfconfig.ComputeProfileRealtime:
+	default:
+		return fmt.Errorf("not supported compute profile %q", compute.Profile)
+	}
+
+	if compute.Resources != nil {
+		podSpec.Containers[0].Resources = *compute.Resources.DeepCopy()
+	}
+	if compute.Profile == workloadnfconfig.ComputeProfileRealtime {
+		if err := setGuaranteedResources(podSpec); err != nil {
+			return err
+		}
+	}
+	hugepages, err := getHugepages(compute)
+	if err != nil {
+		return err
+	}
+	if hugepages != nil {
+		if err := addHugepages(podSpec, hugepages); err != nil {
+			return err
+		}
+	}
+
+	if len(compute.NodeSelector) > 0 {
+		podSpec.NodeSelector = map[string]string{}
+		for key, value := range compute.NodeSelector {
+			podSpec.NodeSelector[key] = value
+		}
+	}
+	podSpec.Affinity = compute.Affinity.DeepCopy()
+	for _, toleration := range compute.Tolerations {
+		podSpec.Tolerations = append(podSpec.Tolerations, *toleration.DeepCopy())
+	}
+	if compute.RuntimeClassName != nil {
+		podSpec.RuntimeClassName = ptr.To(*compute.RuntimeClassName)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func duDefaultPodSpec() corev1.PodSpec {
+	return corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "e2-sm"}},
+		Containers: []corev1.Container{{
+			Name: "du",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resourcev1.MustParse("2000m"),
+					corev1.ResourceMemory: resourcev1.MustParse("2Gi"),
+				},
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resourcev1.MustParse("2000m"),
+					corev1.ResourceMemory: resourcev1.MustParse("1Gi"),
+				},
+			},
+		}, {
+			Name: "telnet-proxy",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resourcev1.MustParse("200m")},
+			},
+		}},
+	}
+}
+
+func TestApplyCompute(t *testing.T) {
+	cases := map[string]struct {
+		compute       *workloadnfconfig.ComputeConfig
+		wantHugepages string
+		wantsError    bool
+	}{
+		"Default": {},
+		"Placement": {
+			compute: &workloadnfconfig.ComputeConfig{
+				NodeSelector:     map[string]string{"node-role.kubernetes.io/ran": ""},
+				Tolerations:      []corev1.Toleration{{Key: "ran", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+				RuntimeClassName: ptr.To("performance-ran"),
+			},
+		},
+		"Realtime": {
+			compute:       &workloadnfconfig.ComputeConfig{Profile: workloadnfconfig.ComputeProfileRealtime},
+			wantHugepages: "hugepages-1Gi",
+		},
+		"Hugepages Of 2Mi": {
+			compute: &workloadnfconfig.ComputeConfig{
+				Hugepages: &workloadnfconfig.HugepagesConfig{PageSize: "2Mi", Size: resourcev1.MustParse("512Mi")},
+			},
+			wantHugepages: "hugepages-2Mi",
+		},
+		"Realtime Fractional CPU": {
+			compute: &workloadnfconfig.ComputeConfig{
+				Profile: workloadnfconfig.ComputeProfileRealtime,
+				Resources: &corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resourcev1.MustParse("1500m"),
+					corev1.ResourceMemory: resourcev1.MustParse("2Gi"),
+				}},
+			},
+			wantsError: true,
+		},
+		"Realtime Without Memory": {
+			compute: &workloadnfconfig.ComputeConfig{
+				Profile:   workloadnfconfig.ComputeProfileRealtime,
+				Resources: &corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resourcev1.MustParse("4")}},
+			},
+			wantsError: true,
+		},
+		"Unknown Profile": {
+			compute:    &workloadnfconfig.ComputeConfig{Profile: "turbo"},
+			wantsError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			podSpec := duDefaultPodSpec()
+			err := applyCompute(&podSpec, tc.compute)
+			if (err != nil) != tc.wantsError {
+				t.Fatalf("applyCompute returned error %v, wants error %v", err, tc.wantsError)
+			}
+			if tc.wantsError {
+				return
+			}
+
+			resources := podSpec.Containers[0].Resources
+			if tc.wantHugepages != "" {
+				if _, found := resources.Limits[corev1.ResourceName(tc.wantHugepages)]; !found {
+					t.Errorf("Resources %v miss %s", resources, tc.wantHugepages)
+				}
+				if len(podSpec.Volumes) != 1 || podSpec.Containers[0].VolumeMounts[0].MountPath != "/dev/hugepages" {
+					t.Errorf("Hugepages are not mounted in %v", podSpec)
+				}
+			}
+			if tc.compute != nil && tc.compute.Profile == workloadnfconfig.ComputeProfileRealtime {
+				for _, resourceName := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+					if !resources.Requests[resourceName].Equal(resources.Limits[resourceName]) {
+						t.Errorf("Request of %s is not its limit in %v", resourceName, resources)
+					}
+				}
+				initResources := podSpec.InitContainers[0].Resources
+				wantInitResources := corev1.ResourceList{corev1.ResourceCPU: helperContainerCpu, corev1.ResourceMemory: helperContainerMemory}
+				if !reflect.DeepEqual(initResources.Requests, wantInitResources) || !reflect.DeepEqual(initResources.Limits, wantInitResources) {
+					t.Errorf("Resources of the init container are %v, expected the guaranteed %v", initResources, wantInitResources)
+				}
+				sidecarResources := podSpec.Containers[1].Resources
+				if !sidecarResources.Requests[corev1.ResourceCPU].Equal(resourcev1.MustParse("200m")) || !sidecarResources.Requests[corev1.ResourceMemory].Equal(helperContainerMemory) {
+					t.Errorf("Resources of the sidecar are %v, expected its CPU limit and the helper memory", sidecarResources)
+				}
+			}
+			if tc.compute != nil && tc.compute.RuntimeClassName != nil {
+				if podSpec.RuntimeClassName == nil || *podSpec.RuntimeClassName != "performance-ran" {
+					t.Errorf("RuntimeClassName is %v", podSpec.RuntimeClassName)
+				}
+				if len(podSpec.NodeSelector) != 1 || len(podSpec.Tolerations) != 1 {
+					t.Errorf("Placement is not applied to %v", podSpec)
+				}
+			}
+		})
+	}
+}
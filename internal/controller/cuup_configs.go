@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+const (
+	// CuUpIdAnnotation records the gNB_CU_UP_ID allocated by the controller to a CU-UP NFDeployment
+	CuUpIdAnnotation = "workload.nephio.org/gnb-cu-up-id"
+	// SlicesAnnotation restricts a CU-UP to a subset of the PLMN NSSAI, as a comma separated list of "sst" or "sst-sd"
+	SlicesAnnotation = "workload.nephio.org/slices"
+
+	firstGnbCuUpId = 0xe00
+	// The gNB_CU_UP_ID is a 36 bits value (TS 38.463)
+	maxGnbCuUpId = 1<<36 - 1
+)
+
+// getCuUpId returns the gNB_CU_UP_ID recorded on the CU-UP, false if none was allocated yet
+func getCuUpId(ranDeployment *workloadv1alpha1.NFDeployment) (uint64, bool, error) {
+	value, found := ranDeployment.Annotations[CuUpIdAnnotation]
+	if !found {
+		return 0, false, nil
+	}
+	cuUpId, err := strconv.ParseUint(value, 0, 64)
+	if err != nil || cuUpId > maxGnbCuUpId {
+		return 0, false, fmt.Errorf("invalid %s annotation %q", CuUpIdAnnotation, value)
+	}
+	return cuUpId, true, nil
+}
+
+// allocateCuUpId returns the lowest gNB_CU_UP_ID not used yet
+func allocateCuUpId(usedIds map[uint64]string) (uint64, error) {
+	for cuUpId := uint64(firstGnbCuUpId); cuUpId <= maxGnbCuUpId; cuUpId++ {
+		if _, used := usedIds[cuUpId]; !used {
+			return cuUpId, nil
+		}
+	}
+	return 0, fmt.Errorf("no gNB_CU_UP_ID left")
+}
+
+// getCuUpResourceName returns the prefix of the resources of a CU-UP, the first CU-UP keeps the name "oai-cu-up"
+// so that several CU-UPs can share a namespace
+func getCuUpResourceName(ranDeployment *workloadv1alpha1.NFDeployment) string {
+	cuUpId, found, err := getCuUpId(ranDeployment)
+	if err != nil || !found || cuUpId <= firstGnbCuUpId {
+		return "oai-cu-up"
+	}
+	return fmt.Sprintf("oai-cu-up-%d", cuUpId-firstGnbCuUpId)
+}
+
+// formatSlice renders a slice the way it is written in the SlicesAnnotation
+func formatSlice(nssai workloadnfconfig.NSSAI) string {
+	if nssai.SD == nil {
+		return strconv.Itoa(nssai.SST)
+	}
+	return fmt.Sprintf("%d-%s", nssai.SST, strings.ToLower(*nssai.SD))
+}
+
+// getCuUpSlices returns the slices served by the CU-UP, all the NSSAI of the PLMN unless the SlicesAnnotation selects some of them
+func getCuUpSlices(ranDeployment *workloadv1alpha1.NFDeployment, plmnInfo *workloadnfconfig.PLMNInfo) ([]workloadnfconfig.NSSAI, error) {
+	value, found := ranDeployment.Annotations[SlicesAnnotation]
+	if !found {
+		return plmnInfo.NSSAI, nil
+	}
+
+	slicesByName := make(map[string]workloadnfconfig.NSSAI, len(plmnInfo.NSSAI))
+	for _, nssai := range plmnInfo.NSSAI {
+		slicesByName[formatSlice(nssai)] = nssai
+	}
+	selected := []workloadnfconfig.NSSAI{}
+	for _, name := range strings.Split(value, ",") {
+		nssai, found := slicesByName[strings.ToLower(strings.TrimSpace(name))]
+		if !found {
+			return nil, fmt.Errorf("slice %q of the %s annotation is not part of the PLMN NSSAI", strings.TrimSpace(name), SlicesAnnotation)
+		}
+		selected = append(selected, nssai)
+	}
+	return selected, nil
+}
+
+// getSliceTemplateValues returns the template values of the snssaiList
+func getSliceTemplateValues(slices []workloadnfconfig.NSSAI) []configurationTemplateValuesForSlice {
+	sliceTemplateValues := make([]configurationTemplateValuesForSlice, 0, len(slices))
+	for _, nssai := range slices {
+		sliceTemplateValue := configurationTemplateValuesForSlice{SST: nssai.SST}
+		if nssai.SD != nil {
+			sliceTemplateValue.SD = *nssai.SD
+		}
+		sliceTemplateValues = append(sliceTemplateValues, sliceTemplateValue)
+	}
+	return sliceTemplateValues
+}
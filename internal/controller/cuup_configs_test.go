@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestGetCuUpResourceName(t *testing.T) {
+	cases := map[string]struct {
+		annotations  map[string]string
+		expectedName string
+	}{
+		"Not Allocated":  {expectedName: "oai-cu-up"},
+		"First CU-UP":    {annotations: map[string]string{CuUpIdAnnotation: "0xe00"}, expectedName: "oai-cu-up"},
+		"Third CU-UP":    {annotations: map[string]string{CuUpIdAnnotation: "0xe02"}, expectedName: "oai-cu-up-2"},
+		"Decimal Value":  {annotations: map[string]string{CuUpIdAnnotation: "3585"}, expectedName: "oai-cu-up-1"},
+		"Invalid Value":  {annotations: map[string]string{CuUpIdAnnotation: "first"}, expectedName: "oai-cu-up"},
+		"Exceeds 36 Bit": {annotations: map[string]string{CuUpIdAnnotation: "0x1000000000"}, expectedName: "oai-cu-up"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ranDeployment := &workloadv1alpha1.NFDeployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			if got := getCuUpResourceName(ranDeployment); got != tc.expectedName {
+				t.Errorf("getCuUpResourceName returned %v, expected %v", got, tc.expectedName)
+			}
+		})
+	}
+}
+
+func TestAllocateCuUpId(t *testing.T) {
+	cases := map[string]struct {
+		usedIds    map[uint64]string
+		expectedId uint64
+	}{
+		"No CU-UP":    {usedIds: map[uint64]string{}, expectedId: 0xe00},
+		"Gap Reused":  {usedIds: map[uint64]string{0xe00: "cuup-a", 0xe02: "cuup-c"}, expectedId: 0xe01},
+		"Next Free":   {usedIds: map[uint64]string{0xe00: "cuup-a", 0xe01: "cuup-b"}, expectedId: 0xe02},
+		"Below First": {usedIds: map[uint64]string{0x1: "cuup-a"}, expectedId: 0xe00},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := allocateCuUpId(tc.usedIds)
+			if err != nil || got != tc.expectedId {
+				t.Errorf("allocateCuUpId returned 0x%x, %v, expected 0x%x", got, err, tc.expectedId)
+			}
+		})
+	}
+}
+
+func TestGetCuUpSlices(t *testing.T) {
+	plmnInfo := workloadnfconfig.PLMNInfo{
+		NSSAI: []workloadnfconfig.NSSAI{
+			{SST: 1, SD: ptr.To("FFFFFF")},
+			{SST: 1, SD: ptr.To("000001")},
+			{SST: 2},
+		},
+	}
+
+	cases := map[string]struct {
+		annotations    map[string]string
+		expectedSlices []workloadnfconfig.NSSAI
+		wantsError     bool
+	}{
+		"All Slices By Default": {
+			expectedSlices: plmnInfo.NSSAI,
+		},
+		"Subset In Annotation Order": {
+			annotations:    map[string]string{SlicesAnnotation: "2, 1-ffffff"},
+			expectedSlices: []workloadnfconfig.NSSAI{{SST: 2}, {SST: 1, SD: ptr.To("FFFFFF")}},
+		},
+		"SD Required When Set In PLMN": {
+			annotations: map[string]string{SlicesAnnotation: "1"},
+			wantsError:  true,
+		},
+		"Unknown Slice": {
+			annotations: map[string]string{SlicesAnnotation: "1-000002"},
+			wantsError:  true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ranDeployment := &workloadv1alpha1.NFDeployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			got, err := getCuUpSlices(ranDeployment, &plmnInfo)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("getCuUpSlices returned %v, expecting an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getCuUpSlices returned error %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expectedSlices) {
+				t.Errorf("getCuUpSlices returned %v, expected %v", got, tc.expectedSlices)
+			}
+		})
+	}
+}
+
+func TestRenderConfigurationTemplateForCuUpSlices(t *testing.T) {
+	configuration, err := renderConfigurationTemplateForCuUp(configurationTemplateValuesForCuUp{
+		CU_UP_ID: "0xe01",
+		SLICES:   getSliceTemplateValues([]workloadnfconfig.NSSAI{{SST: 1, SD: ptr.To("000001")}, {SST: 2}}),
+	})
+	if err != nil {
+		t.Fatalf("renderConfigurationTemplateForCuUp returned error %v", err)
+	}
+	for _, expected := range []string{
+		"gNB_CU_UP_ID = 0xe01;",
+		"snssaiList = ({ sst = 1, sd = 0x000001 }, { sst = 2 })",
+	} {
+		if !strings.Contains(configuration, expected) {
+			t.Errorf("CU-UP configuration must contain %q:\n%s", expected, configuration)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// DryRunAnnotation set to "true" makes the reconciler report the changes it would make to the resources of a
+	// NFDeployment instead of making them, including their deletion: a NFDeployment deleted during a dry-run keeps its
+	// resources and its finalizer until the dry-run is disabled
+	DryRunAnnotation = "workload.nephio.org/dry-run"
+
+	// maxEventMessage is the length of the messages of the events, the API server truncates longer ones
+	maxEventMessage = 1024
+)
+
+// ObjectDiff is the difference between a resource rendered for a NFDeployment and the live one
+type ObjectDiff struct {
+	Kind string
+	Name string
+	// Action is create when the resource doesn't exist, differs when fields of the live resource differ and unchanged
+	// otherwise. The reconciler never updates the existing resources, the differing ones are only reported
+	Action string
+	// Fields are the paths of the fields of the live resource differing from the rendered ones
+	Fields []string
+}
+
+func (diff ObjectDiff) String() string {
+	if len(diff.Fields) == 0 {
+		return fmt.Sprintf("%s %s %s", diff.Kind, diff.Name, diff.Action)
+	}
+	return fmt.Sprintf("%s %s %s (%s)", diff.Kind, diff.Name, diff.Action, strings.Join(diff.Fields, ", "))
+}
+
+// isDryRun tells whether the resources of a NFDeployment are only compared to the live ones
+func (r *RANDeploymentReconciler) isDryRun(ranDeployment *workloadv1alpha1.NFDeployment) bool {
+	return r.DryRun || ranDeployment.Annotations[DryRunAnnotation] == "true"
+}
+
+// isEmpty tells whether a field of a rendered resource is empty, which the API server omits
+func isEmpty(value any) bool {
+	if value == nil {
+		return true
+	}
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return reflectValue.Len() == 0
+	default:
+		return reflectValue.IsZero()
+	}
+}
+
+// diffFields appends to fields the paths of the fields set in desired which live doesn't have the same value of.
+// The fields only set in live are the defaults of the API server, they are not compared
+func diffFields(path string, desired any, live any, fields []string) []string {
+	switch desiredValue := desired.(type) {
+	case map[string]any:
+		liveValue, ok := live.(map[string]any)
+		if !ok {
+			if isEmpty(desiredValue) {
+				return fields
+			}
+			return append(fields, path)
+		}
+		keys := make([]string, 0, len(desiredValue))
+		for key := range desiredValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			if _, found := liveValue[key]; !found && isEmpty(desiredValue[key]) {
+				continue
+			}
+			fields = diffFields(fieldPath, desiredValue[key], liveValue[key], fields)
+		}
+		return fields
+	case []any:
+		liveValue, ok := live.([]any)
+		if !ok || len(liveValue) != len(desiredValue) {
+			if !ok && isEmpty(desiredValue) {
+				return fields
+			}
+			return append(fields, path)
+		}
+		for index := range desiredValue {
+			fields = diffFields(fmt.Sprintf("%s[%d]", path, index), desiredValue[index], liveValue[index], fields)
+		}
+		return fields
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			return append(fields, path)
+		}
+		return fields
+	}
+}
+
+// diffObject compares a rendered resource to the live one, on their labels, annotations and all but their status
+func diffObject(kind string, desired client.Object, live client.Object) (ObjectDiff, error) {
+	diff := ObjectDiff{Kind: kind, Name: desired.GetName(), Action: "unchanged"}
+	desiredFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(desired)
+	if err != nil {
+		return diff, err
+	}
+	liveFields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+	if err != nil {
+		return diff, err
+	}
+	for _, fields := range []map[string]any{desiredFields, liveFields} {
+		labels, _, _ := unstructured.NestedFieldNoCopy(fields, "metadata", "labels")
+		annotations, _, _ := unstructured.NestedFieldNoCopy(fields, "metadata", "annotations")
+		fields["metadata"] = map[string]any{"labels": labels, "annotations": annotations}
+		delete(fields, "status")
+		delete(fields, "apiVersion")
+		delete(fields, "kind")
+	}
+	diff.Fields = diffFields("", desiredFields, liveFields, nil)
+	if len(diff.Fields) > 0 {
+		diff.Action = "differs"
+	}
+	return diff, nil
+}
+
+// lineDiff returns the lines removed from oldText prefixed with "-" and the lines added in newText prefixed with "+",
+// in the order of the files, from their longest common subsequence
+func lineDiff(oldText string, newText string) []string {
+	oldLines := strings.Split(oldText, "\n")
+	newLines := strings.Split(newText, "\n")
+	// common[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	common := make([][]int, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			i++
+			j++
+		case j == len(newLines) || (i < len(oldLines) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, "-"+oldLines[i])
+			i++
+		default:
+			lines = append(lines, "+"+newLines[j])
+			j++
+		}
+	}
+	return lines
+}
+
+// DiffResources compares the rendered resources of a NFDeployment to the live ones. It also returns the line diff
+// of the gnb.conf of the ConfigMaps
+func (r *RANDeploymentReconciler) DiffResources(ctx context.Context, rendered *RenderedResources) ([]ObjectDiff, []string, error) {
+	diffs := []ObjectDiff{}
+	gnbConfDiff := []string{}
+	compare := func(kind string, desired client.Object, live client.Object) error {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(desired), live); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			diffs = append(diffs, ObjectDiff{Kind: kind, Name: desired.GetName(), Action: "create"})
+			return nil
+		}
+		diff, err := diffObject(kind, desired, live)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, diff)
+		return nil
+	}
+
+	for _, resource := range rendered.NetworkAttachmentDefinitions {
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(resource.GroupVersionKind())
+		if err := compare("NetworkAttachmentDefinition", resource, live); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, resource := range rendered.ServiceAccounts {
+		if err := compare("ServiceAccount", resource, &corev1.ServiceAccount{}); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, resource := range rendered.ConfigMaps {
+		live := &corev1.ConfigMap{}
+		if err := compare("ConfigMap", resource, live); err != nil {
+			return nil, nil, err
+		}
+		if diffs[len(diffs)-1].Action == "differs" && live.Data["gnb.conf"] != resource.Data["gnb.conf"] {
+			gnbConfDiff = append(gnbConfDiff, lineDiff(live.Data["gnb.conf"], resource.Data["gnb.conf"])...)
+		}
+	}
+	for _, resource := range rendered.Deployments {
+		if err := compare("Deployment", resource, &appsv1.Deployment{}); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, resource := range rendered.Services {
+		if err := compare("Service", resource, &corev1.Service{}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return diffs, gnbConfDiff, nil
+}
+
+// summarizeDiffs returns the actions on the resources of a NFDeployment with the fields they change
+func summarizeDiffs(diffs []ObjectDiff) string {
+	summary := make([]string, 0, len(diffs))
+	for _, diff := range diffs {
+		summary = append(summary, diff.String())
+	}
+	return strings.Join(summary, "; ")
+}
+
+// diffEventMessage appends the line diff of the gnb.conf to the message of an event, truncated to the length of the events
+func diffEventMessage(message string, gnbConfDiff []string) string {
+	if len(gnbConfDiff) > 0 {
+		message += "\ngnb.conf:\n" + strings.Join(gnbConfDiff, "\n")
+	}
+	if len(message) > maxEventMessage {
+		// Cut at the start of a rune so that the message stays valid UTF-8
+		end := maxEventMessage - 3
+		for end > 0 && !utf8.RuneStart(message[end]) {
+			end--
+		}
+		message = message[:end] + "..."
+	}
+	return message
+}
+
+// UpdateDryRunStatus renders the resources of a NFDeployment and reports their differences with the live ones in the dryRun
+// condition and in an event, with the line diff of the gnb.conf
+func (r *RANDeploymentReconciler) UpdateDryRunStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	curCondition := metav1.Condition{
+		Type:               "dryRun",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionTrue,
+		Reason:             "dryRun",
+	}
+	eventType := corev1.EventTypeNormal
+	var gnbConfDiff []string
+	rendered, err := renderResources(ctx, ranDeployment, configInfo)
+	if err == nil {
+		var diffs []ObjectDiff
+		diffs, gnbConfDiff, err = r.DiffResources(ctx, rendered)
+		if err != nil {
+			return err
+		}
+		curCondition.Message = "Dry-run: " + summarizeDiffs(diffs)
+		if controllerutil.ContainsFinalizer(ranDeployment, finalizerName) {
+			// The resources are only created on the first reconciliation, which added the finalizer
+			curCondition.Message = "Dry-run, the resources were already created and are not changed: " + summarizeDiffs(diffs)
+		}
+	} else {
+		curCondition.Status = metav1.ConditionFalse
+		curCondition.Message = "Dry-run cannot render the resources | Error: " + err.Error()
+		eventType = corev1.EventTypeWarning
+	}
+
+	previous := meta.FindStatusCondition(ranDeployment.Status.Conditions, curCondition.Type)
+	if previous == nil || previous.Message != curCondition.Message {
+		r.recordEvent(ranDeployment, eventType, ReasonDryRun, "%s", diffEventMessage(curCondition.Message, gnbConfDiff))
+	}
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
+
+// UpdateDryRunDeletionStatus reports in the dryRun condition and in an event the resources the deletion of a NFDeployment
+// would delete. Neither the resources nor the finalizer are deleted
+func (r *RANDeploymentReconciler) UpdateDryRunDeletionStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	curCondition := metav1.Condition{
+		Type:               "dryRun",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionTrue,
+		Reason:             "dryRun",
+	}
+	eventType := corev1.EventTypeNormal
+	rendered, err := renderResources(ctx, ranDeployment, configInfo)
+	if err == nil {
+		var diffs []ObjectDiff
+		diffs, _, err = r.DiffResources(ctx, rendered)
+		if err != nil {
+			return err
+		}
+		deleted := []string{}
+		for _, diff := range diffs {
+			if diff.Action != "create" {
+				deleted = append(deleted, diff.Kind+" "+diff.Name)
+			}
+		}
+		if len(deleted) == 0 {
+			deleted = append(deleted, "none")
+		}
+		curCondition.Message = "Dry-run, the deletion is pending until the dry-run is disabled, it deletes: " + strings.Join(deleted, ", ")
+	} else {
+		curCondition.Status = metav1.ConditionFalse
+		curCondition.Message = "Dry-run, the deletion is pending until the dry-run is disabled, the resources cannot be rendered | Error: " + err.Error()
+		eventType = corev1.EventTypeWarning
+	}
+
+	previous := meta.FindStatusCondition(ranDeployment.Status.Conditions, curCondition.Type)
+	if previous == nil || previous.Message != curCondition.Message {
+		r.recordEvent(ranDeployment, eventType, ReasonDryRun, "%s", diffEventMessage(curCondition.Message, nil))
+	}
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
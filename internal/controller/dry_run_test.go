@@ -0,0 +1,251 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestLineDiff(t *testing.T) {
+	cases := map[string]struct {
+		oldText string
+		newText string
+		want    []string
+	}{
+		"Same":     {oldText: "a\nb", newText: "a\nb", want: []string{}},
+		"Changed":  {oldText: "a\nb = 1;\nc", newText: "a\nb = 2;\nc", want: []string{"-b = 1;", "+b = 2;"}},
+		"Added":    {oldText: "a\nc", newText: "a\nb\nc", want: []string{"+b"}},
+		"Removed":  {oldText: "a\nb\nc", newText: "a\nc", want: []string{"-b"}},
+		"Appended": {oldText: "a", newText: "a\nb\nc", want: []string{"+b", "+c"}},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := lineDiff(tc.oldText, tc.newText); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("lineDiff returned %q, expected %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiffObject(t *testing.T) {
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "oai-du", Labels: map[string]string{"app.kubernetes.io/name": "oai-du"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(int32(1)),
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "du", Image: "oai-gnb:develop"}}}},
+		},
+	}
+	cases := map[string]struct {
+		live       func(*appsv1.Deployment)
+		wantFields []string
+	}{
+		"Defaulted By The API Server": {
+			live: func(live *appsv1.Deployment) {
+				live.ResourceVersion = "42"
+				live.Spec.RevisionHistoryLimit = ptr.To(int32(10))
+				live.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+				live.Status.ReadyReplicas = 1
+			},
+		},
+		"Image Changed": {
+			live: func(live *appsv1.Deployment) {
+				live.Spec.Template.Spec.Containers[0].Image = "oai-gnb:v2.0.0"
+			},
+			wantFields: []string{"spec.template.spec.containers[0].image"},
+		},
+		"Label And Replicas Changed": {
+			live: func(live *appsv1.Deployment) {
+				live.Labels = nil
+				live.Spec.Replicas = ptr.To(int32(0))
+			},
+			wantFields: []string{"metadata.labels", "spec.replicas"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			live := desired.DeepCopy()
+			tc.live(live)
+			got, err := diffObject("Deployment", desired, live)
+			if err != nil {
+				t.Fatalf("diffObject returned error %v", err)
+			}
+			wantAction := "unchanged"
+			if len(tc.wantFields) > 0 {
+				wantAction = "differs"
+			}
+			if got.Action != wantAction || !reflect.DeepEqual(got.Fields, tc.wantFields) {
+				t.Errorf("diffObject returned %v, expected %s of %v", got, wantAction, tc.wantFields)
+			}
+		})
+	}
+}
+
+func TestDiffResources(t *testing.T) {
+	clientMock := new(MockClient)
+	clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.ConfigMap")).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(2).(*corev1.ConfigMap) = corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "oai-du-configmap"},
+			Data:       map[string]string{"gnb.conf": "gNB_name = \"oai-du\";\nnr_cellid = 1;"},
+		}
+	})
+	clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Service")).Return(apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, "oai-du-telnet"))
+	r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme()}
+
+	rendered := &RenderedResources{
+		ConfigMaps: []*corev1.ConfigMap{{
+			ObjectMeta: metav1.ObjectMeta{Name: "oai-du-configmap"},
+			Data:       map[string]string{"gnb.conf": "gNB_name = \"oai-du\";\nnr_cellid = 2;"},
+		}},
+		Services: []*corev1.Service{{ObjectMeta: metav1.ObjectMeta{Name: "oai-du-telnet"}}},
+	}
+	diffs, gnbConfDiff, err := r.DiffResources(context.TODO(), rendered)
+	if err != nil {
+		t.Fatalf("DiffResources returned error %v", err)
+	}
+	wantDiffs := []ObjectDiff{
+		{Kind: "ConfigMap", Name: "oai-du-configmap", Action: "differs", Fields: []string{"data.gnb.conf"}},
+		{Kind: "Service", Name: "oai-du-telnet", Action: "create"},
+	}
+	if !reflect.DeepEqual(diffs, wantDiffs) {
+		t.Errorf("DiffResources returned %v, expected %v", diffs, wantDiffs)
+	}
+	if !reflect.DeepEqual(gnbConfDiff, []string{"-nr_cellid = 1;", "+nr_cellid = 2;"}) {
+		t.Errorf("DiffResources returned the gnb.conf diff %q", gnbConfDiff)
+	}
+}
+
+func TestUpdateDryRunStatus(t *testing.T) {
+	clientMock := new(MockClient)
+	statusWriterMock := new(MockStatusWriter)
+	statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+	clientMock.On("Status").Return(statusWriterMock)
+	recorder := record.NewFakeRecorder(2)
+	r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme(), Recorder: recorder}
+
+	// The DU lacks its F1 interface, its ConfigMap cannot be rendered
+	ranDeployment := &workloadv1alpha1.NFDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "du-edge", Annotations: map[string]string{DryRunAnnotation: "true"}},
+		Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+	}
+	if !r.isDryRun(ranDeployment) {
+		t.Fatalf("isDryRun returned false with the %s annotation", DryRunAnnotation)
+	}
+	for range 2 {
+		if err := r.UpdateDryRunStatus(context.TODO(), ranDeployment, NewConfigInfo()); err != nil {
+			t.Fatalf("UpdateDryRunStatus returned error %v", err)
+		}
+	}
+
+	conditions := ranDeployment.Status.Conditions
+	if len(conditions) != 1 || conditions[0].Type != "dryRun" || conditions[0].Status != metav1.ConditionFalse {
+		t.Errorf("UpdateDryRunStatus set the conditions %v, expected a false dryRun condition", conditions)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("UpdateDryRunStatus recorded %d events, expected one for an unchanged diff", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning DryRun Dry-run cannot render") {
+		t.Errorf("UpdateDryRunStatus recorded the event %q", event)
+	}
+}
+
+func TestDiffEventMessage(t *testing.T) {
+	cases := map[string]struct {
+		message string
+		wantLen int
+	}{
+		"Short":       {message: "Dry-run: Service oai-du-telnet create", wantLen: len("Dry-run: Service oai-du-telnet create")},
+		"ASCII":       {message: strings.Repeat("a", 2*maxEventMessage), wantLen: maxEventMessage},
+		"Multi-Byte":  {message: "a" + strings.Repeat("é", maxEventMessage), wantLen: maxEventMessage},
+		"Three Bytes": {message: strings.Repeat("€", maxEventMessage), wantLen: maxEventMessage - 1},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := diffEventMessage(tc.message, nil)
+			if len(got) != tc.wantLen || !utf8.ValidString(got) {
+				t.Errorf("diffEventMessage returned %d bytes, valid UTF-8 %t, expected %d valid bytes", len(got), utf8.ValidString(got), tc.wantLen)
+			}
+		})
+	}
+}
+
+func TestUpdateDryRunDeletionStatus(t *testing.T) {
+	ranDeployment, objects := loadGoldenInput(t, filepath.Join("testdata", "golden", "du-rfsim-band-n78"))
+	scheme := newManagerScheme()
+	configReader := RANDeploymentReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), Scheme: scheme}
+	configInfo, err := configReader.GetConfigs(context.TODO(), ranDeployment)
+	if err != nil {
+		t.Fatalf("GetConfigs returned error %v", err)
+	}
+	rendered, err := renderResources(context.TODO(), ranDeployment, configInfo)
+	if err != nil {
+		t.Fatalf("renderResources returned error %v", err)
+	}
+
+	// The Deployment of the DU exists, the other resources were deleted by hand
+	ranDeployment.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	ranDeployment.Annotations = map[string]string{DuResourceNameAnnotation: getDuResourceName(ranDeployment)}
+	controllerutil.AddFinalizer(ranDeployment, finalizerName)
+	liveDeployment := rendered.Deployments[0].DeepCopy()
+	liveDeployment.Namespace = goldenNamespace
+	recorder := record.NewFakeRecorder(2)
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ranDeployment, liveDeployment).WithStatusSubresource(ranDeployment).Build()
+	r := RANDeploymentReconciler{Client: fakeClient, Scheme: scheme, Recorder: recorder, DryRun: true}
+	for range 2 {
+		if err := r.UpdateDryRunDeletionStatus(context.TODO(), ranDeployment, configInfo); err != nil {
+			t.Fatalf("UpdateDryRunDeletionStatus returned error %v", err)
+		}
+	}
+
+	wantMessage := "Dry-run, the deletion is pending until the dry-run is disabled, it deletes: Deployment " + liveDeployment.Name
+	condition := meta.FindStatusCondition(ranDeployment.Status.Conditions, "dryRun")
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Message != wantMessage {
+		t.Errorf("UpdateDryRunDeletionStatus set the condition %v, expected the message %q", condition, wantMessage)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("UpdateDryRunDeletionStatus recorded %d events, expected one for an unchanged message", len(recorder.Events))
+	}
+	if event := <-recorder.Events; event != "Normal DryRun "+wantMessage {
+		t.Errorf("UpdateDryRunDeletionStatus recorded the event %q", event)
+	}
+	if err := fakeClient.Get(context.TODO(), client.ObjectKeyFromObject(liveDeployment), &appsv1.Deployment{}); err != nil {
+		t.Errorf("UpdateDryRunDeletionStatus deleted the Deployment: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+)
+
+const (
+	// DuIdAnnotation records the gNB_DU_ID allocated by the controller to a DU NFDeployment
+	DuIdAnnotation = "workload.nephio.org/gnb-du-id"
+
+	// gnbId is the gNB_ID of the gNB, shared by the CU-CP and all the cells of its DUs
+	gnbId        = 0xe00
+	firstGnbDuId = 0xe00
+	// The gNB_DU_ID is a 36 bits value (TS 38.473)
+	maxGnbDuId = 1<<36 - 1
+)
+
+// getDuId returns the gNB_DU_ID recorded on the DU, false if none was allocated yet
+func getDuId(ranDeployment *workloadv1alpha1.NFDeployment) (uint64, bool, error) {
+	value, found := ranDeployment.Annotations[DuIdAnnotation]
+	if !found {
+		return 0, false, nil
+	}
+	duId, err := strconv.ParseUint(value, 0, 64)
+	if err != nil || duId > maxGnbDuId {
+		return 0, false, fmt.Errorf("invalid %s annotation %q", DuIdAnnotation, value)
+	}
+	return duId, true, nil
+}
+
+// getDuIdOrDefault returns the gNB_DU_ID recorded on the DU, the first gNB_DU_ID when none was allocated,
+// e.g. when the DU is rendered offline
+func getDuIdOrDefault(ranDeployment *workloadv1alpha1.NFDeployment) (uint64, error) {
+	duId, found, err := getDuId(ranDeployment)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return firstGnbDuId, nil
+	}
+	return duId, nil
+}
+
+// allocateDuId returns the lowest gNB_DU_ID not used yet
+func allocateDuId(usedIds map[uint64]string) (uint64, error) {
+	for duId := uint64(firstGnbDuId); duId <= maxGnbDuId; duId++ {
+		if _, used := usedIds[duId]; !used {
+			return duId, nil
+		}
+	}
+	return 0, fmt.Errorf("no gNB_DU_ID left")
+}
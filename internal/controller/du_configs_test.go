@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetDuIdOrDefault(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		expectedId  uint64
+		wantsError  bool
+	}{
+		"Not Allocated":  {expectedId: 0xe00},
+		"Second DU":      {annotations: map[string]string{DuIdAnnotation: "0xe01"}, expectedId: 0xe01},
+		"Decimal Value":  {annotations: map[string]string{DuIdAnnotation: "3586"}, expectedId: 0xe02},
+		"Invalid Value":  {annotations: map[string]string{DuIdAnnotation: "first"}, wantsError: true},
+		"Exceeds 36 Bit": {annotations: map[string]string{DuIdAnnotation: "0x1000000000"}, wantsError: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ranDeployment := &workloadv1alpha1.NFDeployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			got, err := getDuIdOrDefault(ranDeployment)
+			if (err != nil) != tc.wantsError {
+				t.Fatalf("getDuIdOrDefault returned error %v", err)
+			}
+			if got != tc.expectedId {
+				t.Errorf("getDuIdOrDefault returned 0x%x, expected 0x%x", got, tc.expectedId)
+			}
+		})
+	}
+}
+
+func TestAllocateDuId(t *testing.T) {
+	cases := map[string]struct {
+		usedIds    map[uint64]string
+		expectedId uint64
+	}{
+		"No DU":       {usedIds: map[uint64]string{}, expectedId: 0xe00},
+		"Gap Reused":  {usedIds: map[uint64]string{0xe00: "du-a", 0xe02: "du-c"}, expectedId: 0xe01},
+		"Next Free":   {usedIds: map[uint64]string{0xe00: "du-a", 0xe01: "du-b"}, expectedId: 0xe02},
+		"Below First": {usedIds: map[uint64]string{0x1: "du-a"}, expectedId: 0xe00},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := allocateDuId(tc.usedIds)
+			if err != nil || got != tc.expectedId {
+				t.Errorf("allocateDuId returned 0x%x, %v, expected 0x%x", got, err, tc.expectedId)
+			}
+		})
+	}
+}
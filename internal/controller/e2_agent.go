@@ -0,0 +1,266 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+const (
+	defaultE2RicPort = 36421
+	defaultE2SmDir   = "/usr/local/lib/flexric/"
+	// The selected service models are copied there by an init container
+	selectedE2SmDir       = "/opt/oai-gnb/e2-sm/"
+	e2ServiceModelsVolume = "e2-service-models"
+)
+
+var (
+	// e2SetupRequestRegexp matches the line of the FlexRIC agent sending the E2 Setup Request, e.g.
+	// [E2 AGENT]: E2 SETUP-REQUEST tx
+	e2SetupRequestRegexp = regexp.MustCompile(`(?i)\[E2[ -]AGENT\].*E2 SETUP[ -]REQUEST tx`)
+	// e2SetupResponseRegexp matches the line of the FlexRIC agent receiving the E2 Setup Response, e.g.
+	// [E2 AGENT]: E2 SETUP RESPONSE rx
+	e2SetupResponseRegexp = regexp.MustCompile(`(?i)\[E2[ -]AGENT\].*E2 SETUP[ -]RESPONSE rx`)
+)
+
+// getE2Agent returns the E2 agent of the OAIConfig with its defaults applied, nil if the E2 agent is not enabled
+func getE2Agent(configInfo *ConfigInfo) (*workloadnfconfig.E2AgentConfig, error) {
+	rawOaiConfig, found := configInfo.ConfigSelfInfo["OAIConfig"]
+	if !found {
+		return nil, nil
+	}
+	paramsOAI := &workloadnfconfig.OAIConfig{}
+	if err := json.Unmarshal(rawOaiConfig.Raw, paramsOAI); err != nil {
+		return nil, err
+	}
+	e2Agent := paramsOAI.Spec.E2Agent
+	if e2Agent == nil {
+		return nil, nil
+	}
+
+	if net.ParseIP(e2Agent.RICAddress) == nil {
+		return nil, fmt.Errorf("invalid near-RT RIC address %q", e2Agent.RICAddress)
+	}
+	if e2Agent.RICPort == 0 {
+		e2Agent.RICPort = defaultE2RicPort
+	}
+	if e2Agent.SMDir == "" {
+		e2Agent.SMDir = defaultE2SmDir
+	}
+	return e2Agent, nil
+}
+
+// getE2AgentTemplateValues returns the template values of the e2_agent block, nil if the E2 agent is not enabled
+func getE2AgentTemplateValues(e2Agent *workloadnfconfig.E2AgentConfig) *configurationTemplateValuesForE2Agent {
+	if e2Agent == nil {
+		return nil
+	}
+	smDir := e2Agent.SMDir
+	if len(e2Agent.ServiceModels) > 0 {
+		smDir = selectedE2SmDir
+	}
+	values := &configurationTemplateValuesForE2Agent{
+		RIC_IP: strconv.Quote(e2Agent.RICAddress),
+		SM_DIR: strconv.Quote(smDir),
+	}
+	// The port is left out of the configuration when it is the default one of the agent
+	if e2Agent.RICPort != defaultE2RicPort {
+		values.RIC_PORT = e2Agent.RICPort
+	}
+	return values
+}
+
+// getE2ServiceModelLibrary returns the file name of the library of a FlexRIC service model, e.g. libkpm_sm.so
+func getE2ServiceModelLibrary(serviceModel workloadnfconfig.E2ServiceModel) string {
+	return "lib" + strings.ToLower(string(serviceModel)) + "_sm.so"
+}
+
+// addE2ServiceModels restricts the service models loaded by the E2 agent, the FlexRIC agent loads every
+// library of its sm_dir so only the selected ones are copied into a dedicated directory by an init container
+func addE2ServiceModels(podSpec *corev1.PodSpec, e2Agent *workloadnfconfig.E2AgentConfig, image string) {
+	if e2Agent == nil || len(e2Agent.ServiceModels) == 0 {
+		return
+	}
+
+	libraries := make([]string, 0, len(e2Agent.ServiceModels))
+	for _, serviceModel := range e2Agent.ServiceModels {
+		libraries = append(libraries, path.Join(e2Agent.SMDir, getE2ServiceModelLibrary(serviceModel)))
+	}
+	volumeMount := corev1.VolumeMount{
+		Name:      e2ServiceModelsVolume,
+		MountPath: selectedE2SmDir,
+	}
+
+	podSpec.InitContainers = append(podSpec.InitContainers, corev1.Container{
+		Name:         "e2-service-models",
+		Image:        image,
+		Command:      append([]string{"cp"}, append(libraries, selectedE2SmDir)...),
+		VolumeMounts: []corev1.VolumeMount{volumeMount},
+	})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: e2ServiceModelsVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, volumeMount)
+}
+
+// getE2NodeId describes the global E2 node ID of a NF, which the E2 agent derives from the PLMN and the gNB identifiers
+func getE2NodeId(ranDeployment *workloadv1alpha1.NFDeployment, plmnInfo *workloadnfconfig.PLMNInfo) string {
+	globalGnbId := fmt.Sprintf("gNB %s/%s 0x%x", plmnInfo.PLMNID.MCC, plmnInfo.PLMNID.MNC, gnbId)
+	switch ranDeployment.Spec.Provider {
+	case "cucp.openairinterface.org":
+		return globalGnbId + " CU-CP"
+	case "cuup.openairinterface.org":
+		cuUpId, found, err := getCuUpId(ranDeployment)
+		if err != nil || !found {
+			cuUpId = firstGnbCuUpId
+		}
+		return fmt.Sprintf("%s CU-UP 0x%x", globalGnbId, cuUpId)
+	default:
+		duId, err := getDuIdOrDefault(ranDeployment)
+		if err != nil {
+			duId = firstGnbDuId
+		}
+		return fmt.Sprintf("%s DU 0x%x", globalGnbId, duId)
+	}
+}
+
+// UpdateE2AgentStatus records the E2 agent configuration of a NF in its status, the condition is left out when the E2 agent is not enabled.
+// It reflects the rendered configuration only, the E2 setup with the near-RT RIC is reported by UpdateE2SetupStatus
+func (r *RANDeploymentReconciler) UpdateE2AgentStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	curCondition := metav1.Condition{
+		Type:               "e2AgentConfigured",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Reason:             "e2AgentConfigured",
+	}
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		curCondition.Status = metav1.ConditionFalse
+		curCondition.Message = "Invalid E2 agent | Error: " + err.Error()
+		return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+	}
+	if e2Agent == nil {
+		return nil
+	}
+
+	paramsPlmn := &workloadnfconfig.PLMN{}
+	if err := json.Unmarshal(configInfo.ConfigSelfInfo["PLMN"].Raw, paramsPlmn); err != nil || len(paramsPlmn.Spec.PLMNInfo) == 0 {
+		return fmt.Errorf("cannot get the PLMN of the E2 node: %v", err)
+	}
+	serviceModels := "all"
+	if len(e2Agent.ServiceModels) > 0 {
+		names := make([]string, 0, len(e2Agent.ServiceModels))
+		for _, serviceModel := range e2Agent.ServiceModels {
+			names = append(names, string(serviceModel))
+		}
+		serviceModels = strings.Join(names, " ")
+	}
+	curCondition.Status = metav1.ConditionTrue
+	curCondition.Message = fmt.Sprintf("E2 node %s configured towards near-RT RIC %s with service models %s",
+		getE2NodeId(ranDeployment, &paramsPlmn.Spec.PLMNInfo[0]), net.JoinHostPort(e2Agent.RICAddress, strconv.Itoa(int(e2Agent.RICPort))), serviceModels)
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
+
+// classifyE2Setup returns True when the last E2 setup line of the logs of the E2 agent is the E2 Setup Response, False
+// when it is the E2 Setup Request and Unknown when the logs have no E2 setup line
+func classifyE2Setup(logs string) metav1.ConditionStatus {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	for index := len(lines) - 1; index >= 0; index-- {
+		if e2SetupResponseRegexp.MatchString(lines[index]) {
+			return metav1.ConditionTrue
+		}
+		if e2SetupRequestRegexp.MatchString(lines[index]) {
+			return metav1.ConditionFalse
+		}
+	}
+	return metav1.ConditionUnknown
+}
+
+// UpdateE2SetupStatus records in the e2Setup condition whether the E2 agent of the running NF container of a NF
+// received the E2 Setup Response of the near-RT RIC, from the lines of its logs. The condition is left out when the E2
+// agent is not enabled or the logs are not read, and kept while the same container runs once its E2 setup lines left
+// the last lines of the logs
+func (r *RANDeploymentReconciler) UpdateE2SetupStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	if r.LogReader == nil {
+		return nil
+	}
+	container, found := softmodemContainers[ranDeployment.Spec.Provider]
+	if !found {
+		return nil
+	}
+	// An invalid E2 agent is reported by the e2AgentConfigured condition
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil || e2Agent == nil {
+		return nil
+	}
+	pods, err := r.getNfPods(ctx, ranDeployment)
+	if err != nil {
+		return err
+	}
+
+	ric := net.JoinHostPort(e2Agent.RICAddress, strconv.Itoa(int(e2Agent.RICPort)))
+	curCondition := metav1.Condition{
+		Type:               "e2Setup",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionUnknown,
+		Reason:             "e2Setup",
+		Message:            fmt.Sprintf("No running container %s to set up E2 with near-RT RIC %s", container, ric),
+	}
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != container || status.State.Running == nil {
+				continue
+			}
+			logs, err := r.LogReader.ReadLogs(ctx, pod.Namespace, pod.Name, container, false)
+			if err != nil {
+				return err
+			}
+			origin := fmt.Sprintf("container %s of pod %s started at %s", container, pod.Name, status.State.Running.StartedAt.UTC().Format(time.RFC3339))
+			curCondition.Status = classifyE2Setup(logs)
+			switch curCondition.Status {
+			case metav1.ConditionTrue:
+				curCondition.Message = fmt.Sprintf("E2 Setup Response received from near-RT RIC %s | %s", ric, origin)
+			case metav1.ConditionFalse:
+				curCondition.Message = fmt.Sprintf("E2 Setup Request sent to near-RT RIC %s without E2 Setup Response | %s", ric, origin)
+			default:
+				previous := meta.FindStatusCondition(ranDeployment.Status.Conditions, curCondition.Type)
+				if previous != nil && strings.HasSuffix(previous.Message, "| "+origin) {
+					return nil
+				}
+				curCondition.Message = fmt.Sprintf("No E2 Setup Request to near-RT RIC %s in the logs | %s", ric, origin)
+			}
+			return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+		}
+	}
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	context "context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func configInfoWithE2Agent(e2Agent *workloadnfconfig.E2AgentConfig) *ConfigInfo {
+	configInfo := NewConfigInfo()
+	configInfo.ConfigSelfInfo["OAIConfig"] = runtime.RawExtension{Raw: marshalJsonReturnByteOnly(workloadnfconfig.OAIConfig{
+		Spec: workloadnfconfig.OAIConfigSpec{Image: "oai-gnb:develop", E2Agent: e2Agent},
+	})}
+	configInfo.ConfigSelfInfo["PLMN"] = runtime.RawExtension{Raw: marshalJsonReturnByteOnly(workloadnfconfig.PLMN{
+		Spec: workloadnfconfig.PLMNSpec{PLMNInfo: []workloadnfconfig.PLMNInfo{{
+			PLMNID: workloadnfconfig.PLMNID{MCC: "001", MNC: "01"},
+			NSSAI:  []workloadnfconfig.NSSAI{{SST: 1, SD: ptr.To("ffffff")}},
+		}}},
+	})}
+	return configInfo
+}
+
+func TestGetE2Agent(t *testing.T) {
+	cases := map[string]struct {
+		configInfo      *ConfigInfo
+		expectedE2Agent *workloadnfconfig.E2AgentConfig
+		wantsError      bool
+	}{
+		"No OAIConfig": {
+			configInfo: NewConfigInfo(),
+		},
+		"E2 Agent Disabled": {
+			configInfo: configInfoWithE2Agent(nil),
+		},
+		"Defaults Applied": {
+			configInfo:      configInfoWithE2Agent(&workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"}),
+			expectedE2Agent: &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36421, SMDir: "/usr/local/lib/flexric/"},
+		},
+		"IPv6 RIC": {
+			configInfo:      configInfoWithE2Agent(&workloadnfconfig.E2AgentConfig{RICAddress: "fd00::5", SMDir: "/opt/flexric/"}),
+			expectedE2Agent: &workloadnfconfig.E2AgentConfig{RICAddress: "fd00::5", RICPort: 36421, SMDir: "/opt/flexric/"},
+		},
+		"RIC Name Instead Of Address": {
+			configInfo: configInfoWithE2Agent(&workloadnfconfig.E2AgentConfig{RICAddress: "ric.example.com"}),
+			wantsError: true,
+		},
+		"Other RIC Port": {
+			configInfo:      configInfoWithE2Agent(&workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36422}),
+			expectedE2Agent: &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36422, SMDir: "/usr/local/lib/flexric/"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := getE2Agent(tc.configInfo)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("getE2Agent returned %v, expecting an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getE2Agent returned error %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expectedE2Agent) {
+				t.Errorf("getE2Agent returned %v, expected %v", got, tc.expectedE2Agent)
+			}
+		})
+	}
+}
+
+func TestRenderConfigurationTemplateWithE2Agent(t *testing.T) {
+	e2Agent := &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36421, SMDir: "/usr/local/lib/flexric/"}
+	selectedE2Agent := &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36421, SMDir: "/usr/local/lib/flexric/", ServiceModels: []workloadnfconfig.E2ServiceModel{"KPM"}}
+
+	cases := map[string]struct {
+		render         func(*configurationTemplateValuesForE2Agent) (string, error)
+		e2Agent        *workloadnfconfig.E2AgentConfig
+		expectedSmDir  string
+		expectedPort   string
+		expectedE2Conf bool
+	}{
+		"CU-CP Without E2": {
+			render: func(e2 *configurationTemplateValuesForE2Agent) (string, error) {
+				return renderConfigurationTemplateForCuCp(configurationTemplateValuesForCuCp{E2_AGENT: e2})
+			},
+		},
+		"CU-CP": {
+			render: func(e2 *configurationTemplateValuesForE2Agent) (string, error) {
+				return renderConfigurationTemplateForCuCp(configurationTemplateValuesForCuCp{E2_AGENT: e2})
+			},
+			e2Agent:        e2Agent,
+			expectedSmDir:  `sm_dir = "/usr/local/lib/flexric/";`,
+			expectedE2Conf: true,
+		},
+		"CU-UP With Selected Service Models": {
+			render: func(e2 *configurationTemplateValuesForE2Agent) (string, error) {
+				return renderConfigurationTemplateForCuUp(configurationTemplateValuesForCuUp{E2_AGENT: e2})
+			},
+			e2Agent:        selectedE2Agent,
+			expectedSmDir:  `sm_dir = "/opt/oai-gnb/e2-sm/";`,
+			expectedE2Conf: true,
+		},
+		"DU": {
+			render: func(e2 *configurationTemplateValuesForE2Agent) (string, error) {
+				return renderConfigurationTemplateForDu(configurationTemplateValuesForDu{CELLS: []configurationTemplateValuesForCell{{}}, E2_AGENT: e2})
+			},
+			e2Agent:        e2Agent,
+			expectedSmDir:  `sm_dir = "/usr/local/lib/flexric/";`,
+			expectedE2Conf: true,
+		},
+		"DU With Other RIC Port": {
+			render: func(e2 *configurationTemplateValuesForE2Agent) (string, error) {
+				return renderConfigurationTemplateForDu(configurationTemplateValuesForDu{CELLS: []configurationTemplateValuesForCell{{}}, E2_AGENT: e2})
+			},
+			e2Agent:        &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36422, SMDir: "/usr/local/lib/flexric/"},
+			expectedSmDir:  `sm_dir = "/usr/local/lib/flexric/";`,
+			expectedPort:   "port = 36422;",
+			expectedE2Conf: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			configuration, err := tc.render(getE2AgentTemplateValues(tc.e2Agent))
+			if err != nil {
+				t.Fatalf("Rendering returned error %v", err)
+			}
+			if strings.Contains(configuration, "e2_agent") != tc.expectedE2Conf {
+				t.Errorf("Configuration contains an e2_agent block: %v, expected %v:\n%s", !tc.expectedE2Conf, tc.expectedE2Conf, configuration)
+			}
+			if tc.expectedE2Conf && (!strings.Contains(configuration, `near_ric_ip_addr = "10.0.5.2";`) || !strings.Contains(configuration, tc.expectedSmDir)) {
+				t.Errorf("Configuration must contain the RIC address and %s:\n%s", tc.expectedSmDir, configuration)
+			}
+			if strings.Contains(configuration, "  port = ") != (tc.expectedPort != "") || !strings.Contains(configuration, tc.expectedPort) {
+				t.Errorf("Configuration must contain the RIC port %q only when it is not the default one:\n%s", tc.expectedPort, configuration)
+			}
+		})
+	}
+}
+
+func TestAddE2ServiceModels(t *testing.T) {
+	podSpec := corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "du"}},
+	}
+	addE2ServiceModels(&podSpec, &workloadnfconfig.E2AgentConfig{SMDir: "/usr/local/lib/flexric/"}, "oai-gnb:develop")
+	if len(podSpec.InitContainers) != 0 || len(podSpec.Volumes) != 0 {
+		t.Errorf("addE2ServiceModels must not change the pod when all the service models are loaded")
+	}
+
+	addE2ServiceModels(&podSpec, &workloadnfconfig.E2AgentConfig{SMDir: "/usr/local/lib/flexric/", ServiceModels: []workloadnfconfig.E2ServiceModel{"KPM", "RC"}}, "oai-gnb:develop")
+	expectedCommand := []string{"cp", "/usr/local/lib/flexric/libkpm_sm.so", "/usr/local/lib/flexric/librc_sm.so", "/opt/oai-gnb/e2-sm/"}
+	if len(podSpec.InitContainers) != 1 || !reflect.DeepEqual(podSpec.InitContainers[0].Command, expectedCommand) || podSpec.InitContainers[0].Image != "oai-gnb:develop" {
+		t.Errorf("addE2ServiceModels init containers %v, expected one copying %v", podSpec.InitContainers, expectedCommand)
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].EmptyDir == nil {
+		t.Errorf("addE2ServiceModels volumes %v, expected one emptyDir", podSpec.Volumes)
+	}
+	if len(podSpec.Containers[0].VolumeMounts) != 1 || podSpec.Containers[0].VolumeMounts[0].MountPath != "/opt/oai-gnb/e2-sm/" {
+		t.Errorf("addE2ServiceModels volume mounts %v, expected the selected service models", podSpec.Containers[0].VolumeMounts)
+	}
+}
+
+func TestUpdateE2AgentStatus(t *testing.T) {
+	cases := map[string]struct {
+		provider        string
+		annotations     map[string]string
+		e2Agent         *workloadnfconfig.E2AgentConfig
+		expectedStatus  metav1.ConditionStatus
+		expectedMessage string
+	}{
+		"E2 Agent Disabled": {
+			provider: "cucp.openairinterface.org",
+		},
+		"CU-CP": {
+			provider:        "cucp.openairinterface.org",
+			e2Agent:         &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "E2 node gNB 001/01 0xe00 CU-CP configured towards near-RT RIC 10.0.5.2:36421 with service models all",
+		},
+		"CU-UP": {
+			provider:        "cuup.openairinterface.org",
+			annotations:     map[string]string{CuUpIdAnnotation: "0xe01"},
+			e2Agent:         &workloadnfconfig.E2AgentConfig{RICAddress: "fd00::5", ServiceModels: []workloadnfconfig.E2ServiceModel{"KPM", "GTP"}},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "E2 node gNB 001/01 0xe00 CU-UP 0xe01 configured towards near-RT RIC [fd00::5]:36421 with service models KPM GTP",
+		},
+		"DU": {
+			provider:        "du.openairinterface.org",
+			annotations:     map[string]string{DuIdAnnotation: "0xe02"},
+			e2Agent:         &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2", RICPort: 36422},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "E2 node gNB 001/01 0xe00 DU 0xe02 configured towards near-RT RIC 10.0.5.2:36422 with service models all",
+		},
+		"Invalid E2 Agent": {
+			provider:        "du.openairinterface.org",
+			e2Agent:         &workloadnfconfig.E2AgentConfig{RICAddress: "ric"},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedMessage: "Invalid E2 agent | Error: invalid near-RT RIC address \"ric\"",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+
+			ranReconcilerObj := RANDeploymentReconciler{
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
+			}
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: tc.provider},
+			}
+			if err := ranReconcilerObj.UpdateE2AgentStatus(context.TODO(), ranDeployment, configInfoWithE2Agent(tc.e2Agent)); err != nil {
+				t.Fatalf("UpdateE2AgentStatus returned error %v", err)
+			}
+			if tc.e2Agent == nil {
+				if len(ranDeployment.Status.Conditions) != 0 {
+					t.Errorf("UpdateE2AgentStatus set %v while the E2 agent is disabled", ranDeployment.Status.Conditions)
+				}
+				return
+			}
+			condition := ranDeployment.Status.Conditions[0]
+			if condition.Type != "e2AgentConfigured" || condition.Status != tc.expectedStatus || condition.Message != tc.expectedMessage {
+				t.Errorf("UpdateE2AgentStatus set condition %v/%v %q, expected e2AgentConfigured/%v %q", condition.Type, condition.Status, condition.Message, tc.expectedStatus, tc.expectedMessage)
+			}
+		})
+	}
+}
+
+func TestClassifyE2Setup(t *testing.T) {
+	cases := map[string]struct {
+		logs string
+		want metav1.ConditionStatus
+	}{
+		"Setup Response Received": {
+			logs: "[E2 AGENT]: E2 SETUP-REQUEST tx\n[E2 AGENT]: Transaction ID E2 SETUP-REQUEST 0 E2 SETUP-RESPONSE 0\n[E2 AGENT]: E2 SETUP RESPONSE rx\n",
+			want: metav1.ConditionTrue,
+		},
+		"Setup Request Without Response": {
+			logs: "[E2 AGENT]: E2 SETUP-REQUEST tx\n[SCTP]   sctp_connectx(): Connection refused\n",
+			want: metav1.ConditionFalse,
+		},
+		"Setup Request Sent Again": {
+			logs: "[E2 AGENT]: E2 SETUP RESPONSE rx\n[E2 AGENT]: E2 SETUP-REQUEST tx\n",
+			want: metav1.ConditionFalse,
+		},
+		"No Setup Line": {
+			logs: readSampleLogs(t, "healthy.log"),
+			want: metav1.ConditionUnknown,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := classifyE2Setup(tc.logs); got != tc.want {
+				t.Errorf("classifyE2Setup returned %s, expected %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdateE2SetupStatus(t *testing.T) {
+	startedAt := metav1.NewTime(time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC))
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}}
+	cases := map[string]struct {
+		e2Agent     *workloadnfconfig.E2AgentConfig
+		state       corev1.ContainerState
+		logs        string
+		conditions  []metav1.Condition
+		wantStatus  metav1.ConditionStatus
+		wantMessage string
+	}{
+		"E2 Agent Disabled": {
+			state: running,
+			logs:  "[E2 AGENT]: E2 SETUP RESPONSE rx\n",
+		},
+		"Setup Response Received": {
+			e2Agent:     &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"},
+			state:       running,
+			logs:        "[E2 AGENT]: E2 SETUP-REQUEST tx\n[E2 AGENT]: E2 SETUP RESPONSE rx\n",
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: "E2 Setup Response received from near-RT RIC 10.0.5.2:36421 | container du of pod oai-du-5d8f started at 2024-05-02T10:00:00Z",
+		},
+		"Setup Request Without Response": {
+			e2Agent:     &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"},
+			state:       running,
+			logs:        "[E2 AGENT]: E2 SETUP-REQUEST tx\n",
+			wantStatus:  metav1.ConditionFalse,
+			wantMessage: "E2 Setup Request sent to near-RT RIC 10.0.5.2:36421 without E2 Setup Response | container du of pod oai-du-5d8f started at 2024-05-02T10:00:00Z",
+		},
+		"Setup Lines Out Of The Logs": {
+			e2Agent: &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"},
+			state:   running,
+			logs:    readSampleLogs(t, "healthy.log"),
+			conditions: []metav1.Condition{{
+				Type:    "e2Setup",
+				Status:  metav1.ConditionTrue,
+				Reason:  "e2Setup",
+				Message: "E2 Setup Response received from near-RT RIC 10.0.5.2:36421 | container du of pod oai-du-5d8f started at 2024-05-02T10:00:00Z",
+			}},
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: "E2 Setup Response received from near-RT RIC 10.0.5.2:36421 | container du of pod oai-du-5d8f started at 2024-05-02T10:00:00Z",
+		},
+		"Setup Of The Previous Container": {
+			e2Agent: &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"},
+			state:   running,
+			logs:    readSampleLogs(t, "healthy.log"),
+			conditions: []metav1.Condition{{
+				Type:    "e2Setup",
+				Status:  metav1.ConditionTrue,
+				Reason:  "e2Setup",
+				Message: "E2 Setup Response received from near-RT RIC 10.0.5.2:36421 | container du of pod oai-du-5d8f started at 2024-05-01T08:00:00Z",
+			}},
+			wantStatus:  metav1.ConditionUnknown,
+			wantMessage: "No E2 Setup Request to near-RT RIC 10.0.5.2:36421 in the logs | container du of pod oai-du-5d8f started at 2024-05-02T10:00:00Z",
+		},
+		"Container Not Running": {
+			e2Agent:     &workloadnfconfig.E2AgentConfig{RICAddress: "10.0.5.2"},
+			state:       corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			wantStatus:  metav1.ConditionUnknown,
+			wantMessage: "No running container du to set up E2 with near-RT RIC 10.0.5.2:36421",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Deployment")).Return(nil).Run(func(args mock.Arguments) {
+				deployment := args.Get(2).(*appsv1.Deployment)
+				deployment.Namespace = "oai"
+				deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "oai-du"}}
+			})
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1.PodList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				args.Get(1).(*corev1.PodList).Items = []corev1.Pod{{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "oai-du-5d8f"},
+					Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "du", State: tc.state}}},
+				}}
+			})
+			r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme(), LogReader: &stubLogReader{logs: tc.logs}}
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "du"},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+				Status:     workloadv1alpha1.NFDeploymentStatus{Conditions: tc.conditions},
+			}
+			if err := r.UpdateE2SetupStatus(context.TODO(), ranDeployment, configInfoWithE2Agent(tc.e2Agent)); err != nil {
+				t.Fatalf("UpdateE2SetupStatus returned error %v", err)
+			}
+			if tc.e2Agent == nil {
+				if len(ranDeployment.Status.Conditions) != 0 {
+					t.Errorf("UpdateE2SetupStatus set %v while the E2 agent is disabled", ranDeployment.Status.Conditions)
+				}
+				return
+			}
+			condition := ranDeployment.Status.Conditions[0]
+			if condition.Type != "e2Setup" || condition.Status != tc.wantStatus || condition.Message != tc.wantMessage {
+				t.Errorf("UpdateE2SetupStatus set condition %v/%v %q, expected e2Setup/%v %q", condition.Type, condition.Status, condition.Message, tc.wantStatus, tc.wantMessage)
+			}
+		})
+	}
+}
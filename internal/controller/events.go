@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons of the events recorded on the NFDeployments, alerts can select the failures on the *Failed, InvalidProvider,
+// MissingPeer, PodSecurityViolation and SoftmodemFailure reasons. They are kept across releases
+const (
+	ReasonInvalidProvider        = "InvalidProvider"
+	ReasonConfigResolved         = "ConfigResolved"
+	ReasonConfigResolutionFailed = "ConfigResolutionFailed"
+	ReasonMissingPeer            = "MissingPeer"
+	ReasonPodSecurityViolation   = "PodSecurityViolation"
+	ReasonRenderFailed           = "RenderFailed"
+	ReasonCreated                = "Created"
+	ReasonCreateFailed           = "CreateFailed"
+	ReasonUpdated                = "Updated"
+	ReasonUpdateFailed           = "UpdateFailed"
+	ReasonDeleted                = "Deleted"
+	ReasonDeleteFailed           = "DeleteFailed"
+	ReasonFinalizerRemoved       = "FinalizerRemoved"
+	ReasonDryRun                 = "DryRun"
+	ReasonPaused                 = "Paused"
+	ReasonResumed                = "Resumed"
+	ReasonSoftmodemFailure       = "SoftmodemFailure"
+)
+
+// recordEvent records an event on a NFDeployment, nothing is recorded when the reconciler has no Recorder
+func (r *RANDeploymentReconciler) recordEvent(ranDeployment *workloadv1alpha1.NFDeployment, eventType string, reason string, messageFmt string, args ...any) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(ranDeployment, eventType, reason, messageFmt, args...)
+}
+
+// recordResourceEvent records the creation or the deletion of a resource of a NFDeployment, as a Warning with the
+// error when it failed
+func (r *RANDeploymentReconciler) recordResourceEvent(ranDeployment *workloadv1alpha1.NFDeployment, verb string, kind string, resource client.Object, err error) {
+	reason, failedReason, done := ReasonCreated, ReasonCreateFailed, "Created"
+	if verb == "delete" {
+		reason, failedReason, done = ReasonDeleted, ReasonDeleteFailed, "Deleted"
+	}
+	if err != nil {
+		r.recordEvent(ranDeployment, corev1.EventTypeWarning, failedReason, "Cannot %s %s %s: %s", verb, kind, resource.GetName(), err.Error())
+		return
+	}
+	r.recordEvent(ranDeployment, corev1.EventTypeNormal, reason, "%s %s %s", done, kind, resource.GetName())
+}
+
+// getMissingPeers returns why the peers a NFDeployment connects to are missing from its Config refs: the CU-CP of
+// a DU or a CU-UP and the AMF of a CU-CP
+func getMissingPeers(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []string {
+	var missingPeers []string
+	switch ranDeployment.Spec.Provider {
+	case "cucp.openairinterface.org":
+		if len(getConfigInstancesByProvider(log, configInfo.ConfigRefInfo["NFDeployment"], "amf.openairinterface.org")) == 0 {
+			missingPeers = append(missingPeers, "no AMF NFDeployment in the Config refs")
+		}
+	case "cuup.openairinterface.org", "du.openairinterface.org":
+		if _, err := getCuCpDeployment(log, ranDeployment, configInfo); err != nil {
+			missingPeers = append(missingPeers, fmt.Sprintf("no CU-CP to attach to: %s", err.Error()))
+		}
+	}
+	return missingPeers
+}
+
+// countConfigRefs returns the number of Config refs resolved for a NFDeployment
+func countConfigRefs(configInfo *ConfigInfo) int {
+	count := 0
+	for _, configRefs := range configInfo.ConfigRefInfo {
+		count += len(configRefs)
+	}
+	return count
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configref "github.com/nephio-project/api/references/v1alpha1"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCreateAllEvents(t *testing.T) {
+	clientMock := new(MockClient)
+	clientMock.On("Create", context.TODO(), mock.AnythingOfType("*v1.ServiceAccount")).Return(nil)
+	clientMock.On("Create", context.TODO(), mock.AnythingOfType("*v1.Service")).Return(errors.New("service already exists"))
+	recorder := record.NewFakeRecorder(10)
+	r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme(), Recorder: recorder}
+
+	// The ConfigMap and the Deployment cannot be rendered
+	nfResourceMock := new(MockNfResource)
+	nfResourceMock.On("GetNetworkAttachmentDefinitions", mock.Anything, mock.Anything, mock.Anything).Return([]*unstructured.Unstructured(nil), nil)
+	nfResourceMock.On("GetServiceAccount").Return([]*corev1.ServiceAccount{{ObjectMeta: metav1.ObjectMeta{Name: "oai-du-sa"}}})
+	nfResourceMock.On("GetConfigMap", mock.Anything, mock.Anything, mock.Anything).Return([]*corev1.ConfigMap(nil))
+	nfResourceMock.On("GetDeployment", mock.Anything, mock.Anything, mock.Anything).Return([]*appsv1.Deployment(nil))
+	nfResourceMock.On("GetService", mock.Anything, mock.Anything, mock.Anything).Return([]*corev1.Service{{ObjectMeta: metav1.ObjectMeta{Name: "oai-du"}}})
+
+	errList := r.CreateAll(context.TODO(), &workloadv1alpha1.NFDeployment{}, nfResourceMock, NewConfigInfo())
+	if len(errList) != 1 {
+		t.Errorf("CreateAll returned the errors %v, expected the one of the Service", errList)
+	}
+	close(recorder.Events)
+	events := []string{}
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	wantEvents := []string{
+		"Normal Created Created ServiceAccount oai-du-sa",
+		"Warning RenderFailed Cannot render the ConfigMap, see the logs of the controller",
+		"Warning RenderFailed Cannot render the Deployment, see the logs of the controller",
+		"Warning CreateFailed Cannot create Service oai-du: service already exists",
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("CreateAll recorded the events %q, expected %q", events, wantEvents)
+	}
+}
+
+func TestGetMissingPeers(t *testing.T) {
+	cases := map[string]struct {
+		provider         string
+		configRefs       []*configref.Config
+		wantMissingPeers int
+	}{
+		"DU With A CU-CP":     {provider: "du.openairinterface.org", configRefs: []*configref.Config{cuCpConfigRef("cucp-a")}},
+		"DU Without CU-CP":    {provider: "du.openairinterface.org", wantMissingPeers: 1},
+		"CU-UP Without CU-CP": {provider: "cuup.openairinterface.org", wantMissingPeers: 1},
+		"CU-CP Without AMF":   {provider: "cucp.openairinterface.org", configRefs: []*configref.Config{cuCpConfigRef("cucp-a")}, wantMissingPeers: 1},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			configInfo := NewConfigInfo()
+			configInfo.ConfigRefInfo["NFDeployment"] = tc.configRefs
+			ranDeployment := &workloadv1alpha1.NFDeployment{Spec: workloadv1alpha1.NFDeploymentSpec{Provider: tc.provider}}
+			if missingPeers := getMissingPeers(logr.Discard(), ranDeployment, configInfo); len(missingPeers) != tc.wantMissingPeers {
+				t.Errorf("getMissingPeers returned %q, expected %d missing peers", missingPeers, tc.wantMissingPeers)
+			}
+		})
+	}
+}
+
+func TestRecordEventWithoutRecorder(t *testing.T) {
+	r := RANDeploymentReconciler{Scheme: runtime.NewScheme()}
+	// Without Recorder the events are dropped
+	r.recordEvent(&workloadv1alpha1.NFDeployment{}, corev1.EventTypeNormal, ReasonCreated, "Created %s", "oai-du")
+}
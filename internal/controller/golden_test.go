@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+/*
+The golden tests render the resources of the NFDeployment of each scenario of testdata/golden and compare them to the
+files of the scenario:
+  - input.yaml is the NFDeployment with the Configs and the NFConfig it references
+  - configmaps.yaml are the expected ConfigMaps, each of their data is stored in its own file named after the ConfigMap
+    and the key, e.g. oai-cu-cp-configmap.gnb.conf, to be diffed line by line
+  - manifests.yaml are the other expected resources: NetworkAttachmentDefinitions, ServiceAccounts, Deployments and Services
+
+After an intended change of the rendering, the files are updated with go test ./internal/controller/ -run Golden -update
+and the changes reviewed in the diff.
+*/
+
+var update = flag.Bool("update", false, "update the golden files of testdata/golden with the rendered resources")
+
+// goldenNamespace is the namespace of the objects of the inputs
+const goldenNamespace = "oai-ran"
+
+// loadGoldenInput returns the NFDeployment of the input.yaml of a scenario and the other objects of the input
+func loadGoldenInput(t *testing.T, scenario string) (*workloadv1alpha1.NFDeployment, []client.Object) {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(scenario, "input.yaml"))
+	if err != nil {
+		t.Fatalf("Cannot read the input: %v", err)
+	}
+	objects := []client.Object{}
+	var ranDeployment *workloadv1alpha1.NFDeployment
+	for _, document := range strings.Split(string(content), "\n---\n") {
+		object := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(document), &object.Object); err != nil {
+			t.Fatalf("Cannot parse the input: %v", err)
+		}
+		object.SetNamespace(goldenNamespace)
+		if object.GetKind() == "NFDeployment" {
+			ranDeployment = &workloadv1alpha1.NFDeployment{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, ranDeployment); err != nil {
+				t.Fatalf("Cannot decode the NFDeployment: %v", err)
+			}
+			continue
+		}
+		objects = append(objects, object)
+	}
+	if ranDeployment == nil {
+		t.Fatalf("No NFDeployment in the input")
+	}
+	return ranDeployment, objects
+}
+
+// renderGoldenScenario renders the resources of the NFDeployment of the input.yaml of a scenario, its Configs and
+// NFConfig are read from a fake client
+func renderGoldenScenario(t *testing.T, scenario string) *RenderedResources {
+	t.Helper()
+	ranDeployment, objects := loadGoldenInput(t, scenario)
+	scheme := newManagerScheme()
+	r := RANDeploymentReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), Scheme: scheme}
+	rendered, err := r.Render(context.TODO(), ranDeployment)
+	if err != nil {
+		t.Fatalf("Render returned error %v", err)
+	}
+	return rendered
+}
+
+// marshalGolden writes objects as a YAML stream, with their kind and without their empty creation timestamp and status
+func marshalGolden(t *testing.T, scheme *runtime.Scheme, objects ...client.Object) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	for _, object := range objects {
+		if object.GetObjectKind().GroupVersionKind().Empty() {
+			gvk, err := apiutil.GVKForObject(object, scheme)
+			if err != nil {
+				t.Fatalf("Cannot get the kind of %s: %v", object.GetName(), err)
+			}
+			object.GetObjectKind().SetGroupVersionKind(gvk)
+		}
+		raw, err := json.Marshal(object)
+		if err != nil {
+			t.Fatalf("Cannot marshal %s: %v", object.GetName(), err)
+		}
+		item := map[string]any{}
+		if err := json.Unmarshal(raw, &item); err != nil {
+			t.Fatalf("Cannot unmarshal %s: %v", object.GetName(), err)
+		}
+		unstructured.RemoveNestedField(item, "metadata", "creationTimestamp")
+		unstructured.RemoveNestedField(item, "status")
+		document, err := yaml.Marshal(item)
+		if err != nil {
+			t.Fatalf("Cannot marshal %s: %v", object.GetName(), err)
+		}
+		out.WriteString("---\n")
+		out.Write(document)
+	}
+	return out.Bytes()
+}
+
+// checkGolden compares got to the golden file, which is written instead with -update
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Cannot update the golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Cannot read the golden file, run the test with -update to create it: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("The rendered resources differ from %s, run the test with -update if the change is intended:\n%s",
+			path, strings.Join(lineDiff(string(want), string(got)), "\n"))
+	}
+}
+
+func TestGoldenRender(t *testing.T) {
+	// The scenarios of each provider, the directories of testdata/golden
+	scenarios := []string{
+		"cucp-single-plmn",
+		"cucp-multi-slice",
+		"cucp-dual-stack",
+		"cuup-single-plmn",
+		"cuup-multi-slice",
+		"cuup-dual-stack",
+		"du-rfsim-band-n78",
+		"du-usrp-band-n41",
+		"du-multi-slice",
+		"du-dual-stack",
+	}
+
+	for _, name := range scenarios {
+		t.Run(name, func(t *testing.T) {
+			scenario := filepath.Join("testdata", "golden", name)
+			rendered := renderGoldenScenario(t, scenario)
+
+			scheme := newManagerScheme()
+			configMaps := []client.Object{}
+			for _, resource := range rendered.ConfigMaps {
+				for key, data := range resource.Data {
+					file := resource.Name + "." + key
+					checkGolden(t, filepath.Join(scenario, file), []byte(data))
+					resource.Data[key] = "see " + file
+				}
+				configMaps = append(configMaps, resource)
+			}
+			manifests := []client.Object{}
+			for _, resource := range rendered.NetworkAttachmentDefinitions {
+				manifests = append(manifests, resource)
+			}
+			for _, resource := range rendered.ServiceAccounts {
+				manifests = append(manifests, resource)
+			}
+			for _, resource := range rendered.Deployments {
+				manifests = append(manifests, resource)
+			}
+			for _, resource := range rendered.Services {
+				manifests = append(manifests, resource)
+			}
+			checkGolden(t, filepath.Join(scenario, "configmaps.yaml"), marshalGolden(t, scheme, configMaps...))
+			checkGolden(t, filepath.Join(scenario, "manifests.yaml"), marshalGolden(t, scheme, manifests...))
+		})
+	}
+}
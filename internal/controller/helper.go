@@ -24,6 +24,7 @@ import (
 	configref "github.com/nephio-project/api/references/v1alpha1"
 	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
 
 func getConfigInstanceByProvider(log logr.Logger, configInstances []*configref.Config, provider string) *workloadv1alpha1.NFDeployment {
@@ -42,6 +43,20 @@ func getConfigInstanceByProvider(log logr.Logger, configInstances []*configref.C
 	return nil
 }
 
+// checkPlmn checks that the PLMN has the first PLMN info and slice the resources are rendered from,
+// which the NFConfig doesn't validate
+func checkPlmn(configSelfInfo map[string]runtime.RawExtension) error {
+	paramsPlmn := &workloadnfconfig.PLMN{}
+	if err := json.Unmarshal(configSelfInfo["PLMN"].Raw, paramsPlmn); err != nil {
+		return err
+	}
+	plmnInfo := paramsPlmn.Spec.PLMNInfo
+	if len(plmnInfo) == 0 || len(plmnInfo[0].NSSAI) == 0 || plmnInfo[0].NSSAI[0].SD == nil {
+		return errInvalidPlmn
+	}
+	return nil
+}
+
 func CheckMandatoryKinds(configSelfInfo map[string]runtime.RawExtension) bool {
 
 	for _, kind := range GetMandatoryNfKinds() {
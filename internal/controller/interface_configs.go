@@ -21,8 +21,11 @@ package controller
 import (
 	"fmt"
 	"net"
+	"strconv"
 
 	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
 )
 
 func GetInterfaceConfigs(interfaceConfigs []workloadv1alpha1.InterfaceConfig, interfaceName string) []workloadv1alpha1.InterfaceConfig {
@@ -53,6 +56,10 @@ func GetFirstInterfaceConfigIPv4(interfaceConfigs []workloadv1alpha1.InterfaceCo
 		return "", err
 	}
 
+	if interfaceConfig.IPv4 == nil {
+		return "", fmt.Errorf("interface %q has no IPv4 address", interfaceName)
+	}
+
 	ip, _, err := net.ParseCIDR(interfaceConfig.IPv4.Address)
 	if err != nil {
 		return "", err
@@ -60,3 +67,103 @@ func GetFirstInterfaceConfigIPv4(interfaceConfigs []workloadv1alpha1.InterfaceCo
 
 	return ip.String(), nil
 }
+
+func GetFirstInterfaceConfigIPv6(interfaceConfigs []workloadv1alpha1.InterfaceConfig, interfaceName string) (string, error) {
+	interfaceConfig, err := GetFirstInterfaceConfig(interfaceConfigs, interfaceName)
+	if err != nil {
+		return "", err
+	}
+
+	if interfaceConfig.IPv6 == nil {
+		return "", fmt.Errorf("interface %q has no IPv6 address", interfaceName)
+	}
+
+	ip, _, err := net.ParseCIDR(interfaceConfig.IPv6.Address)
+	if err != nil {
+		return "", err
+	}
+
+	return ip.String(), nil
+}
+
+// GetFirstInterfaceConfigIPs returns the IPv4 and IPv6 addresses of an interface, empty for a family the interface does not have
+func GetFirstInterfaceConfigIPs(interfaceConfigs []workloadv1alpha1.InterfaceConfig, interfaceName string) (string, string, error) {
+	interfaceConfig, err := GetFirstInterfaceConfig(interfaceConfigs, interfaceName)
+	if err != nil {
+		return "", "", err
+	}
+	if interfaceConfig.IPv4 == nil && interfaceConfig.IPv6 == nil {
+		return "", "", fmt.Errorf("interface %q has neither an IPv4 nor an IPv6 address", interfaceName)
+	}
+
+	var ipv4, ipv6 string
+	if interfaceConfig.IPv4 != nil {
+		if ipv4, err = GetFirstInterfaceConfigIPv4(interfaceConfigs, interfaceName); err != nil {
+			return "", "", err
+		}
+	}
+	if interfaceConfig.IPv6 != nil {
+		if ipv6, err = GetFirstInterfaceConfigIPv6(interfaceConfigs, interfaceName); err != nil {
+			return "", "", err
+		}
+	}
+	return ipv4, ipv6, nil
+}
+
+// GetFirstInterfaceConfigIP returns the address OAI binds an interface to, the IPv4 one on a dual-stack interface
+func GetFirstInterfaceConfigIP(interfaceConfigs []workloadv1alpha1.InterfaceConfig, interfaceName string) (string, error) {
+	ipv4, ipv6, err := GetFirstInterfaceConfigIPs(interfaceConfigs, interfaceName)
+	if err != nil {
+		return "", err
+	}
+	if ipv4 != "" {
+		return ipv4, nil
+	}
+	return ipv6, nil
+}
+
+// GetPeerInterfaceConfigIP returns the address of a peer interface in the IP family of the local address
+func GetPeerInterfaceConfigIP(localIp string, interfaceConfigs []workloadv1alpha1.InterfaceConfig, interfaceName string) (string, error) {
+	if net.ParseIP(localIp).To4() != nil {
+		return GetFirstInterfaceConfigIPv4(interfaceConfigs, interfaceName)
+	}
+	return GetFirstInterfaceConfigIPv6(interfaceConfigs, interfaceName)
+}
+
+// quoteIPIfSet quotes an address for the OAI configuration, leaving a missing address empty so that its field is omitted
+func quoteIPIfSet(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return strconv.Quote(ip)
+}
+
+// GetIPFamilies returns the IP families of the interfaces of a NF, IPv4 first
+func GetIPFamilies(interfaceConfigs []workloadv1alpha1.InterfaceConfig) []corev1.IPFamily {
+	hasIPv4, hasIPv6 := false, false
+	for _, interfaceConfig := range interfaceConfigs {
+		hasIPv4 = hasIPv4 || interfaceConfig.IPv4 != nil
+		hasIPv6 = hasIPv6 || interfaceConfig.IPv6 != nil
+	}
+
+	ipFamilies := []corev1.IPFamily{}
+	if hasIPv4 {
+		ipFamilies = append(ipFamilies, corev1.IPv4Protocol)
+	}
+	if hasIPv6 {
+		ipFamilies = append(ipFamilies, corev1.IPv6Protocol)
+	}
+	return ipFamilies
+}
+
+// SetServiceIPFamilies requests the IP families of the NF for a Service, IPv4 only NFs keep the cluster default
+func SetServiceIPFamilies(service *corev1.Service, ipFamilies []corev1.IPFamily) {
+	switch {
+	case len(ipFamilies) > 1:
+		service.Spec.IPFamilyPolicy = ptr.To(corev1.IPFamilyPolicyPreferDualStack)
+		service.Spec.IPFamilies = ipFamilies
+	case len(ipFamilies) == 1 && ipFamilies[0] == corev1.IPv6Protocol:
+		service.Spec.IPFamilyPolicy = ptr.To(corev1.IPFamilyPolicySingleStack)
+		service.Spec.IPFamilies = ipFamilies
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+var dualStackInterfaceConfigs = []workloadv1alpha1.InterfaceConfig{
+	{
+		Name: "e1",
+		IPv4: &workloadv1alpha1.IPv4{Address: "172.4.1.3/24", Gateway: ptr.To("172.4.1.1")},
+		IPv6: &workloadv1alpha1.IPv6{Address: "fd00:4:1::3/64", Gateway: ptr.To("fd00:4:1::1")},
+	},
+	{
+		Name: "n3",
+		IPv6: &workloadv1alpha1.IPv6{Address: "fd00:3:1::3/64", Gateway: ptr.To("fd00:3:1::1")},
+	},
+	{
+		Name: "f1u",
+	},
+}
+
+func TestGetFirstInterfaceConfigIPs(t *testing.T) {
+	cases := map[string]struct {
+		interfaceName string
+		wantIPv4      string
+		wantIPv6      string
+		wantIP        string
+		wantsError    bool
+	}{
+		"Dual Stack": {
+			interfaceName: "e1",
+			wantIPv4:      "172.4.1.3",
+			wantIPv6:      "fd00:4:1::3",
+			wantIP:        "172.4.1.3",
+		},
+		"IPv6 Only": {
+			interfaceName: "n3",
+			wantIPv6:      "fd00:3:1::3",
+			wantIP:        "fd00:3:1::3",
+		},
+		"No Address": {
+			interfaceName: "f1u",
+			wantsError:    true,
+		},
+		"Interface Not Found": {
+			interfaceName: "n2",
+			wantsError:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotIPv4, gotIPv6, err := GetFirstInterfaceConfigIPs(dualStackInterfaceConfigs, tc.interfaceName)
+			gotIP, errIP := GetFirstInterfaceConfigIP(dualStackInterfaceConfigs, tc.interfaceName)
+			if tc.wantsError {
+				if err == nil || errIP == nil {
+					t.Errorf("GetFirstInterfaceConfigIPs returned %q %q, expecting an error", gotIPv4, gotIPv6)
+				}
+				return
+			}
+			if err != nil || errIP != nil {
+				t.Fatalf("GetFirstInterfaceConfigIPs returned error %v %v", err, errIP)
+			}
+			if gotIPv4 != tc.wantIPv4 || gotIPv6 != tc.wantIPv6 || gotIP != tc.wantIP {
+				t.Errorf("GetFirstInterfaceConfigIPs returned %q %q %q, wanted %q %q %q", gotIPv4, gotIPv6, gotIP, tc.wantIPv4, tc.wantIPv6, tc.wantIP)
+			}
+		})
+	}
+}
+
+func TestGetPeerInterfaceConfigIP(t *testing.T) {
+	cases := map[string]struct {
+		localIp       string
+		interfaceName string
+		want          string
+		wantsError    bool
+	}{
+		"IPv4 Peer": {
+			localIp:       "172.4.1.4",
+			interfaceName: "e1",
+			want:          "172.4.1.3",
+		},
+		"IPv6 Peer": {
+			localIp:       "fd00:4:1::4",
+			interfaceName: "e1",
+			want:          "fd00:4:1::3",
+		},
+		"No Peer In The Family": {
+			localIp:       "172.3.1.4",
+			interfaceName: "n3",
+			wantsError:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := GetPeerInterfaceConfigIP(tc.localIp, dualStackInterfaceConfigs, tc.interfaceName)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("GetPeerInterfaceConfigIP returned %q, expecting an error", got)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("GetPeerInterfaceConfigIP returned %q %v, wanted %q", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetServiceIPFamilies(t *testing.T) {
+	cases := map[string]struct {
+		interfaceConfigs   []workloadv1alpha1.InterfaceConfig
+		wantIPFamilyPolicy *corev1.IPFamilyPolicy
+		wantIPFamilies     []corev1.IPFamily
+	}{
+		"IPv4 Only": {
+			interfaceConfigs: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.3/24"}},
+			},
+		},
+		"IPv6 Only": {
+			interfaceConfigs: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1", IPv6: &workloadv1alpha1.IPv6{Address: "fd00:5:1::3/64"}},
+			},
+			wantIPFamilyPolicy: ptr.To(corev1.IPFamilyPolicySingleStack),
+			wantIPFamilies:     []corev1.IPFamily{corev1.IPv6Protocol},
+		},
+		"Dual Stack": {
+			interfaceConfigs:   dualStackInterfaceConfigs,
+			wantIPFamilyPolicy: ptr.To(corev1.IPFamilyPolicyPreferDualStack),
+			wantIPFamilies:     []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			service := &corev1.Service{}
+			SetServiceIPFamilies(service, GetIPFamilies(tc.interfaceConfigs))
+			if !reflect.DeepEqual(service.Spec.IPFamilyPolicy, tc.wantIPFamilyPolicy) || !reflect.DeepEqual(service.Spec.IPFamilies, tc.wantIPFamilies) {
+				t.Errorf("SetServiceIPFamilies set %v %v, wanted %v %v", service.Spec.IPFamilyPolicy, service.Spec.IPFamilies, tc.wantIPFamilyPolicy, tc.wantIPFamilies)
+			}
+		})
+	}
+}
+
+func TestRenderConfigurationTemplateWithIPv6(t *testing.T) {
+	cases := map[string]struct {
+		render          func() (string, error)
+		expectedLines   []string
+		unexpectedLines []string
+	}{
+		"CU-CP IPv4": {
+			render: func() (string, error) {
+				return renderConfigurationTemplateForCuCp(configurationTemplateValuesForCuCp{N2_IP: `"172.2.1.3"`, AMF_IP: `"172.2.1.254"`})
+			},
+			expectedLines:   []string{`amf_ip_address      = ( { ipv4       = "172.2.1.254"; });`, `GNB_IPV4_ADDRESS_FOR_NG_AMF              = "172.2.1.3";`},
+			unexpectedLines: []string{"GNB_IPV6_ADDRESS_FOR_NG_AMF", "ipv6"},
+		},
+		"CU-CP IPv6 Only": {
+			render: func() (string, error) {
+				return renderConfigurationTemplateForCuCp(configurationTemplateValuesForCuCp{N2_IPV6: `"fd00:2:1::3"`, AMF_IPV6: `"fd00:2:1::fe"`})
+			},
+			expectedLines:   []string{`amf_ip_address      = ( { ipv6       = "fd00:2:1::fe"; preference = "ipv6"; });`, `GNB_IPV6_ADDRESS_FOR_NG_AMF              = "fd00:2:1::3";`},
+			unexpectedLines: []string{"GNB_IPV4_ADDRESS_FOR_NG_AMF"},
+		},
+		"CU-UP Dual Stack": {
+			render: func() (string, error) {
+				return renderConfigurationTemplateForCuUp(configurationTemplateValuesForCuUp{N3_IP: `"172.3.1.3"`, N3_IPV6: `"fd00:3:1::3"`})
+			},
+			expectedLines: []string{
+				`GNB_IPV4_ADDRESS_FOR_NGU                 = "172.3.1.3";`,
+				`GNB_IPV6_ADDRESS_FOR_NGU                 = "fd00:3:1::3";`,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			configuration, err := tc.render()
+			if err != nil {
+				t.Fatalf("Rendering returned error %v", err)
+			}
+			for _, line := range tc.expectedLines {
+				if !strings.Contains(configuration, line) {
+					t.Errorf("Configuration must contain %s:\n%s", line, configuration)
+				}
+			}
+			for _, line := range tc.unexpectedLines {
+				if strings.Contains(configuration, line) {
+					t.Errorf("Configuration must not contain %s:\n%s", line, configuration)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+)
+
+// InterfaceSchema describes an interface of the NFDeployments of a provider
+type InterfaceSchema struct {
+	// Name is the logical name of the interface, which also names its NetworkAttachmentDefinition
+	Name string
+	// Aliases are the other names the interface may have in the NFDeployment, looked up in order after Name
+	Aliases []string
+	// Required interfaces must be in the NFDeployment to render the configuration of the NF
+	Required bool
+	// Exclusive names a group of interfaces of which at most one is in the NFDeployment, a required group needs one of them
+	Exclusive string
+	// IPKey is the template value fed with the address OAI binds the interface to, IPv4 first
+	IPKey string
+	// IPv4Key and IPv6Key are the template values fed with the address of each family, left empty when missing
+	IPv4Key string
+	IPv6Key string
+}
+
+// interfaceSchemas are the interfaces of the NFDeployments of each provider, attached through Multus
+var interfaceSchemas = map[string][]InterfaceSchema{
+	"cucp.openairinterface.org": {
+		{Name: "e1", Required: true, IPKey: "E1_IP"},
+		{Name: "n2", Required: true, IPv4Key: "N2_IP", IPv6Key: "N2_IPV6"},
+		{Name: "f1c", Aliases: []string{"f1"}, Required: true, IPKey: "F1C_IP"},
+		{Name: "e2"},
+	},
+	"cuup.openairinterface.org": {
+		{Name: "e1", Required: true, IPKey: "E1_IP"},
+		{Name: "n3", Required: true, IPv4Key: "N3_IP", IPv6Key: "N3_IPV6"},
+		{Name: "f1u", Aliases: []string{"f1"}, Required: true, IPKey: "F1U_IP"},
+		{Name: "e2"},
+	},
+	"du.openairinterface.org": {
+		// A single f1 interface carries both F1-C and F1-U unless they are split into f1c and f1u
+		{Name: "f1", Required: true, Exclusive: "f1c", IPKey: "F1C_DU_IP"},
+		{Name: "f1c", Required: true, Exclusive: "f1c", IPKey: "F1C_DU_IP"},
+		{Name: "f1u", IPKey: "F1U_DU_IP"},
+		{Name: "e2"},
+	},
+}
+
+// getInterfaceSchema returns the schema of the logical interface interfaceName of a provider
+func getInterfaceSchema(provider string, interfaceName string) (*InterfaceSchema, error) {
+	for _, interfaceSchema := range interfaceSchemas[provider] {
+		if interfaceSchema.Name == interfaceName {
+			return &interfaceSchema, nil
+		}
+	}
+	return nil, fmt.Errorf("interface %q is not an interface of %s", interfaceName, provider)
+}
+
+// getSchemaInterfaceConfigs returns the configs of an interface, found by its name or else by its first alias present
+func getSchemaInterfaceConfigs(interfaceSchema *InterfaceSchema, interfaceConfigs []workloadv1alpha1.InterfaceConfig) []workloadv1alpha1.InterfaceConfig {
+	for _, interfaceName := range append([]string{interfaceSchema.Name}, interfaceSchema.Aliases...) {
+		if configs := GetInterfaceConfigs(interfaceConfigs, interfaceName); len(configs) > 0 {
+			return configs
+		}
+	}
+	return nil
+}
+
+// getInterfaceConfigsOfProvider returns the configs of the interfaces of a provider by logical name
+func getInterfaceConfigsOfProvider(provider string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) map[string][]workloadv1alpha1.InterfaceConfig {
+	interfaceConfigs := map[string][]workloadv1alpha1.InterfaceConfig{}
+	for _, interfaceSchema := range interfaceSchemas[provider] {
+		interfaceConfigs[interfaceSchema.Name] = getSchemaInterfaceConfigs(&interfaceSchema, ranDeploymentSpec.Interfaces)
+	}
+	return interfaceConfigs
+}
+
+// getInterfaceIPsOfProvider returns the IPv4 and IPv6 addresses of the logical interface interfaceName of a provider
+func getInterfaceIPsOfProvider(provider string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, interfaceName string) (string, string, error) {
+	interfaceSchema, err := getInterfaceSchema(provider, interfaceName)
+	if err != nil {
+		return "", "", err
+	}
+	configs := getSchemaInterfaceConfigs(interfaceSchema, ranDeploymentSpec.Interfaces)
+	if len(configs) == 0 {
+		return "", "", fmt.Errorf("interface %q not found", interfaceName)
+	}
+	return GetFirstInterfaceConfigIPs(configs, configs[0].Name)
+}
+
+// getInterfaceIPOfProvider returns the address OAI binds the logical interface interfaceName of a provider to, IPv4 first
+func getInterfaceIPOfProvider(provider string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, interfaceName string) (string, error) {
+	ipv4, ipv6, err := getInterfaceIPsOfProvider(provider, ranDeploymentSpec, interfaceName)
+	if err != nil {
+		return "", err
+	}
+	if ipv4 != "" {
+		return ipv4, nil
+	}
+	return ipv6, nil
+}
+
+// getPeerInterfaceIPOfProvider returns the address of the logical interface interfaceName of a peer in the IP family of the local address
+func getPeerInterfaceIPOfProvider(localIp string, provider string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, interfaceName string) (string, error) {
+	interfaceSchema, err := getInterfaceSchema(provider, interfaceName)
+	if err != nil {
+		return "", err
+	}
+	configs := getSchemaInterfaceConfigs(interfaceSchema, ranDeploymentSpec.Interfaces)
+	if len(configs) == 0 {
+		return "", fmt.Errorf("interface %q not found", interfaceName)
+	}
+	return GetPeerInterfaceConfigIP(localIp, configs, configs[0].Name)
+}
+
+// getInterfaceTemplateValues returns the template values fed by the interfaces of a provider, keyed by IPKey, IPv4Key and IPv6Key,
+// with the addresses quoted for the OAI configuration
+func getInterfaceTemplateValues(provider string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) (map[string]string, error) {
+	templateValues := map[string]string{}
+	// The interface found for each exclusive group, an empty name for a required group without any interface yet
+	exclusiveInterfaces := map[string]string{}
+	for _, interfaceSchema := range interfaceSchemas[provider] {
+		configs := getSchemaInterfaceConfigs(&interfaceSchema, ranDeploymentSpec.Interfaces)
+		if interfaceSchema.Exclusive != "" {
+			foundInterface, found := exclusiveInterfaces[interfaceSchema.Exclusive]
+			if len(configs) > 0 && foundInterface != "" {
+				return nil, fmt.Errorf("interfaces %q and %q cannot be both used", foundInterface, interfaceSchema.Name)
+			}
+			if len(configs) > 0 {
+				exclusiveInterfaces[interfaceSchema.Exclusive] = interfaceSchema.Name
+			} else if !found && interfaceSchema.Required {
+				exclusiveInterfaces[interfaceSchema.Exclusive] = ""
+			}
+		}
+		if len(configs) == 0 {
+			if interfaceSchema.Required && interfaceSchema.Exclusive == "" {
+				return nil, fmt.Errorf("interface %q not found", interfaceSchema.Name)
+			}
+			continue
+		}
+		if interfaceSchema.IPKey == "" && interfaceSchema.IPv4Key == "" && interfaceSchema.IPv6Key == "" {
+			continue
+		}
+
+		ipv4, ipv6, err := GetFirstInterfaceConfigIPs(configs, configs[0].Name)
+		if err != nil {
+			return nil, err
+		}
+		if interfaceSchema.IPKey != "" {
+			if ipv4 != "" {
+				templateValues[interfaceSchema.IPKey] = quoteIPIfSet(ipv4)
+			} else {
+				templateValues[interfaceSchema.IPKey] = quoteIPIfSet(ipv6)
+			}
+		}
+		if interfaceSchema.IPv4Key != "" {
+			templateValues[interfaceSchema.IPv4Key] = quoteIPIfSet(ipv4)
+		}
+		if interfaceSchema.IPv6Key != "" {
+			templateValues[interfaceSchema.IPv6Key] = quoteIPIfSet(ipv6)
+		}
+	}
+	for exclusive, foundInterface := range exclusiveInterfaces {
+		if foundInterface == "" {
+			return nil, fmt.Errorf("no interface of the %q group found", exclusive)
+		}
+	}
+	return templateValues, nil
+}
+
+// getFirstInterfaceIPOfProvider returns the address OAI binds the first logical interface found among interfaceNames to, IPv4 first
+func getFirstInterfaceIPOfProvider(provider string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, interfaceNames ...string) (string, error) {
+	for _, interfaceName := range interfaceNames {
+		interfaceSchema, err := getInterfaceSchema(provider, interfaceName)
+		if err != nil {
+			return "", err
+		}
+		if len(getSchemaInterfaceConfigs(interfaceSchema, ranDeploymentSpec.Interfaces)) > 0 {
+			return getInterfaceIPOfProvider(provider, ranDeploymentSpec, interfaceName)
+		}
+	}
+	return "", fmt.Errorf("none of the interfaces %q found", interfaceNames)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+func TestInterfaceSchemasFeedTemplateValues(t *testing.T) {
+	templateValues := map[string]reflect.Type{
+		"cucp.openairinterface.org": reflect.TypeOf(configurationTemplateValuesForCuCp{}),
+		"cuup.openairinterface.org": reflect.TypeOf(configurationTemplateValuesForCuUp{}),
+		"du.openairinterface.org":   reflect.TypeOf(configurationTemplateValuesForDu{}),
+	}
+	for provider, interfaceSchemasOfProvider := range interfaceSchemas {
+		templateValuesType, found := templateValues[provider]
+		if !found {
+			t.Errorf("No template values for provider %s", provider)
+			continue
+		}
+		for _, interfaceSchema := range interfaceSchemasOfProvider {
+			for _, key := range []string{interfaceSchema.IPKey, interfaceSchema.IPv4Key, interfaceSchema.IPv6Key} {
+				if _, found := templateValuesType.FieldByName(key); key != "" && !found {
+					t.Errorf("Interface %q of %s feeds %s which is not a template value", interfaceSchema.Name, provider, key)
+				}
+			}
+		}
+	}
+}
+
+func TestGetInterfaceTemplateValues(t *testing.T) {
+	cases := map[string]struct {
+		provider   string
+		interfaces []workloadv1alpha1.InterfaceConfig
+		want       map[string]string
+		wantsError bool
+	}{
+		"CU-CP": {
+			provider: "cucp.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "e1", IPv4: &workloadv1alpha1.IPv4{Address: "172.4.1.3/24"}},
+				{Name: "n2", IPv6: &workloadv1alpha1.IPv6{Address: "fd00:2:1::3/64"}},
+				{Name: "f1c", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.3/24"}},
+			},
+			want: map[string]string{"E1_IP": `"172.4.1.3"`, "N2_IP": "", "N2_IPV6": `"fd00:2:1::3"`, "F1C_IP": `"172.5.1.3"`},
+		},
+		"CU-CP F1 By Alias": {
+			provider: "cucp.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "e1", IPv4: &workloadv1alpha1.IPv4{Address: "172.4.1.3/24"}},
+				{Name: "n2", IPv4: &workloadv1alpha1.IPv4{Address: "172.2.1.3/24"}},
+				{Name: "f1", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.3/24"}},
+			},
+			want: map[string]string{"E1_IP": `"172.4.1.3"`, "N2_IP": `"172.2.1.3"`, "N2_IPV6": "", "F1C_IP": `"172.5.1.3"`},
+		},
+		"CU-UP Missing N3": {
+			provider: "cuup.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "e1", IPv4: &workloadv1alpha1.IPv4{Address: "172.4.1.4/24"}},
+				{Name: "f1u", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.4/24"}},
+			},
+			wantsError: true,
+		},
+		"DU Without E2": {
+			provider: "du.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.5/24"}},
+			},
+			want: map[string]string{"F1C_DU_IP": `"172.5.1.5"`},
+		},
+		"DU Split F1": {
+			provider: "du.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1c", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.5/24"}},
+				{Name: "f1u", IPv4: &workloadv1alpha1.IPv4{Address: "172.6.1.5/24"}},
+			},
+			want: map[string]string{"F1C_DU_IP": `"172.5.1.5"`, "F1U_DU_IP": `"172.6.1.5"`},
+		},
+		"DU With F1 And F1c": {
+			provider: "du.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.5/24"}},
+				{Name: "f1c", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.6/24"}},
+			},
+			wantsError: true,
+		},
+		"DU Without F1": {
+			provider: "du.openairinterface.org",
+			interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1u", IPv4: &workloadv1alpha1.IPv4{Address: "172.6.1.5/24"}},
+			},
+			wantsError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := getInterfaceTemplateValues(tc.provider, &workloadv1alpha1.NFDeploymentSpec{Interfaces: tc.interfaces})
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("getInterfaceTemplateValues returned %v, expecting an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("getInterfaceTemplateValues returned %v %v, wanted %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetInterfaceConfigsOfProvider(t *testing.T) {
+	f1 := workloadv1alpha1.InterfaceConfig{Name: "f1c", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.5/24", Gateway: ptr.To("172.5.1.1")}}
+	got := getInterfaceConfigsOfProvider("du.openairinterface.org", &workloadv1alpha1.NFDeploymentSpec{
+		Interfaces: []workloadv1alpha1.InterfaceConfig{f1},
+	})
+	want := map[string][]workloadv1alpha1.InterfaceConfig{
+		"f1":  nil,
+		"f1c": {f1},
+		"f1u": nil,
+		"e2":  nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("getInterfaceConfigsOfProvider returned %v, wanted %v", got, want)
+	}
+}
+
+func TestGetPeerInterfaceIPOfProvider(t *testing.T) {
+	cuCpSpec := &workloadv1alpha1.NFDeploymentSpec{
+		Interfaces: []workloadv1alpha1.InterfaceConfig{
+			{
+				Name: "f1",
+				IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.3/24"},
+				IPv6: &workloadv1alpha1.IPv6{Address: "fd00:5:1::3/64"},
+			},
+		},
+	}
+	got, err := getPeerInterfaceIPOfProvider("fd00:5:1::5", "cucp.openairinterface.org", cuCpSpec, "f1c")
+	if err != nil || got != "fd00:5:1::3" {
+		t.Errorf("getPeerInterfaceIPOfProvider returned %q %v, wanted fd00:5:1::3", got, err)
+	}
+	if _, err := getPeerInterfaceIPOfProvider("172.5.1.5", "cucp.openairinterface.org", cuCpSpec, "n3"); err == nil {
+		t.Errorf("getPeerInterfaceIPOfProvider returned no error for an interface not in the schema")
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+
+const (
+	// logTailLines are the last lines of the logs of a container inspected
+	logTailLines = 500
+	// logExcerptContext are the lines kept before and after the line of a fatal cause in its excerpt
+	logExcerptContext = 2
+)
+
+// logPattern classifies the lines of the logs of the softmodem matching Regexp as a fatal cause, Cause expands
+// the submatches of Regexp as regexp.Expand does
+type logPattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+	Cause  string
+}
+
+// fatalLogPatterns is the catalog of the fatal errors of the softmodem, from the most to the least specific:
+// the generic assertion only describes the cause when no other pattern matched
+var fatalLogPatterns = []logPattern{
+	{
+		// [LIBCONFIG] file /opt/oai-gnb/etc/gnb.conf - line 42: syntax error
+		Name:   "ConfigParseError",
+		Regexp: regexp.MustCompile(`\[LIBCONFIG\] (?:file )?(\S+) - line (\d+): (.+)$`),
+		Cause:  "Config parse error at line $2 of $1: $3",
+	},
+	{
+		// [NGAP]   Received NG setup failure for AMF... please check your parameters, cause: unknown-PLMN
+		Name:   "NGSetupFailure",
+		Regexp: regexp.MustCompile(`(?i)NG ?Setup ?Failure.*?cause[:=]?\s*(.+)$`),
+		Cause:  "NG Setup Failure, cause: $1",
+	},
+	{
+		// [F1AP]   Received F1 Setup Failure, cause: plmn-not-served-by-the-gNB-CU
+		Name:   "F1SetupFailure",
+		Regexp: regexp.MustCompile(`(?i)F1 ?Setup ?Failure.*?cause[:=]?\s*(.+)$`),
+		Cause:  "F1 Setup Failure, cause: $1",
+	},
+	{
+		// [SCTP]   connect() to 10.0.3.1:38472 failed: Connection refused
+		Name:   "SCTPConnectionRefused",
+		Regexp: regexp.MustCompile(`\[SCTP\].*\bto ([0-9a-fA-F.:\[\]]+).*Connection refused`),
+		Cause:  "SCTP connection refused by $1",
+	},
+	{
+		// [SCTP]   sctp_connectx(): Connection refused
+		Name:   "SCTPConnectionRefused",
+		Regexp: regexp.MustCompile(`\[SCTP\].*Connection refused`),
+		Cause:  "SCTP connection refused",
+	},
+	{
+		// Assertion (ret == 0) failed!
+		Name:   "AssertionFailed",
+		Regexp: regexp.MustCompile(`Assertion \((.*)\) failed!`),
+		Cause:  "Assertion failed: $1",
+	},
+}
+
+// FatalCause is a fatal error of the softmodem found in its logs
+type FatalCause struct {
+	// Pattern is the name of the logPattern of the catalog matching the logs
+	Pattern string
+	Cause   string
+	// Excerpt are the lines of the logs around the line of the cause
+	Excerpt string
+}
+
+// classifyLogs returns the fatal cause of the most specific pattern of the catalog matching the logs, on its last
+// matching line, nil when no pattern matches
+func classifyLogs(logs string) *FatalCause {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	for _, pattern := range fatalLogPatterns {
+		for index := len(lines) - 1; index >= 0; index-- {
+			match := pattern.Regexp.FindStringSubmatchIndex(lines[index])
+			if match == nil {
+				continue
+			}
+			cause := string(pattern.Regexp.ExpandString(nil, pattern.Cause, lines[index], match))
+			first := max(index-logExcerptContext, 0)
+			last := min(index+logExcerptContext+1, len(lines))
+			return &FatalCause{
+				Pattern: pattern.Name,
+				Cause:   strings.TrimSpace(cause),
+				Excerpt: strings.Join(lines[first:last], "\n"),
+			}
+		}
+	}
+	return nil
+}
+
+// PodLogReader reads the last lines of the logs of a container, of its previous instance when previous is set
+type PodLogReader interface {
+	ReadLogs(ctx context.Context, namespace string, pod string, container string, previous bool) (string, error)
+}
+
+type clientsetLogReader struct {
+	clientset kubernetes.Interface
+}
+
+// NewPodLogReader returns a PodLogReader reading the logs with the clientset
+func NewPodLogReader(clientset kubernetes.Interface) PodLogReader {
+	return &clientsetLogReader{clientset: clientset}
+}
+
+func (reader *clientsetLogReader) ReadLogs(ctx context.Context, namespace string, pod string, container string, previous bool) (string, error) {
+	options := &corev1.PodLogOptions{Container: container, Previous: previous, TailLines: ptr.To(int64(logTailLines))}
+	stream, err := reader.clientset.CoreV1().Pods(namespace).GetLogs(pod, options).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	return string(logs), err
+}
+
+// softmodemContainers are the names of the NF container of the pods of each provider, the sidecars and init
+// containers added next to it are not inspected
+var softmodemContainers = map[string]string{
+	"cucp.openairinterface.org": "cucp",
+	"cuup.openairinterface.org": "cuup",
+	"du.openairinterface.org":   "du",
+}
+
+// inspectPodLogs returns the fatal cause of the NF container of a pod found in its logs, nil otherwise. The logs of a
+// running container are read while it is not ready, e.g. while it retries a refused SCTP connection, then the logs of
+// its previous instance when it terminated with an error. The logs of a terminated container are read when it exited
+// with an error
+func (r *RANDeploymentReconciler) inspectPodLogs(ctx context.Context, pod *corev1.Pod, container string) (*FatalCause, error) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != container {
+			continue
+		}
+		if status.State.Running != nil {
+			if status.Ready {
+				return nil, nil
+			}
+			logs, err := r.LogReader.ReadLogs(ctx, pod.Namespace, pod.Name, container, false)
+			if err != nil {
+				return nil, err
+			}
+			if fatalCause := classifyLogs(logs); fatalCause != nil {
+				return fatalCause, nil
+			}
+		}
+		previous := false
+		terminated := status.State.Terminated
+		if terminated == nil && status.LastTerminationState.Terminated != nil {
+			previous = true
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated == nil || terminated.ExitCode == 0 {
+			return nil, nil
+		}
+		logs, err := r.LogReader.ReadLogs(ctx, pod.Namespace, pod.Name, container, previous)
+		if err != nil {
+			return nil, err
+		}
+		return classifyLogs(logs), nil
+	}
+	return nil, nil
+}
+
+// getNfPods returns the pods of the Deployment of a NFDeployment, none when the Deployment is not found
+func (r *RANDeploymentReconciler) getNfPods(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) ([]corev1.Pod, error) {
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ranDeployment.Namespace, Name: getDeploymentName(ranDeployment)}, deployment); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if deployment.Spec.Selector == nil {
+		return nil, nil
+	}
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(deployment.Namespace), client.MatchingLabels(deployment.Spec.Selector.MatchLabels)); err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// UpdateSoftmodemFailureStatus records in the softmodemFailure condition and in an event the last fatal cause found
+// in the logs of the NF container of the pods of a NFDeployment, with its log excerpt. The condition is kept once the
+// container runs again, until another fatal cause is found
+func (r *RANDeploymentReconciler) UpdateSoftmodemFailureStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) error {
+	if r.LogReader == nil {
+		return nil
+	}
+	container, found := softmodemContainers[ranDeployment.Spec.Provider]
+	if !found {
+		return nil
+	}
+	pods, err := r.getNfPods(ctx, ranDeployment)
+	if err != nil {
+		return err
+	}
+
+	for index := range pods {
+		pod := &pods[index]
+		fatalCause, err := r.inspectPodLogs(ctx, pod, container)
+		if err != nil {
+			return err
+		}
+		if fatalCause == nil {
+			continue
+		}
+
+		curCondition := metav1.Condition{
+			Type:               "softmodemFailure",
+			LastTransitionTime: metav1.Time{Time: time.Now()},
+			Status:             metav1.ConditionTrue,
+			Reason:             "softmodemFailure",
+			Message:            fmt.Sprintf("%s in container %s of pod %s | Log: %s", fatalCause.Cause, container, pod.Name, fatalCause.Excerpt),
+		}
+		previous := meta.FindStatusCondition(ranDeployment.Status.Conditions, curCondition.Type)
+		if previous == nil || previous.Message != curCondition.Message {
+			r.recordEvent(ranDeployment, corev1.EventTypeWarning, ReasonSoftmodemFailure, "%s in container %s of pod %s", fatalCause.Cause, container, pod.Name)
+		}
+		return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+	}
+	return nil
+}
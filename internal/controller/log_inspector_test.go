@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func readSampleLogs(t *testing.T, name string) string {
+	content, err := os.ReadFile(filepath.Join("testdata", "logs", name))
+	if err != nil {
+		t.Fatalf("Cannot read the sample logs %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestClassifyLogs(t *testing.T) {
+	cases := map[string]struct {
+		logs        string
+		wantPattern string
+		wantCause   string
+		wantExcerpt string
+	}{
+		"Config Parse Error": {
+			logs:        "config_parse_error.log",
+			wantPattern: "ConfigParseError",
+			wantCause:   "Config parse error at line 42 of /opt/oai-gnb/etc/gnb.conf: syntax error",
+			wantExcerpt: "[LIBCONFIG] file /opt/oai-gnb/etc/gnb.conf - line 42: syntax error",
+		},
+		"SCTP Connection Refused": {
+			logs:        "sctp_refused.log",
+			wantPattern: "SCTPConnectionRefused",
+			wantCause:   "SCTP connection refused by 10.0.3.1:38472",
+			wantExcerpt: "[SCTP]   connect() to 10.0.3.1:38472 failed: Connection refused",
+		},
+		"F1 Setup Failure Before The Assertion": {
+			logs:        "f1_setup_failure.log",
+			wantPattern: "F1SetupFailure",
+			wantCause:   "F1 Setup Failure, cause: plmn-not-served-by-the-gNB-CU",
+			wantExcerpt: "Assertion (0) failed!",
+		},
+		"NG Setup Failure": {
+			logs:        "ng_setup_failure.log",
+			wantPattern: "NGSetupFailure",
+			wantCause:   "NG Setup Failure, cause: unknown-PLMN",
+		},
+		"Assertion Only": {
+			logs:        "assertion.log",
+			wantPattern: "AssertionFailed",
+			wantCause:   "Assertion failed: ru->rfdevice.trx_start_func(&ru->rfdevice) == 0",
+			wantExcerpt: "In start_RU_proc()",
+		},
+		"Healthy": {
+			logs: "healthy.log",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fatalCause := classifyLogs(readSampleLogs(t, tc.logs))
+			if tc.wantPattern == "" {
+				if fatalCause != nil {
+					t.Errorf("classifyLogs returned %v from healthy logs", fatalCause)
+				}
+				return
+			}
+			if fatalCause == nil {
+				t.Fatalf("classifyLogs found no fatal cause, expected %s", tc.wantPattern)
+			}
+			if fatalCause.Pattern != tc.wantPattern || fatalCause.Cause != tc.wantCause {
+				t.Errorf("classifyLogs returned %s %q, expected %s %q", fatalCause.Pattern, fatalCause.Cause, tc.wantPattern, tc.wantCause)
+			}
+			if lines := strings.Count(fatalCause.Excerpt, "\n") + 1; lines > 2*logExcerptContext+1 {
+				t.Errorf("classifyLogs returned an excerpt of %d lines", lines)
+			}
+			if !strings.Contains(fatalCause.Excerpt, tc.wantExcerpt) {
+				t.Errorf("classifyLogs returned the excerpt %q, expected it to contain %q", fatalCause.Excerpt, tc.wantExcerpt)
+			}
+		})
+	}
+}
+
+// stubLogReader returns the sample logs of testdata/logs for any container
+type stubLogReader struct {
+	logs     string
+	previous bool
+}
+
+func (reader *stubLogReader) ReadLogs(ctx context.Context, namespace string, pod string, container string, previous bool) (string, error) {
+	reader.previous = previous
+	return reader.logs, nil
+}
+
+func TestUpdateSoftmodemFailureStatus(t *testing.T) {
+	cases := map[string]struct {
+		containerStatus corev1.ContainerStatus
+		conditions      []metav1.Condition
+		wantPrevious    bool
+		wantMessage     string
+		wantEvent       bool
+	}{
+		"Running": {
+			containerStatus: corev1.ContainerStatus{Name: "du", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		},
+		"Running Not Ready": {
+			containerStatus: corev1.ContainerStatus{Name: "du", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			wantMessage:     "SCTP connection refused by 10.0.3.1:38472 in container du of pod oai-du-5d8f | Log: ",
+			wantEvent:       true,
+		},
+		"Sidecar Terminated": {
+			containerStatus: corev1.ContainerStatus{Name: "e2-agent", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+		},
+		"Terminated": {
+			containerStatus: corev1.ContainerStatus{Name: "du", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+			wantMessage:     "SCTP connection refused by 10.0.3.1:38472 in container du of pod oai-du-5d8f | Log: ",
+			wantEvent:       true,
+		},
+		"Restarted": {
+			containerStatus: corev1.ContainerStatus{
+				Name:                 "du",
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+			},
+			wantPrevious: true,
+			wantMessage:  "SCTP connection refused by 10.0.3.1:38472 in container du of pod oai-du-5d8f | Log: ",
+			wantEvent:    true,
+		},
+		"Already Reported": {
+			containerStatus: corev1.ContainerStatus{
+				Name:                 "du",
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}},
+			},
+			conditions: []metav1.Condition{{
+				Type:    "softmodemFailure",
+				Status:  metav1.ConditionTrue,
+				Reason:  "softmodemFailure",
+				Message: "SCTP connection refused by 10.0.3.1:38472 in container du of pod oai-du-5d8f | Log: " + classifyLogs(readSampleLogs(t, "sctp_refused.log")).Excerpt,
+			}},
+			wantPrevious: true,
+			wantMessage:  "SCTP connection refused by 10.0.3.1:38472 in container du of pod oai-du-5d8f | Log: ",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Deployment")).Return(nil).Run(func(args mock.Arguments) {
+				deployment := args.Get(2).(*appsv1.Deployment)
+				deployment.Namespace = "oai"
+				deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "oai-du"}}
+				deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: "telnet-proxy"}, {Name: "du"}}
+			})
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1.PodList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				args.Get(1).(*corev1.PodList).Items = []corev1.Pod{{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "oai-du-5d8f"},
+					Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+						{Name: "telnet-proxy", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}}},
+						tc.containerStatus,
+					}},
+				}}
+			})
+			logReader := &stubLogReader{logs: readSampleLogs(t, "sctp_refused.log")}
+			recorder := record.NewFakeRecorder(1)
+			r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme(), Recorder: recorder, LogReader: logReader}
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "du"},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+				Status:     workloadv1alpha1.NFDeploymentStatus{Conditions: tc.conditions},
+			}
+			if err := r.UpdateSoftmodemFailureStatus(context.TODO(), ranDeployment); err != nil {
+				t.Fatalf("UpdateSoftmodemFailureStatus returned error %v", err)
+			}
+
+			if tc.wantMessage == "" {
+				if len(ranDeployment.Status.Conditions) != 0 {
+					t.Errorf("UpdateSoftmodemFailureStatus set the conditions %v without a failed NF container", ranDeployment.Status.Conditions)
+				}
+				return
+			}
+			if logReader.previous != tc.wantPrevious {
+				t.Errorf("UpdateSoftmodemFailureStatus read the logs of the previous container: %v, expected %v", logReader.previous, tc.wantPrevious)
+			}
+			condition := ranDeployment.Status.Conditions[0]
+			if condition.Status != metav1.ConditionTrue || !strings.HasPrefix(condition.Message, tc.wantMessage) {
+				t.Errorf("UpdateSoftmodemFailureStatus set the condition %v, expected %s", condition, tc.wantMessage)
+			}
+			if (len(recorder.Events) == 1) != tc.wantEvent {
+				t.Errorf("UpdateSoftmodemFailureStatus recorded %d events, expected an event: %v", len(recorder.Events), tc.wantEvent)
+			}
+		})
+	}
+}
+
+func TestUpdateSoftmodemFailureStatusWithoutLogReader(t *testing.T) {
+	r := RANDeploymentReconciler{Client: new(MockClient), Scheme: runtime.NewScheme()}
+	if err := r.UpdateSoftmodemFailureStatus(context.TODO(), &workloadv1alpha1.NFDeployment{}); err != nil {
+		t.Errorf("UpdateSoftmodemFailureStatus returned error %v without LogReader", err)
+	}
+}
+
+func TestClientsetLogReader(t *testing.T) {
+	reader := NewPodLogReader(fake.NewSimpleClientset())
+	logs, err := reader.ReadLogs(context.TODO(), "oai", "oai-du-5d8f", "du", true)
+	if err != nil {
+		t.Fatalf("ReadLogs returned error %v", err)
+	}
+	if logs != "fake logs" {
+		t.Errorf("ReadLogs returned %q, expected the logs of the fake clientset", logs)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// metricsNamespace prefixes the names of the metrics of the reconciler
+const metricsNamespace = "ran_deployment"
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_total",
+		Help:      "Reconciliations of the NFDeployments by provider and result: success, requeue or error",
+	}, []string{"namespace", "nfdeployment", "provider", "result"})
+	renderDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "render_duration_seconds",
+		Help:      "Duration of the rendering of the ConfigMaps and Deployments of the NFDeployments",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12),
+	}, []string{"namespace", "nfdeployment", "provider"})
+	configResolutionFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "config_resolution_failures_total",
+		Help:      "Failures to resolve the configs referenced by the NFDeployments by reason",
+	}, []string{"namespace", "nfdeployment", "reason"})
+	managedInstances = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "managed_instances",
+		Help:      "NFDeployments whose resources are managed by the reconciler by provider",
+	}, []string{"namespace", "provider"})
+	telnetUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "telnet_up",
+		Help:      "Whether the telnet server of the NF answered the last poll",
+	}, []string{"namespace", "nfdeployment", "provider"})
+	cellUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_up",
+		Help:      "Whether the O1 module of the telnet server of the DU reports its cell",
+	}, []string{"namespace", "nfdeployment"})
+	connectedUes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "connected_ues",
+		Help:      "UEs connected to the cell of the DU reported by the O1 module of its telnet server",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn"})
+	cellPrbUsageRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_prb_usage_ratio",
+		Help:      "Ratio of the PRBs of the cell of the DU used",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn"})
+	cellThroughputBitsPerSecond = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_throughput_bits_per_second",
+		Help:      "Throughput of the UEs of the cell of the DU by direction: dl or ul",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn", "direction"})
+	cellBler = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_bler",
+		Help:      "Average block error rate of the UEs of the cell of the DU by direction: dl or ul",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn", "direction"})
+	cellMcs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_mcs",
+		Help:      "Average MCS of the UEs of the cell of the DU by direction: dl or ul",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn", "direction"})
+	cellMacBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_mac_bytes",
+		Help:      "Bytes of the MAC of the DU for the UEs of the cell by direction: dl or ul, since the UEs attached",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn", "direction"})
+	cellRlcBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "cell_rlc_bytes",
+		Help:      "Bytes of the logical channels of the UEs of the cell by LCID and direction: dl or ul, since the UEs attached",
+	}, []string{"namespace", "nfdeployment", "cell_id", "plmn", "lcid", "direction"})
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(reconcileTotal, renderDurationSeconds, configResolutionFailuresTotal, managedInstances, telnetUp, cellUp, connectedUes,
+		cellPrbUsageRatio, cellThroughputBitsPerSecond, cellBler, cellMcs, cellMacBytes, cellRlcBytes)
+}
+
+// nfLabels are the labels of the metrics of a NFDeployment
+func nfLabels(namespacedName types.NamespacedName) prometheus.Labels {
+	return prometheus.Labels{"namespace": namespacedName.Namespace, "nfdeployment": namespacedName.Name}
+}
+
+// recordReconcile counts the result of a reconciliation of a NFDeployment
+func recordReconcile(ranDeployment *workloadv1alpha1.NFDeployment, result ctrl.Result, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	} else if result.Requeue || result.RequeueAfter > 0 {
+		outcome = "requeue"
+	}
+	reconcileTotal.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, ranDeployment.Spec.Provider, outcome).Inc()
+}
+
+// observeRenderDuration records the duration of the rendering of the resources of a NFDeployment
+func observeRenderDuration(ranDeployment *workloadv1alpha1.NFDeployment, start time.Time) {
+	renderDurationSeconds.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, ranDeployment.Spec.Provider).Observe(time.Since(start).Seconds())
+}
+
+// configResolutionFailureReason classifies the errors of GetConfigs
+func configResolutionFailureReason(err error) string {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case apierrors.IsNotFound(err):
+		return "ConfigNotFound"
+	case errors.Is(err, errMissingMandatoryKinds):
+		return "MissingMandatoryKinds"
+	case errors.Is(err, errUnsupportedAPIVersion):
+		return "UnsupportedAPIVersion"
+	case errors.Is(err, errInvalidPlmn), errors.As(err, &syntaxError), errors.As(err, &typeError):
+		return "InvalidConfig"
+	case apierrors.ReasonForError(err) != "":
+		return string(apierrors.ReasonForError(err))
+	default:
+		return "Unknown"
+	}
+}
+
+// recordConfigResolutionFailure counts a failure to resolve the configs of a NFDeployment
+func recordConfigResolutionFailure(ranDeployment *workloadv1alpha1.NFDeployment, err error) {
+	configResolutionFailuresTotal.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, configResolutionFailureReason(err)).Inc()
+}
+
+// managedNfs tracks the providers of the NFDeployments whose resources are managed, for the managed_instances gauge
+type managedNfs struct {
+	mutex     sync.Mutex
+	providers map[types.NamespacedName]string
+}
+
+var managedNfDeployments = &managedNfs{providers: map[types.NamespacedName]string{}}
+
+// update tracks a NFDeployment as managed once its resources are created, i.e. it holds the finalizer, and until it is deleted
+func (m *managedNfs) update(ranDeployment *workloadv1alpha1.NFDeployment) {
+	namespacedName := types.NamespacedName{Namespace: ranDeployment.Namespace, Name: ranDeployment.Name}
+	if !ranDeployment.DeletionTimestamp.IsZero() || !controllerutil.ContainsFinalizer(ranDeployment, finalizerName) {
+		m.remove(namespacedName)
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.providers[namespacedName] = ranDeployment.Spec.Provider
+	m.setGauge()
+}
+
+// remove stops tracking a NFDeployment and drops its metrics
+func (m *managedNfs) remove(namespacedName types.NamespacedName) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.providers, namespacedName)
+	m.setGauge()
+	labels := nfLabels(namespacedName)
+	telnetUp.DeletePartialMatch(labels)
+	cellUp.DeletePartialMatch(labels)
+	deleteCellKpis(labels)
+}
+
+// setIfTracked runs set under the mutex while a NFDeployment is tracked, so that the metrics set by the polls of its
+// telnet server are not set again once remove dropped them
+func (m *managedNfs) setIfTracked(namespacedName types.NamespacedName, set func()) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, found := m.providers[namespacedName]; found {
+		set()
+	}
+}
+
+// deleteNfMetrics drops the counters and histograms of a deleted NFDeployment
+func deleteNfMetrics(namespacedName types.NamespacedName) {
+	labels := nfLabels(namespacedName)
+	reconcileTotal.DeletePartialMatch(labels)
+	renderDurationSeconds.DeletePartialMatch(labels)
+	configResolutionFailuresTotal.DeletePartialMatch(labels)
+}
+
+// deleteCellKpis drops the KPIs of the cells of a DU
+func deleteCellKpis(labels prometheus.Labels) {
+	connectedUes.DeletePartialMatch(labels)
+	cellPrbUsageRatio.DeletePartialMatch(labels)
+	cellThroughputBitsPerSecond.DeletePartialMatch(labels)
+	cellBler.DeletePartialMatch(labels)
+	cellMcs.DeletePartialMatch(labels)
+	cellMacBytes.DeletePartialMatch(labels)
+	cellRlcBytes.DeletePartialMatch(labels)
+}
+
+// setCellKpis sets the KPIs of the cell of a DU, the KPIs of its previous cells are dropped. A DU has a single series
+// per metric since getCellKpis only reports its first cell. The polls call it through managedNfs.setIfTracked
+func setCellKpis(namespacedName types.NamespacedName, kpis *CellKpis) {
+	deleteCellKpis(nfLabels(namespacedName))
+	labels := []string{namespacedName.Namespace, namespacedName.Name, kpis.CellId, kpis.Plmn}
+	connectedUes.WithLabelValues(labels...).Set(float64(kpis.ConnectedUes))
+	cellPrbUsageRatio.WithLabelValues(labels...).Set(kpis.PrbUsage)
+	cellThroughputBitsPerSecond.WithLabelValues(append(labels, "dl")...).Set(kpis.DownlinkThroughput)
+	cellThroughputBitsPerSecond.WithLabelValues(append(labels, "ul")...).Set(kpis.UplinkThroughput)
+	if kpis.DownlinkBler != nil {
+		cellBler.WithLabelValues(append(labels, "dl")...).Set(*kpis.DownlinkBler)
+		cellBler.WithLabelValues(append(labels, "ul")...).Set(*kpis.UplinkBler)
+	}
+	if kpis.DownlinkMcs != nil {
+		cellMcs.WithLabelValues(append(labels, "dl")...).Set(*kpis.DownlinkMcs)
+		cellMcs.WithLabelValues(append(labels, "ul")...).Set(*kpis.UplinkMcs)
+	}
+	if kpis.RlcBytes != nil {
+		cellMacBytes.WithLabelValues(append(labels, "dl")...).Set(float64(kpis.MacTxBytes))
+		cellMacBytes.WithLabelValues(append(labels, "ul")...).Set(float64(kpis.MacRxBytes))
+	}
+	for lcid, rlcBytes := range kpis.RlcBytes {
+		lcidLabels := append(labels, strconv.Itoa(lcid))
+		cellRlcBytes.WithLabelValues(append(lcidLabels, "dl")...).Set(float64(rlcBytes.TxBytes))
+		cellRlcBytes.WithLabelValues(append(lcidLabels, "ul")...).Set(float64(rlcBytes.RxBytes))
+	}
+}
+
+// setGauge sets managed_instances from the tracked NFDeployments, the caller holds the mutex
+func (m *managedNfs) setGauge() {
+	managedInstances.Reset()
+	for namespacedName, provider := range m.providers {
+		managedInstances.WithLabelValues(namespacedName.Namespace, provider).Inc()
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestConfigResolutionFailureReason(t *testing.T) {
+	cases := map[string]struct {
+		err        error
+		wantReason string
+	}{
+		"Config Not Found":    {err: apierrors.NewNotFound(schema.GroupResource{Resource: "configs"}, "amf"), wantReason: "ConfigNotFound"},
+		"Forbidden":           {err: apierrors.NewForbidden(schema.GroupResource{Resource: "configs"}, "amf", errors.New("rbac")), wantReason: "Forbidden"},
+		"Missing Kinds":       {err: errMissingMandatoryKinds, wantReason: "MissingMandatoryKinds"},
+		"Unsupported Version": {err: fmt.Errorf("%w %q", errUnsupportedAPIVersion, "v2"), wantReason: "UnsupportedAPIVersion"},
+		"Invalid Config":      {err: json.Unmarshal([]byte("{"), &map[string]any{}), wantReason: "InvalidConfig"},
+		"Invalid PLMN":        {err: errInvalidPlmn, wantReason: "InvalidConfig"},
+		"Unknown":             {err: errors.New("connection refused"), wantReason: "Unknown"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if reason := configResolutionFailureReason(tc.err); reason != tc.wantReason {
+				t.Errorf("configResolutionFailureReason returned %s, expected %s", reason, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestRecordReconcile(t *testing.T) {
+	ranDeployment := &workloadv1alpha1.NFDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "du-metrics"},
+		Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+	}
+	recordReconcile(ranDeployment, ctrl.Result{}, nil)
+	recordReconcile(ranDeployment, ctrl.Result{RequeueAfter: time.Second}, nil)
+	recordReconcile(ranDeployment, ctrl.Result{}, errors.New("conflict"))
+	recordReconcile(ranDeployment, ctrl.Result{}, errors.New("conflict"))
+
+	for result, want := range map[string]float64{"success": 1, "requeue": 1, "error": 2} {
+		if got := testutil.ToFloat64(reconcileTotal.WithLabelValues("oai", "du-metrics", "du.openairinterface.org", result)); got != want {
+			t.Errorf("reconcile_total of %s is %v, expected %v", result, got, want)
+		}
+	}
+	deleteNfMetrics(types.NamespacedName{Namespace: "oai", Name: "du-metrics"})
+	if reconcileTotal.DeleteLabelValues("oai", "du-metrics", "du.openairinterface.org", "success") {
+		t.Errorf("reconcile_total kept the series of the deleted NFDeployment")
+	}
+}
+
+func TestManagedNfs(t *testing.T) {
+	managed := &managedNfs{providers: map[types.NamespacedName]string{}}
+	newNfDeployment := func(name string, provider string) *workloadv1alpha1.NFDeployment {
+		return &workloadv1alpha1.NFDeployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: name, Finalizers: []string{finalizerName}},
+			Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: provider},
+		}
+	}
+	managed.update(newNfDeployment("du-a", "du.openairinterface.org"))
+	managed.update(newNfDeployment("du-b", "du.openairinterface.org"))
+	managed.update(newNfDeployment("cucp", "cucp.openairinterface.org"))
+	deleted := newNfDeployment("du-b", "du.openairinterface.org")
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	managed.update(deleted)
+	// Without finalizer the resources are not created yet
+	managed.update(&workloadv1alpha1.NFDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "cuup"},
+		Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "cuup.openairinterface.org"},
+	})
+
+	for provider, want := range map[string]float64{"du.openairinterface.org": 1, "cucp.openairinterface.org": 1} {
+		if got := testutil.ToFloat64(managedInstances.WithLabelValues("oai", provider)); got != want {
+			t.Errorf("managed_instances of %s is %v, expected %v", provider, got, want)
+		}
+	}
+	if count := testutil.CollectAndCount(managedInstances); count != 2 {
+		t.Errorf("managed_instances has %d series, expected 2", count)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	mock "github.com/stretchr/testify/mock"
 	v10 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	v1alpha10 "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
 
 // NewMockNfResource creates a new instance of MockNfResource. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
@@ -169,17 +171,91 @@ func (_c *MockNfResource_GetDeployment_Call) RunAndReturn(run func(logger logr.L
 	return _c
 }
 
+// GetNetworkAttachmentDefinitions provides a mock function for the type MockNfResource
+func (_mock *MockNfResource) GetNetworkAttachmentDefinitions(logger logr.Logger, nFDeployment *v1alpha1.NFDeployment, configInfo *ConfigInfo) ([]*unstructured.Unstructured, error) {
+	ret := _mock.Called(logger, nFDeployment, configInfo)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetNetworkAttachmentDefinitions")
+	}
+
+	var r0 []*unstructured.Unstructured
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(logr.Logger, *v1alpha1.NFDeployment, *ConfigInfo) ([]*unstructured.Unstructured, error)); ok {
+		return returnFunc(logger, nFDeployment, configInfo)
+	}
+	if returnFunc, ok := ret.Get(0).(func(logr.Logger, *v1alpha1.NFDeployment, *ConfigInfo) []*unstructured.Unstructured); ok {
+		r0 = returnFunc(logger, nFDeployment, configInfo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*unstructured.Unstructured)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(logr.Logger, *v1alpha1.NFDeployment, *ConfigInfo) error); ok {
+		r1 = returnFunc(logger, nFDeployment, configInfo)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockNfResource_GetNetworkAttachmentDefinitions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNetworkAttachmentDefinitions'
+type MockNfResource_GetNetworkAttachmentDefinitions_Call struct {
+	*mock.Call
+}
+
+// GetNetworkAttachmentDefinitions is a helper method to define mock.On call
+//   - logger logr.Logger
+//   - nFDeployment *v1alpha1.NFDeployment
+//   - configInfo *ConfigInfo
+func (_e *MockNfResource_Expecter) GetNetworkAttachmentDefinitions(logger interface{}, nFDeployment interface{}, configInfo interface{}) *MockNfResource_GetNetworkAttachmentDefinitions_Call {
+	return &MockNfResource_GetNetworkAttachmentDefinitions_Call{Call: _e.mock.On("GetNetworkAttachmentDefinitions", logger, nFDeployment, configInfo)}
+}
+
+func (_c *MockNfResource_GetNetworkAttachmentDefinitions_Call) Run(run func(logger logr.Logger, nFDeployment *v1alpha1.NFDeployment, configInfo *ConfigInfo)) *MockNfResource_GetNetworkAttachmentDefinitions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 logr.Logger
+		if args[0] != nil {
+			arg0 = args[0].(logr.Logger)
+		}
+		var arg1 *v1alpha1.NFDeployment
+		if args[1] != nil {
+			arg1 = args[1].(*v1alpha1.NFDeployment)
+		}
+		var arg2 *ConfigInfo
+		if args[2] != nil {
+			arg2 = args[2].(*ConfigInfo)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockNfResource_GetNetworkAttachmentDefinitions_Call) Return(unstructureds []*unstructured.Unstructured, err error) *MockNfResource_GetNetworkAttachmentDefinitions_Call {
+	_c.Call.Return(unstructureds, err)
+	return _c
+}
+
+func (_c *MockNfResource_GetNetworkAttachmentDefinitions_Call) RunAndReturn(run func(logger logr.Logger, nFDeployment *v1alpha1.NFDeployment, configInfo *ConfigInfo) ([]*unstructured.Unstructured, error)) *MockNfResource_GetNetworkAttachmentDefinitions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetService provides a mock function for the type MockNfResource
-func (_mock *MockNfResource) GetService() []*v1.Service {
-	ret := _mock.Called()
+func (_mock *MockNfResource) GetService(logger logr.Logger, nFDeployment *v1alpha1.NFDeployment, configInfo *ConfigInfo) []*v1.Service {
+	ret := _mock.Called(logger, nFDeployment, configInfo)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetService")
 	}
 
 	var r0 []*v1.Service
-	if returnFunc, ok := ret.Get(0).(func() []*v1.Service); ok {
-		r0 = returnFunc()
+	if returnFunc, ok := ret.Get(0).(func(logr.Logger, *v1alpha1.NFDeployment, *ConfigInfo) []*v1.Service); ok {
+		r0 = returnFunc(logger, nFDeployment, configInfo)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*v1.Service)
@@ -194,13 +270,32 @@ type MockNfResource_GetService_Call struct {
 }
 
 // GetService is a helper method to define mock.On call
-func (_e *MockNfResource_Expecter) GetService() *MockNfResource_GetService_Call {
-	return &MockNfResource_GetService_Call{Call: _e.mock.On("GetService")}
+//   - logger logr.Logger
+//   - nFDeployment *v1alpha1.NFDeployment
+//   - configInfo *ConfigInfo
+func (_e *MockNfResource_Expecter) GetService(logger interface{}, nFDeployment interface{}, configInfo interface{}) *MockNfResource_GetService_Call {
+	return &MockNfResource_GetService_Call{Call: _e.mock.On("GetService", logger, nFDeployment, configInfo)}
 }
 
-func (_c *MockNfResource_GetService_Call) Run(run func()) *MockNfResource_GetService_Call {
+func (_c *MockNfResource_GetService_Call) Run(run func(logger logr.Logger, nFDeployment *v1alpha1.NFDeployment, configInfo *ConfigInfo)) *MockNfResource_GetService_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		var arg0 logr.Logger
+		if args[0] != nil {
+			arg0 = args[0].(logr.Logger)
+		}
+		var arg1 *v1alpha1.NFDeployment
+		if args[1] != nil {
+			arg1 = args[1].(*v1alpha1.NFDeployment)
+		}
+		var arg2 *ConfigInfo
+		if args[2] != nil {
+			arg2 = args[2].(*ConfigInfo)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
 	})
 	return _c
 }
@@ -210,7 +305,7 @@ func (_c *MockNfResource_GetService_Call) Return(services []*v1.Service) *MockNf
 	return _c
 }
 
-func (_c *MockNfResource_GetService_Call) RunAndReturn(run func() []*v1.Service) *MockNfResource_GetService_Call {
+func (_c *MockNfResource_GetService_Call) RunAndReturn(run func(logger logr.Logger, nFDeployment *v1alpha1.NFDeployment, configInfo *ConfigInfo) []*v1.Service) *MockNfResource_GetService_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -262,8 +357,8 @@ func (_c *MockNfResource_GetServiceAccount_Call) RunAndReturn(run func() []*v1.S
 }
 
 // createNetworkAttachmentDefinitionNetworks provides a mock function for the type MockNfResource
-func (_mock *MockNfResource) createNetworkAttachmentDefinitionNetworks(s string, nFDeploymentSpec *v1alpha1.NFDeploymentSpec) (string, error) {
-	ret := _mock.Called(s, nFDeploymentSpec)
+func (_mock *MockNfResource) createNetworkAttachmentDefinitionNetworks(s string, nFDeploymentSpec *v1alpha1.NFDeploymentSpec, networkConfigs []v1alpha10.NetworkConfig) (string, error) {
+	ret := _mock.Called(s, nFDeploymentSpec, networkConfigs)
 
 	if len(ret) == 0 {
 		panic("no return value specified for createNetworkAttachmentDefinitionNetworks")
@@ -271,16 +366,16 @@ func (_mock *MockNfResource) createNetworkAttachmentDefinitionNetworks(s string,
 
 	var r0 string
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string, *v1alpha1.NFDeploymentSpec) (string, error)); ok {
-		return returnFunc(s, nFDeploymentSpec)
+	if returnFunc, ok := ret.Get(0).(func(string, *v1alpha1.NFDeploymentSpec, []v1alpha10.NetworkConfig) (string, error)); ok {
+		return returnFunc(s, nFDeploymentSpec, networkConfigs)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string, *v1alpha1.NFDeploymentSpec) string); ok {
-		r0 = returnFunc(s, nFDeploymentSpec)
+	if returnFunc, ok := ret.Get(0).(func(string, *v1alpha1.NFDeploymentSpec, []v1alpha10.NetworkConfig) string); ok {
+		r0 = returnFunc(s, nFDeploymentSpec, networkConfigs)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
-	if returnFunc, ok := ret.Get(1).(func(string, *v1alpha1.NFDeploymentSpec) error); ok {
-		r1 = returnFunc(s, nFDeploymentSpec)
+	if returnFunc, ok := ret.Get(1).(func(string, *v1alpha1.NFDeploymentSpec, []v1alpha10.NetworkConfig) error); ok {
+		r1 = returnFunc(s, nFDeploymentSpec, networkConfigs)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -295,11 +390,12 @@ type MockNfResource_createNetworkAttachmentDefinitionNetworks_Call struct {
 // createNetworkAttachmentDefinitionNetworks is a helper method to define mock.On call
 //   - s string
 //   - nFDeploymentSpec *v1alpha1.NFDeploymentSpec
-func (_e *MockNfResource_Expecter) createNetworkAttachmentDefinitionNetworks(s interface{}, nFDeploymentSpec interface{}) *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call {
-	return &MockNfResource_createNetworkAttachmentDefinitionNetworks_Call{Call: _e.mock.On("createNetworkAttachmentDefinitionNetworks", s, nFDeploymentSpec)}
+//   - networkConfigs []v1alpha10.NetworkConfig
+func (_e *MockNfResource_Expecter) createNetworkAttachmentDefinitionNetworks(s interface{}, nFDeploymentSpec interface{}, networkConfigs interface{}) *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call {
+	return &MockNfResource_createNetworkAttachmentDefinitionNetworks_Call{Call: _e.mock.On("createNetworkAttachmentDefinitionNetworks", s, nFDeploymentSpec, networkConfigs)}
 }
 
-func (_c *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call) Run(run func(s string, nFDeploymentSpec *v1alpha1.NFDeploymentSpec)) *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call {
+func (_c *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call) Run(run func(s string, nFDeploymentSpec *v1alpha1.NFDeploymentSpec, networkConfigs []v1alpha10.NetworkConfig)) *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
@@ -309,9 +405,14 @@ func (_c *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call) Run(run
 		if args[1] != nil {
 			arg1 = args[1].(*v1alpha1.NFDeploymentSpec)
 		}
+		var arg2 []v1alpha10.NetworkConfig
+		if args[2] != nil {
+			arg2 = args[2].([]v1alpha10.NetworkConfig)
+		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
@@ -322,7 +423,7 @@ func (_c *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call) Return(
 	return _c
 }
 
-func (_c *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call) RunAndReturn(run func(s string, nFDeploymentSpec *v1alpha1.NFDeploymentSpec) (string, error)) *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call {
+func (_c *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call) RunAndReturn(run func(s string, nFDeploymentSpec *v1alpha1.NFDeploymentSpec, networkConfigs []v1alpha10.NetworkConfig) (string, error)) *MockNfResource_createNetworkAttachmentDefinitionNetworks_Call {
 	_c.Call.Return(run)
 	return _c
 }
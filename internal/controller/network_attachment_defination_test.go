@@ -20,13 +20,17 @@ import (
 	"testing"
 
 	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
 
 func TestCreateNetworkAttachmentDefinitionNetworks(t *testing.T) {
 	cases := map[string]struct {
 		templateName     string
 		interfaceConfigs map[string][]workloadv1alpha1.InterfaceConfig
+		networkConfigs   []workloadnfconfig.NetworkConfig
 		want             string
 	}{
 		"Normal": {
@@ -52,7 +56,7 @@ func TestCreateNetworkAttachmentDefinitionNetworks(t *testing.T) {
 				}
 			   ] `,
 		},
-		"Gateway Not Provided": {
+		"Dual Stack": {
 			templateName: "abc",
 			interfaceConfigs: map[string][]workloadv1alpha1.InterfaceConfig{
 				"f1": []workloadv1alpha1.InterfaceConfig{
@@ -60,18 +64,135 @@ func TestCreateNetworkAttachmentDefinitionNetworks(t *testing.T) {
 						Name: "f1",
 						IPv4: &workloadv1alpha1.IPv4{
 							Address: "172.5.1.3/24",
+							Gateway: ptr.To("172.5.1.1"),
+						},
+						IPv6: &workloadv1alpha1.IPv6{
+							Address: "fd00:5:1::3/64",
+							Gateway: ptr.To("fd00:5:1::1"),
 						},
 						VLANID: uint16Ptr(2),
 					},
 				},
 			},
+			want: `[
+				{
+				 "name": "abc-f1",
+				 "interface": "f1",
+				 "ips": ["172.5.1.3/24", "fd00:5:1::3/64"],
+				 "gateways": ["172.5.1.1", "fd00:5:1::1"]
+				}
+			   ] `,
+		},
+		"IPv6 Only": {
+			templateName: "abc",
+			interfaceConfigs: map[string][]workloadv1alpha1.InterfaceConfig{
+				"f1": []workloadv1alpha1.InterfaceConfig{
+					{
+						Name: "f1",
+						IPv6: &workloadv1alpha1.IPv6{
+							Address: "fd00:5:1::3/64",
+							Gateway: ptr.To("fd00:5:1::1"),
+						},
+					},
+				},
+			},
+			want: `[
+				{
+				 "name": "abc-f1",
+				 "interface": "f1",
+				 "ips": ["fd00:5:1::3/64"],
+				 "gateways": ["fd00:5:1::1"]
+				}
+			   ] `,
+		},
+		"IPv6 Gateway Not Provided": {
+			templateName: "abc",
+			interfaceConfigs: map[string][]workloadv1alpha1.InterfaceConfig{
+				"f1": []workloadv1alpha1.InterfaceConfig{
+					{
+						Name: "f1",
+						IPv4: &workloadv1alpha1.IPv4{
+							Address: "172.5.1.3/24",
+							Gateway: ptr.To("172.5.1.1"),
+						},
+						IPv6: &workloadv1alpha1.IPv6{
+							Address: "fd00:5:1::3/64",
+						},
+					},
+				},
+			},
+			want: `[
+				{
+				 "name": "abc-f1",
+				 "interface": "f1",
+				 "ips": ["172.5.1.3/24", "fd00:5:1::3/64"],
+				 "gateways": ["172.5.1.1"]
+				}
+			   ] `,
+		},
+		"No Address": {
+			templateName: "abc",
+			interfaceConfigs: map[string][]workloadv1alpha1.InterfaceConfig{
+				"f1": []workloadv1alpha1.InterfaceConfig{
+					{
+						Name: "f1",
+					},
+				},
+			},
 			want: "error",
 		},
+		"Gateway Not Provided": {
+			templateName: "abc",
+			interfaceConfigs: map[string][]workloadv1alpha1.InterfaceConfig{
+				"f1": []workloadv1alpha1.InterfaceConfig{
+					{
+						Name: "f1",
+						IPv4: &workloadv1alpha1.IPv4{
+							Address: "172.5.1.3/24",
+						},
+						VLANID: uint16Ptr(2),
+					},
+				},
+			},
+			want: `[
+				{
+				 "name": "abc-f1",
+				 "interface": "f1",
+				 "ips": ["172.5.1.3/24"]
+				}
+			   ] `,
+		},
+		"Pinned MAC": {
+			templateName: "abc",
+			interfaceConfigs: map[string][]workloadv1alpha1.InterfaceConfig{
+				"f1": []workloadv1alpha1.InterfaceConfig{
+					{
+						Name: "f1",
+						IPv4: &workloadv1alpha1.IPv4{
+							Address: "172.5.1.3/24",
+							Gateway: ptr.To("172.5.1.1"),
+						},
+					},
+				},
+			},
+			networkConfigs: []workloadnfconfig.NetworkConfig{
+				{Interface: "f1", Master: "eth1", MAC: "02:00:00:05:01:03"},
+			},
+			want: `[
+				{
+				 "name": "abc-f1",
+				 "interface": "f1",
+				 "ips": ["172.5.1.3/24"],
+				 "gateways": ["172.5.1.1"],
+				 "mac": "02:00:00:05:01:03"
+				}
+			   ] `,
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := CreateNetworkAttachmentDefinitionNetworks(tc.templateName, tc.interfaceConfigs)
+			got, err := CreateNetworkAttachmentDefinitionNetworks(tc.templateName, tc.interfaceConfigs, tc.networkConfigs)
 			if tc.want == "error" {
 				if err == nil {
 					t.Errorf("createNetworkAttachmentDefinitionNetworks Returned Nil expecting error")
@@ -80,7 +201,7 @@ func TestCreateNetworkAttachmentDefinitionNetworks(t *testing.T) {
 				if err != nil {
 					t.Errorf("createNetworkAttachmentDefinitionNetworks Error %v ", err)
 				}
-				if !compareStringLineByLineTrimmed(got, tc.want) {
+				if !compareJson(got, tc.want) {
 					t.Errorf("createNetworkAttachmentDefinitionNetworks returned %s wanted %s", got, tc.want)
 				}
 			}
@@ -89,3 +210,129 @@ func TestCreateNetworkAttachmentDefinitionNetworks(t *testing.T) {
 	}
 
 }
+
+func TestCreateNetworkAttachmentDefinitions(t *testing.T) {
+	ranDeployment := &workloadv1alpha1.NFDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "du-a", Namespace: "myns", UID: "1234"},
+	}
+	interfaceConfigs := map[string][]workloadv1alpha1.InterfaceConfig{
+		"f1": {
+			{
+				Name: "f1",
+				IPv4: &workloadv1alpha1.IPv4{
+					Address: "172.5.1.3/24",
+					Gateway: ptr.To("172.5.1.1"),
+				},
+				VLANID: uint16Ptr(2),
+			},
+		},
+		"e2": {},
+	}
+
+	cases := map[string]struct {
+		networkConfig        workloadnfconfig.NetworkConfig
+		wantConfig           string
+		wantResourceName     string
+		wantNoNetworkAttDefs bool
+		wantsError           bool
+	}{
+		"Macvlan On VLAN": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeMacvlan, Master: "eth1"},
+			wantConfig:    `{"cniVersion":"0.3.1","plugins":[{"capabilities":{"ips":true},"ipam":{"type":"static"},"master":"eth1.2","mode":"bridge","type":"macvlan"}]}`,
+		},
+		"Macvlan By Default": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Master: "eth1"},
+			wantConfig:    `{"cniVersion":"0.3.1","plugins":[{"capabilities":{"ips":true},"ipam":{"type":"static"},"master":"eth1.2","mode":"bridge","type":"macvlan"}]}`,
+		},
+		"Ipvlan": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeIpvlan, Master: "ens3"},
+			wantConfig:    `{"cniVersion":"0.3.1","plugins":[{"capabilities":{"ips":true},"ipam":{"type":"static"},"master":"ens3.2","mode":"l2","type":"ipvlan"}]}`,
+		},
+		"Bridge": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeBridge, Master: "br-ran"},
+			wantConfig:    `{"cniVersion":"0.3.1","plugins":[{"bridge":"br-ran","capabilities":{"ips":true},"ipam":{"type":"static"},"type":"bridge","vlan":2}]}`,
+		},
+		"Sriov": {
+			networkConfig:    workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeSriov, ResourceName: "intel.com/sriov_netdevice"},
+			wantConfig:       `{"cniVersion":"0.3.1","plugins":[{"capabilities":{"ips":true},"ipam":{"type":"static"},"type":"sriov","vlan":2}]}`,
+			wantResourceName: "intel.com/sriov_netdevice",
+		},
+		"MTU And Routes": {
+			networkConfig: workloadnfconfig.NetworkConfig{
+				Interface: "f1",
+				Master:    "eth1",
+				MTU:       ptr.To(int32(9000)),
+				Routes: []workloadnfconfig.StaticRoute{
+					{Destination: "10.10.0.0/16", Gateway: ptr.To("172.5.1.254")},
+					{Destination: "172.6.0.0/24"},
+				},
+			},
+			wantConfig: `{"cniVersion":"0.3.1","plugins":[{"capabilities":{"ips":true},"ipam":{"routes":[{"dst":"10.10.0.0/16","gw":"172.5.1.254"},{"dst":"172.6.0.0/24"}],"type":"static"},"master":"eth1.2","mode":"bridge","mtu":9000,"type":"macvlan"}]}`,
+		},
+		"Pinned MAC": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeBridge, Master: "br-ran", MAC: "02:00:00:05:01:03"},
+			wantConfig:    `{"cniVersion":"0.3.1","plugins":[{"bridge":"br-ran","capabilities":{"ips":true,"mac":true},"ipam":{"type":"static"},"type":"bridge","vlan":2}]}`,
+		},
+		"Pinned MAC On Ipvlan": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeIpvlan, Master: "ens3", MAC: "02:00:00:05:01:03"},
+			wantsError:    true,
+		},
+		"Invalid Route": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Master: "eth1", Routes: []workloadnfconfig.StaticRoute{{Destination: "10.10.0.0"}}},
+			wantsError:    true,
+		},
+		"Interface Not Used": {
+			networkConfig:        workloadnfconfig.NetworkConfig{Interface: "e2", Master: "eth1"},
+			wantNoNetworkAttDefs: true,
+		},
+		"Unknown Interface": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "n3", Master: "eth1"},
+			wantsError:    true,
+		},
+		"Macvlan Without Master": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1"},
+			wantsError:    true,
+		},
+		"Sriov Without Resource": {
+			networkConfig: workloadnfconfig.NetworkConfig{Interface: "f1", Type: workloadnfconfig.NetworkTypeSriov},
+			wantsError:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := CreateNetworkAttachmentDefinitions(ranDeployment, []workloadnfconfig.NetworkConfig{tc.networkConfig}, interfaceConfigs)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("CreateNetworkAttachmentDefinitions returned %v, expecting an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateNetworkAttachmentDefinitions returned error %v", err)
+			}
+			if tc.wantNoNetworkAttDefs {
+				if len(got) != 0 {
+					t.Errorf("CreateNetworkAttachmentDefinitions returned %v, expecting none", got)
+				}
+				return
+			}
+			if len(got) != 1 {
+				t.Fatalf("CreateNetworkAttachmentDefinitions returned %d NetworkAttachmentDefinitions, expecting 1", len(got))
+			}
+			networkAttachmentDefinition := got[0]
+			if networkAttachmentDefinition.GroupVersionKind() != NetworkAttachmentDefinitionGVK || networkAttachmentDefinition.GetName() != "du-a-f1" || networkAttachmentDefinition.GetNamespace() != "myns" {
+				t.Errorf("CreateNetworkAttachmentDefinitions returned %v %s/%s", networkAttachmentDefinition.GroupVersionKind(), networkAttachmentDefinition.GetNamespace(), networkAttachmentDefinition.GetName())
+			}
+			if owners := networkAttachmentDefinition.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != "1234" || owners[0].Kind != "NFDeployment" {
+				t.Errorf("CreateNetworkAttachmentDefinitions owners %v, expecting the NFDeployment", owners)
+			}
+			if config, _, _ := unstructured.NestedString(networkAttachmentDefinition.Object, "spec", "config"); config != tc.wantConfig {
+				t.Errorf("CreateNetworkAttachmentDefinitions config %s, wanted %s", config, tc.wantConfig)
+			}
+			if resourceName := networkAttachmentDefinition.GetAnnotations()[ResourceNameAnnotation]; resourceName != tc.wantResourceName {
+				t.Errorf("CreateNetworkAttachmentDefinitions resource name %q, wanted %q", resourceName, tc.wantResourceName)
+			}
+		})
+	}
+}
@@ -19,23 +19,38 @@ limitations under the License.
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"sort"
-	"strings"
 
 	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
 
 const NetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
 
+const ResourceNameAnnotation = "k8s.v1.cni.cncf.io/resourceName"
+
 var NetworkAttachmentDefinitionGVK = schema.GroupVersionKind{
 	Group:   "k8s.cni.cncf.io",
 	Kind:    "NetworkAttachmentDefinition",
 	Version: "v1",
 }
 
-func CreateNetworkAttachmentDefinitionNetworks(templateName string, interfaceConfigs map[string][]workloadv1alpha1.InterfaceConfig) (string, error) {
+// networkSelectionElement is an entry of the networks annotation read by Multus
+type networkSelectionElement struct {
+	Name      string   `json:"name"`
+	Interface string   `json:"interface"`
+	IPs       []string `json:"ips"`
+	Gateways  []string `json:"gateways,omitempty"`
+	MAC       string   `json:"mac,omitempty"`
+}
+
+func CreateNetworkAttachmentDefinitionNetworks(templateName string, interfaceConfigs map[string][]workloadv1alpha1.InterfaceConfig, networkConfigs []workloadnfconfig.NetworkConfig) (string, error) {
 
 	interfaceNames := make([]string, 0, len(interfaceConfigs))
 	for interfaceName := range interfaceConfigs {
@@ -44,29 +59,190 @@ func CreateNetworkAttachmentDefinitionNetworks(templateName string, interfaceCon
 
 	sort.Strings(interfaceNames) // ensure consistent return value for unit tests
 
-	var networksJson []string
+	macs := map[string]string{}
+	for _, networkConfig := range networkConfigs {
+		macs[networkConfig.Interface] = networkConfig.MAC
+	}
+
+	networks := []networkSelectionElement{}
 	for _, interfaceName := range interfaceNames {
 		for _, interfaceConfig := range interfaceConfigs[interfaceName] {
-			if interfaceConfig.IPv4.Gateway == nil {
-				return "", fmt.Errorf("missing `InterfaceConfig.IPv4.Gateway` for %q", interfaceName)
+			if interfaceConfig.IPv4 == nil && interfaceConfig.IPv6 == nil {
+				return "", fmt.Errorf("missing `InterfaceConfig.IPv4` and `InterfaceConfig.IPv6` for %q", interfaceName)
 			}
 
-			networksJson = append(networksJson, fmt.Sprintf(` {
-  "name": %q,
-  "interface": %q,
-  "ips": [%q],
-  "gateways": [%q]
- }`,
-				CreateNetworkAttachmentDefinitionName(templateName, interfaceName),
-				interfaceConfig.Name,
-				interfaceConfig.IPv4.Address,
-				*interfaceConfig.IPv4.Gateway))
+			// Directly connected interfaces have no gateway
+			network := networkSelectionElement{
+				Name:      CreateNetworkAttachmentDefinitionName(templateName, interfaceName),
+				Interface: interfaceConfig.Name,
+				MAC:       macs[interfaceName],
+			}
+			if interfaceConfig.IPv4 != nil {
+				network.IPs = append(network.IPs, interfaceConfig.IPv4.Address)
+				if interfaceConfig.IPv4.Gateway != nil {
+					network.Gateways = append(network.Gateways, *interfaceConfig.IPv4.Gateway)
+				}
+			}
+			if interfaceConfig.IPv6 != nil {
+				network.IPs = append(network.IPs, interfaceConfig.IPv6.Address)
+				if interfaceConfig.IPv6.Gateway != nil {
+					network.Gateways = append(network.Gateways, *interfaceConfig.IPv6.Gateway)
+				}
+			}
+			networks = append(networks, network)
 		}
 	}
 
-	return "[\n" + strings.Join(networksJson, ",\n") + "\n]", nil
+	networksJson, err := json.MarshalIndent(networks, "", " ")
+	if err != nil {
+		return "", err
+	}
+	return string(networksJson), nil
 }
 
 func CreateNetworkAttachmentDefinitionName(templateName string, suffix string) string {
 	return templateName + "-" + suffix
 }
+
+// createNetworkConfig returns the CNI configuration of a network, the addresses are given per pod by the networks annotation
+func createNetworkConfig(networkConfig workloadnfconfig.NetworkConfig, vlanId *uint16) (string, error) {
+	capabilities := map[string]any{"ips": true}
+	ipam := map[string]any{"type": "static"}
+	plugin := map[string]any{
+		"capabilities": capabilities,
+		"ipam":         ipam,
+	}
+
+	if networkConfig.MTU != nil {
+		plugin["mtu"] = *networkConfig.MTU
+	}
+	if networkConfig.MAC != "" {
+		if networkConfig.Type == workloadnfconfig.NetworkTypeIpvlan {
+			return "", fmt.Errorf("MAC address of %q cannot be pinned on an ipvlan network", networkConfig.Interface)
+		}
+		if _, err := net.ParseMAC(networkConfig.MAC); err != nil {
+			return "", fmt.Errorf("invalid MAC address of %q: %v", networkConfig.Interface, err)
+		}
+		capabilities["mac"] = true
+	}
+	if len(networkConfig.Routes) > 0 {
+		routes := make([]any, 0, len(networkConfig.Routes))
+		for _, staticRoute := range networkConfig.Routes {
+			if _, _, err := net.ParseCIDR(staticRoute.Destination); err != nil {
+				return "", fmt.Errorf("invalid route destination of %q: %v", networkConfig.Interface, err)
+			}
+			route := map[string]any{"dst": staticRoute.Destination}
+			if staticRoute.Gateway != nil {
+				if net.ParseIP(*staticRoute.Gateway) == nil {
+					return "", fmt.Errorf("invalid route gateway %q of %q", *staticRoute.Gateway, networkConfig.Interface)
+				}
+				route["gw"] = *staticRoute.Gateway
+			}
+			routes = append(routes, route)
+		}
+		ipam["routes"] = routes
+	}
+
+	switch networkConfig.Type {
+	case workloadnfconfig.NetworkTypeMacvlan, workloadnfconfig.NetworkTypeIpvlan, "":
+		if networkConfig.Master == "" {
+			return "", fmt.Errorf("missing master for the %s network of %q", networkConfig.Type, networkConfig.Interface)
+		}
+		master := networkConfig.Master
+		if vlanId != nil {
+			master = fmt.Sprintf("%s.%d", master, *vlanId)
+		}
+		plugin["master"] = master
+		if networkConfig.Type == workloadnfconfig.NetworkTypeIpvlan {
+			plugin["type"] = "ipvlan"
+			plugin["mode"] = "l2"
+		} else {
+			plugin["type"] = "macvlan"
+			plugin["mode"] = "bridge"
+		}
+	case workloadnfconfig.NetworkTypeBridge:
+		if networkConfig.Master == "" {
+			return "", fmt.Errorf("missing master for the bridge network of %q", networkConfig.Interface)
+		}
+		plugin["type"] = "bridge"
+		plugin["bridge"] = networkConfig.Master
+		if vlanId != nil {
+			plugin["vlan"] = *vlanId
+		}
+	case workloadnfconfig.NetworkTypeSriov:
+		if networkConfig.ResourceName == "" {
+			return "", fmt.Errorf("missing resourceName for the sriov network of %q", networkConfig.Interface)
+		}
+		plugin["type"] = "sriov"
+		if vlanId != nil {
+			plugin["vlan"] = *vlanId
+		}
+	default:
+		return "", fmt.Errorf("not supported network type %q for %q", networkConfig.Type, networkConfig.Interface)
+	}
+
+	config, err := json.Marshal(map[string]any{
+		"cniVersion": "0.3.1",
+		"plugins":    []any{plugin},
+	})
+	return string(config), err
+}
+
+// CreateNetworkAttachmentDefinitions returns the NetworkAttachmentDefinitions of the interfaces with a network config,
+// owned by the NFDeployment and named as referenced by the networks annotation
+func CreateNetworkAttachmentDefinitions(ranDeployment *workloadv1alpha1.NFDeployment, networkConfigs []workloadnfconfig.NetworkConfig, interfaceConfigs map[string][]workloadv1alpha1.InterfaceConfig) ([]*unstructured.Unstructured, error) {
+	networkAttachmentDefinitions := []*unstructured.Unstructured{}
+	for _, networkConfig := range networkConfigs {
+		configs, found := interfaceConfigs[networkConfig.Interface]
+		if !found {
+			return nil, fmt.Errorf("network for %q which is not an interface of the NF", networkConfig.Interface)
+		}
+		if len(configs) == 0 {
+			// The interface is not used by this NFDeployment
+			continue
+		}
+
+		config, err := createNetworkConfig(networkConfig, configs[0].VLANID)
+		if err != nil {
+			return nil, err
+		}
+
+		networkAttachmentDefinition := &unstructured.Unstructured{}
+		networkAttachmentDefinition.SetGroupVersionKind(NetworkAttachmentDefinitionGVK)
+		networkAttachmentDefinition.SetName(CreateNetworkAttachmentDefinitionName(ranDeployment.Name, networkConfig.Interface))
+		networkAttachmentDefinition.SetNamespace(ranDeployment.Namespace)
+		networkAttachmentDefinition.SetOwnerReferences([]metav1.OwnerReference{
+			*metav1.NewControllerRef(ranDeployment, workloadv1alpha1.GroupVersion.WithKind("NFDeployment")),
+		})
+		if networkConfig.Type == workloadnfconfig.NetworkTypeSriov {
+			networkAttachmentDefinition.SetAnnotations(map[string]string{ResourceNameAnnotation: networkConfig.ResourceName})
+		}
+		if err := unstructured.SetNestedField(networkAttachmentDefinition.Object, config, "spec", "config"); err != nil {
+			return nil, err
+		}
+		networkAttachmentDefinitions = append(networkAttachmentDefinitions, networkAttachmentDefinition)
+	}
+	return networkAttachmentDefinitions, nil
+}
+
+// getNetworkConfigs returns the networks of the OAIConfig of the NF
+func getNetworkConfigs(configInfo *ConfigInfo) ([]workloadnfconfig.NetworkConfig, error) {
+	rawOaiConfig, found := configInfo.ConfigSelfInfo["OAIConfig"]
+	if !found {
+		return nil, nil
+	}
+	paramsOAI := &workloadnfconfig.OAIConfig{}
+	if err := json.Unmarshal(rawOaiConfig.Raw, paramsOAI); err != nil {
+		return nil, err
+	}
+	return paramsOAI.Spec.Networks, nil
+}
+
+// getNetworkAttachmentDefinitions returns the NetworkAttachmentDefinitions requested by the OAIConfig of the NF
+func getNetworkAttachmentDefinitions(ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo, interfaceConfigs map[string][]workloadv1alpha1.InterfaceConfig) ([]*unstructured.Unstructured, error) {
+	networkConfigs, err := getNetworkConfigs(configInfo)
+	if err != nil {
+		return nil, err
+	}
+	return CreateNetworkAttachmentDefinitions(ranDeployment, networkConfigs, interfaceConfigs)
+}
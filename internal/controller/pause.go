@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// PausedAnnotation set to "true" freezes the reconciliation of a NFDeployment: its resources are neither created,
+// updated nor deleted, e.g. while they are edited by hand
+const PausedAnnotation = "workload.nephio.org/paused"
+
+// isPaused tells whether the reconciliation of a NFDeployment is paused
+func isPaused(ranDeployment *workloadv1alpha1.NFDeployment) bool {
+	return ranDeployment.Annotations[PausedAnnotation] == "true"
+}
+
+// ReconcilePaused reports a paused NFDeployment in the paused condition. A paused NFDeployment being deleted only
+// gets its finalizer removed, its resources are left for the ones editing them. During a dry-run the finalizer is kept
+func (r *RANDeploymentReconciler) ReconcilePaused(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) error {
+	if !ranDeployment.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(ranDeployment, finalizerName) {
+			return nil
+		}
+		if r.isDryRun(ranDeployment) {
+			r.recordEvent(ranDeployment, corev1.EventTypeNormal, ReasonDryRun, "Deleted while paused during a dry-run, the finalizer is kept")
+			return nil
+		}
+		r.recordEvent(ranDeployment, corev1.EventTypeWarning, ReasonPaused, "Deleted while paused, its resources are not deleted")
+		controllerutil.RemoveFinalizer(ranDeployment, finalizerName)
+		if err := r.Update(ctx, ranDeployment); err != nil {
+			r.recordEvent(ranDeployment, corev1.EventTypeWarning, ReasonUpdateFailed, "Cannot remove the finalizer: %v", err)
+			return err
+		}
+		r.recordEvent(ranDeployment, corev1.EventTypeNormal, ReasonFinalizerRemoved, "Removed the finalizer %s", finalizerName)
+		return nil
+	}
+
+	if !meta.IsStatusConditionTrue(ranDeployment.Status.Conditions, "paused") {
+		r.recordEvent(ranDeployment, corev1.EventTypeNormal, ReasonPaused, "Reconciliation is paused, the resources are not changed")
+	}
+	curCondition := metav1.Condition{
+		Type:               "paused",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionTrue,
+		Reason:             "paused",
+		Message:            "Reconciliation is paused by the " + PausedAnnotation + " annotation",
+	}
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
+
+// UpdateResumedStatus reports in the paused condition and in an event the drift of the resources of a NFDeployment
+// from the rendered ones when its reconciliation resumes, i.e. the changes made while it was paused
+func (r *RANDeploymentReconciler) UpdateResumedStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	if !meta.IsStatusConditionTrue(ranDeployment.Status.Conditions, "paused") {
+		return nil
+	}
+	curCondition := metav1.Condition{
+		Type:               "paused",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionFalse,
+		Reason:             "paused",
+	}
+	eventType := corev1.EventTypeNormal
+	var gnbConfDiff []string
+	rendered, err := renderResources(ctx, ranDeployment, configInfo)
+	if err == nil {
+		var diffs []ObjectDiff
+		diffs, gnbConfDiff, err = r.DiffResources(ctx, rendered)
+		if err != nil {
+			return err
+		}
+		curCondition.Message = "Reconciliation resumed | Drift: " + summarizeDiffs(diffs)
+		for _, diff := range diffs {
+			if diff.Action != "unchanged" {
+				eventType = corev1.EventTypeWarning
+			}
+		}
+	} else {
+		curCondition.Message = "Reconciliation resumed, the drift cannot be computed | Error: " + err.Error()
+		eventType = corev1.EventTypeWarning
+	}
+
+	r.recordEvent(ranDeployment, eventType, ReasonResumed, "%s", diffEventMessage(curCondition.Message, gnbConfDiff))
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestReconcilePaused(t *testing.T) {
+	cases := map[string]struct {
+		deleted        bool
+		dryRun         bool
+		wantFinalizer  bool
+		wantConditions int
+		wantUpdates    int
+		wantEvents     []string
+	}{
+		"Paused": {
+			wantFinalizer:  true,
+			wantConditions: 1,
+			wantEvents:     []string{"Normal Paused"},
+		},
+		"Deleted While Paused": {
+			deleted:     true,
+			wantUpdates: 1,
+			wantEvents:  []string{"Warning Paused", "Normal FinalizerRemoved"},
+		},
+		"Deleted While Paused During A Dry-Run": {
+			deleted:       true,
+			dryRun:        true,
+			wantFinalizer: true,
+			wantEvents:    []string{"Normal DryRun", "Normal DryRun"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+			recorder := record.NewFakeRecorder(3)
+			r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme(), Recorder: recorder, DryRun: tc.dryRun}
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "du-edge", Annotations: map[string]string{PausedAnnotation: "true"}},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+			}
+			controllerutil.AddFinalizer(ranDeployment, finalizerName)
+			if tc.deleted {
+				ranDeployment.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+			}
+			if !isPaused(ranDeployment) {
+				t.Fatalf("isPaused returned false with the %s annotation", PausedAnnotation)
+			}
+			for range 2 {
+				if err := r.ReconcilePaused(context.TODO(), ranDeployment); err != nil {
+					t.Fatalf("ReconcilePaused returned error %v", err)
+				}
+			}
+
+			if controllerutil.ContainsFinalizer(ranDeployment, finalizerName) != tc.wantFinalizer {
+				t.Errorf("ReconcilePaused left the finalizers %v", ranDeployment.Finalizers)
+			}
+			clientMock.AssertNumberOfCalls(t, "Update", tc.wantUpdates)
+			if len(ranDeployment.Status.Conditions) != tc.wantConditions {
+				t.Errorf("ReconcilePaused set the conditions %v", ranDeployment.Status.Conditions)
+			}
+			if len(recorder.Events) != len(tc.wantEvents) {
+				t.Fatalf("ReconcilePaused recorded %d events, expected %d", len(recorder.Events), len(tc.wantEvents))
+			}
+			for _, wantEvent := range tc.wantEvents {
+				if event := <-recorder.Events; !strings.HasPrefix(event, wantEvent) {
+					t.Errorf("ReconcilePaused recorded the event %q, expected %s", event, wantEvent)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateResumedStatus(t *testing.T) {
+	cases := map[string]struct {
+		paused      bool
+		wantMessage string
+	}{
+		"Not Paused": {},
+		"Resumed": {
+			paused:      true,
+			wantMessage: "Reconciliation resumed, the drift cannot be computed",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+			recorder := record.NewFakeRecorder(1)
+			r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme(), Recorder: recorder}
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{Spec: workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"}}
+			if tc.paused {
+				ranDeployment.Status.Conditions = []metav1.Condition{{Type: "paused", Status: metav1.ConditionTrue, Reason: "paused"}}
+			}
+			if err := r.UpdateResumedStatus(context.TODO(), ranDeployment, NewConfigInfo()); err != nil {
+				t.Fatalf("UpdateResumedStatus returned error %v", err)
+			}
+
+			if tc.wantMessage == "" {
+				if len(ranDeployment.Status.Conditions) != 0 || len(recorder.Events) != 0 {
+					t.Errorf("UpdateResumedStatus reported a drift of a NFDeployment which was not paused")
+				}
+				return
+			}
+			condition := ranDeployment.Status.Conditions[0]
+			if condition.Status != metav1.ConditionFalse || !strings.HasPrefix(condition.Message, tc.wantMessage) {
+				t.Errorf("UpdateResumedStatus set the condition %v, expected %s", condition, tc.wantMessage)
+			}
+			if event := <-recorder.Events; !strings.HasPrefix(event, "Warning Resumed") {
+				t.Errorf("UpdateResumedStatus recorded the event %q", event)
+			}
+		})
+	}
+}
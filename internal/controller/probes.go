@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+// nfProbeChecks describes what the probes of the NF container of a provider check
+type nfProbeChecks struct {
+	// startup checks the interface the NF serves is bound
+	startup string
+	// readiness checks the SCTP association of the NF towards its peer is established
+	readiness string
+	// process is the name of the softmodem process
+	process string
+}
+
+// sctpListening checks a SCTP endpoint listens on port, the 6th field of /proc/net/sctp/eps
+func sctpListening(port int) string {
+	return fmt.Sprintf("grep -qE '^ *([^ ]+ +){5}%d ' /proc/net/sctp/eps", port)
+}
+
+// sctpAssociated checks a SCTP association towards the remote port is established, the 13th field of /proc/net/sctp/assocs
+func sctpAssociated(port int) string {
+	return fmt.Sprintf("grep -qE '^ *([^ ]+ +){12}%d ' /proc/net/sctp/assocs", port)
+}
+
+// udpBound checks a UDP socket is bound to port, written in hexadecimal in /proc/net/udp and /proc/net/udp6
+func udpBound(port int) string {
+	return fmt.Sprintf("grep -q ':%04X ' /proc/net/udp /proc/net/udp6", port)
+}
+
+// processRunning checks a process named name is neither a zombie nor stopped
+func processRunning(name string) string {
+	return fmt.Sprintf("for process in /proc/[0-9]*; do grep -qx %s $process/comm 2>/dev/null && ! grep -qE '^State:\\s+[ZT]' $process/status && exit 0; done; exit 1", name)
+}
+
+// probeChecks are the probe checks of each provider: the CU-CP serves F1-C once the NG Setup with the AMF is done,
+// the CU-UP and the DU bind GTP-U and are ready once associated with the CU-CP over E1 and F1-C
+var probeChecks = map[string]nfProbeChecks{
+	"cucp.openairinterface.org": {startup: sctpListening(38472), readiness: sctpAssociated(38412), process: "nr-softmodem"},
+	"cuup.openairinterface.org": {startup: udpBound(2152), readiness: sctpAssociated(38462), process: "nr-cuup"},
+	"du.openairinterface.org":   {startup: udpBound(2152), readiness: sctpAssociated(38472), process: "nr-softmodem"},
+}
+
+var (
+	defaultStartupProbe   = corev1.Probe{PeriodSeconds: 5, TimeoutSeconds: 1, FailureThreshold: 60}
+	defaultReadinessProbe = corev1.Probe{PeriodSeconds: 10, TimeoutSeconds: 1, FailureThreshold: 3}
+	defaultLivenessProbe  = corev1.Probe{PeriodSeconds: 10, TimeoutSeconds: 5, FailureThreshold: 6}
+)
+
+// getProbe returns a probe running handler with the thresholds of the OAIConfig over the defaults of the probe
+func getProbe(handler corev1.ProbeHandler, defaultProbe corev1.Probe, thresholds *workloadnfconfig.ProbeThresholds) *corev1.Probe {
+	probe := defaultProbe
+	probe.ProbeHandler = handler
+	if thresholds == nil {
+		return &probe
+	}
+	if thresholds.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *thresholds.InitialDelaySeconds
+	}
+	if thresholds.PeriodSeconds != nil {
+		probe.PeriodSeconds = *thresholds.PeriodSeconds
+	}
+	if thresholds.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *thresholds.TimeoutSeconds
+	}
+	if thresholds.FailureThreshold != nil {
+		probe.FailureThreshold = *thresholds.FailureThreshold
+	}
+	return &probe
+}
+
+func execHandler(command string) corev1.ProbeHandler {
+	return corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"/bin/sh", "-c", command}}}
+}
+
+// addProbes adds the probes of a provider to the NF container, the liveness probe connects to the telnet server when it
+// listens on the pod IP, the address the kubelet probes; a telnet server bound to a Multus interface is not reachable there
+func addProbes(container *corev1.Container, provider string, probes *workloadnfconfig.ProbesConfig, telnet *workloadnfconfig.TelnetConfig) {
+	if probes == nil {
+		probes = &workloadnfconfig.ProbesConfig{}
+	}
+	checks, found := probeChecks[provider]
+	if probes.Disabled || !found {
+		return
+	}
+
+	container.StartupProbe = getProbe(execHandler(checks.startup), defaultStartupProbe, probes.Startup)
+	container.ReadinessProbe = getProbe(execHandler(checks.readiness), defaultReadinessProbe, probes.Readiness)
+
+	livenessHandler := execHandler(checks.startup)
+	if probes.ProcessCheck {
+		livenessHandler = execHandler(processRunning(checks.process))
+	} else if telnet != nil && telnet.ListenInterface == "" {
+		livenessHandler = corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(telnet.Port)}}
+	}
+	container.LivenessProbe = getProbe(livenessHandler, defaultLivenessProbe, probes.Liveness)
+}
+
+// getDeploymentName returns the name of the Deployment of a NFDeployment
+func getDeploymentName(ranDeployment *workloadv1alpha1.NFDeployment) string {
+	switch ranDeployment.Spec.Provider {
+	case "cucp.openairinterface.org":
+		return "oai-cu-cp"
+	case "cuup.openairinterface.org":
+		return getCuUpResourceName(ranDeployment)
+	default:
+		return getDuResourceName(ranDeployment)
+	}
+}
+
+// UpdateReadyStatus reports in the ready condition whether the pods of the NF passed their readiness probes
+func (r *RANDeploymentReconciler) UpdateReadyStatus(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) error {
+	curCondition := metav1.Condition{
+		Type:               "ready",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionFalse,
+		Reason:             "ready",
+	}
+	deploymentName := getDeploymentName(ranDeployment)
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ranDeployment.Namespace, Name: deploymentName}, deployment); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		curCondition.Message = "Deployment " + deploymentName + " not found"
+		return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if replicas > 0 && deployment.Status.ReadyReplicas >= replicas {
+		curCondition.Status = metav1.ConditionTrue
+	}
+	curCondition.Message = fmt.Sprintf("Deployment %s has %d/%d ready pods", deploymentName, deployment.Status.ReadyReplicas, replicas)
+	return r.updateStatusIfRequired(ctx, ranDeployment, curCondition)
+}
+
+// findNfOfDeployment maps a Deployment to the NFDeployments of its namespace it was generated for
+func (r *RANDeploymentReconciler) findNfOfDeployment(ctx context.Context, object client.Object) []reconcile.Request {
+	logger := log.FromContext(ctx).WithValues("Deployment", types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()})
+	nfDeploymentList := &workloadv1alpha1.NFDeploymentList{}
+	if err := r.List(ctx, nfDeploymentList, client.InNamespace(object.GetNamespace())); err != nil {
+		logger.Error(err, "Cannot list the NFDeployments")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, nfDeployment := range nfDeploymentList.Items {
+		if slices.Contains(GetSupportedProviders(), nfDeployment.Spec.Provider) && getDeploymentName(&nfDeployment) == object.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: nfDeployment.Namespace, Name: nfDeployment.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestAddProbes(t *testing.T) {
+	cases := map[string]struct {
+		provider          string
+		probes            *workloadnfconfig.ProbesConfig
+		telnet            *workloadnfconfig.TelnetConfig
+		wantStartup       string
+		wantReadiness     string
+		wantLiveness      string
+		wantTelnet        bool
+		wantFailureThresh int32
+	}{
+		"CU-CP": {
+			provider:          "cucp.openairinterface.org",
+			wantStartup:       "/proc/net/sctp/eps",
+			wantReadiness:     "38412 ' /proc/net/sctp/assocs",
+			wantLiveness:      "/proc/net/sctp/eps",
+			wantFailureThresh: 3,
+		},
+		"DU With Telnet": {
+			provider:          "du.openairinterface.org",
+			telnet:            &workloadnfconfig.TelnetConfig{Port: 9090},
+			wantStartup:       "':0868 ' /proc/net/udp",
+			wantReadiness:     "38472 ' /proc/net/sctp/assocs",
+			wantTelnet:        true,
+			wantFailureThresh: 3,
+		},
+		"DU With Telnet On A Multus Interface": {
+			provider:          "du.openairinterface.org",
+			telnet:            &workloadnfconfig.TelnetConfig{Port: 9090, ListenInterface: "o1"},
+			wantStartup:       "':0868 ' /proc/net/udp",
+			wantReadiness:     "38472 ' /proc/net/sctp/assocs",
+			wantLiveness:      "':0868 ' /proc/net/udp",
+			wantFailureThresh: 3,
+		},
+		"CU-UP Process Check": {
+			provider: "cuup.openairinterface.org",
+			probes: &workloadnfconfig.ProbesConfig{
+				ProcessCheck: true,
+				Readiness:    &workloadnfconfig.ProbeThresholds{FailureThreshold: ptr.To(int32(5))},
+			},
+			telnet:            &workloadnfconfig.TelnetConfig{Port: 9090},
+			wantStartup:       "/proc/net/udp",
+			wantReadiness:     "38462 ' /proc/net/sctp/assocs",
+			wantLiveness:      "grep -qx nr-cuup",
+			wantFailureThresh: 5,
+		},
+		"Disabled": {
+			provider: "du.openairinterface.org",
+			probes:   &workloadnfconfig.ProbesConfig{Disabled: true},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			container := corev1.Container{}
+			addProbes(&container, tc.provider, tc.probes, tc.telnet)
+			if tc.wantStartup == "" {
+				if container.StartupProbe != nil || container.ReadinessProbe != nil || container.LivenessProbe != nil {
+					t.Errorf("addProbes added probes %v", container)
+				}
+				return
+			}
+
+			if !strings.Contains(container.StartupProbe.Exec.Command[2], tc.wantStartup) {
+				t.Errorf("Startup probe %v doesn't check %s", container.StartupProbe.Exec.Command, tc.wantStartup)
+			}
+			if !strings.Contains(container.ReadinessProbe.Exec.Command[2], tc.wantReadiness) {
+				t.Errorf("Readiness probe %v doesn't check %s", container.ReadinessProbe.Exec.Command, tc.wantReadiness)
+			}
+			if container.ReadinessProbe.FailureThreshold != tc.wantFailureThresh {
+				t.Errorf("Readiness probe failure threshold is %d, expected %d", container.ReadinessProbe.FailureThreshold, tc.wantFailureThresh)
+			}
+			if tc.wantTelnet {
+				if container.LivenessProbe.TCPSocket == nil || container.LivenessProbe.TCPSocket.Port.IntVal != 9090 {
+					t.Errorf("Liveness probe %v doesn't connect to the telnet server", container.LivenessProbe)
+				}
+			} else if !strings.Contains(container.LivenessProbe.Exec.Command[2], tc.wantLiveness) {
+				t.Errorf("Liveness probe %v doesn't check %s", container.LivenessProbe.Exec.Command, tc.wantLiveness)
+			}
+		})
+	}
+}
+
+func TestProbesWithTelnetListenInterface(t *testing.T) {
+	ranDeployment, objects := loadGoldenInput(t, filepath.Join("testdata", "golden", "du-rfsim-band-n78"))
+	for _, object := range objects {
+		nfConfig, ok := object.(*unstructured.Unstructured)
+		if !ok || nfConfig.GetKind() != "NFConfig" {
+			continue
+		}
+		configRefs, _, _ := unstructured.NestedSlice(nfConfig.Object, "spec", "configRefs")
+		for _, configRef := range configRefs {
+			if configRef.(map[string]any)["kind"] == "OAIConfig" {
+				configRef.(map[string]any)["spec"].(map[string]any)["telnet"] = map[string]any{"listenInterface": "f1"}
+			}
+		}
+		if err := unstructured.SetNestedSlice(nfConfig.Object, configRefs, "spec", "configRefs"); err != nil {
+			t.Fatalf("Cannot set the telnet server: %v", err)
+		}
+	}
+	scheme := newManagerScheme()
+	r := RANDeploymentReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), Scheme: scheme}
+
+	rendered, err := r.Render(context.TODO(), ranDeployment)
+	if err != nil {
+		t.Fatalf("Render returned error %v", err)
+	}
+	container := rendered.Deployments[0].Spec.Template.Spec.Containers[0]
+	if !strings.Contains(fmt.Sprint(container.Env), "--telnetsrv.listenaddr 172.6.0.20") {
+		t.Errorf("The telnet server doesn't listen on the f1 interface: %v", container.Env)
+	}
+	// Nothing listens on the pod IP the kubelet connects to
+	if container.LivenessProbe.TCPSocket != nil || container.LivenessProbe.Exec == nil {
+		t.Errorf("Liveness probe %v connects to the telnet server", container.LivenessProbe)
+	}
+}
+
+func TestUpdateReadyStatus(t *testing.T) {
+	cases := map[string]struct {
+		deployment *appsv1.Deployment
+		wantStatus metav1.ConditionStatus
+	}{
+		"Ready": {
+			deployment: &appsv1.Deployment{Status: appsv1.DeploymentStatus{ReadyReplicas: 1}},
+			wantStatus: metav1.ConditionTrue,
+		},
+		"Not Ready": {
+			deployment: &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: ptr.To(int32(1))}},
+			wantStatus: metav1.ConditionFalse,
+		},
+		"Not Found": {
+			wantStatus: metav1.ConditionFalse,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			getCall := clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Deployment"))
+			if tc.deployment == nil {
+				getCall.Return(apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "oai-du"))
+			} else {
+				getCall.Return(nil).Run(func(args mock.Arguments) {
+					*args.Get(2).(*appsv1.Deployment) = *tc.deployment
+				})
+			}
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{Spec: workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"}}
+			r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme()}
+			if err := r.UpdateReadyStatus(context.TODO(), ranDeployment); err != nil {
+				t.Fatalf("UpdateReadyStatus returned error %v", err)
+			}
+			conditions := ranDeployment.Status.Conditions
+			if len(conditions) != 1 || conditions[0].Type != "ready" || conditions[0].Status != tc.wantStatus {
+				t.Errorf("UpdateReadyStatus set the conditions %v, expected a ready condition %s", conditions, tc.wantStatus)
+			}
+		})
+	}
+}
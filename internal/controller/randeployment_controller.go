@@ -21,22 +21,37 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	configref "github.com/nephio-project/api/references/v1alpha1"
 	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+// finalizerName is the finalizer deleting the resources of a NFDeployment
+const finalizerName = "batch.tutorial.kubebuilder.io/finalizer"
+
+var (
+	errMissingMandatoryKinds = fmt.Errorf("not all mandatory Kinds available")
+	errUnsupportedAPIVersion = fmt.Errorf("not supported API version")
+	errInvalidPlmn           = fmt.Errorf("PLMN without PLMN info, slice or slice differentiator")
 )
 
 func GetSupportedProviders() []string {
@@ -60,15 +75,22 @@ func NewConfigInfo() *ConfigInfo {
 type RANDeploymentReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// DryRun makes the reconciler report the changes it would make to the resources of all NFDeployments instead of making them
+	DryRun bool
+	// Recorder records the events of the NFDeployments, none are recorded when nil
+	Recorder record.EventRecorder
+	// LogReader reads the logs of the NF containers to report their fatal errors, they are not read when nil
+	LogReader PodLogReader
 }
 
 // Interface definition for NfResource
 type NfResource interface {
+	GetNetworkAttachmentDefinitions(logr.Logger, *workloadv1alpha1.NFDeployment, *ConfigInfo) ([]*unstructured.Unstructured, error)
 	GetServiceAccount() []*corev1.ServiceAccount
 	GetConfigMap(logr.Logger, *workloadv1alpha1.NFDeployment, *ConfigInfo) []*corev1.ConfigMap
-	createNetworkAttachmentDefinitionNetworks(string, *workloadv1alpha1.NFDeploymentSpec) (string, error)
+	createNetworkAttachmentDefinitionNetworks(string, *workloadv1alpha1.NFDeploymentSpec, []workloadnfconfig.NetworkConfig) (string, error)
 	GetDeployment(logr.Logger, *workloadv1alpha1.NFDeployment, *ConfigInfo) []*appsv1.Deployment
-	GetService() []*corev1.Service
+	GetService(logr.Logger, *workloadv1alpha1.NFDeployment, *ConfigInfo) []*corev1.Service
 }
 
 func (r *RANDeploymentReconciler) CreateAll(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, nfResource NfResource, configInfo *ConfigInfo) []error {
@@ -77,43 +99,74 @@ func (r *RANDeploymentReconciler) CreateAll(ctx context.Context, ranDeployment *
 	outErrorList := []error{}
 	var err error
 	namespaceProvided := ranDeployment.Namespace
+	networkAttachmentDefinitions, err := nfResource.GetNetworkAttachmentDefinitions(logger, ranDeployment, configInfo)
+	if err != nil {
+		// The pods of the Deployment would wait for the networks forever, nothing is created
+		logger.Error(err, "Cannot render the NetworkAttachmentDefinitions")
+		r.recordEvent(ranDeployment, corev1.EventTypeWarning, ReasonRenderFailed, "Cannot render the NetworkAttachmentDefinitions: %v", err)
+		return []error{err}
+	}
+	for _, resource := range networkAttachmentDefinitions {
+		err = r.Create(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "create", "NetworkAttachmentDefinition", resource, err)
+		if err != nil {
+			outErrorList = append(outErrorList, err)
+			logger.Error(err, "Error During Creating resource of GetNetworkAttachmentDefinitions()")
+		}
+	}
+
 	for _, resource := range nfResource.GetServiceAccount() {
 		if resource.Namespace == "" {
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Create(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "create", "ServiceAccount", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Creating resource of GetServiceAccount()")
 		}
 	}
 
-	for _, resource := range nfResource.GetConfigMap(logger, ranDeployment, configInfo) {
+	renderStart := time.Now()
+	configMaps := nfResource.GetConfigMap(logger, ranDeployment, configInfo)
+	deployments := nfResource.GetDeployment(logger, ranDeployment, configInfo)
+	observeRenderDuration(ranDeployment, renderStart)
+	// The resources log their errors and return nil
+	if configMaps == nil {
+		r.recordEvent(ranDeployment, corev1.EventTypeWarning, ReasonRenderFailed, "Cannot render the ConfigMap, see the logs of the controller")
+	}
+	if deployments == nil {
+		r.recordEvent(ranDeployment, corev1.EventTypeWarning, ReasonRenderFailed, "Cannot render the Deployment, see the logs of the controller")
+	}
+	for _, resource := range configMaps {
 		if resource.Namespace == "" {
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Create(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "create", "ConfigMap", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Creating resource of GetConfigMap()")
 		}
 	}
 
-	for _, resource := range nfResource.GetDeployment(logger, ranDeployment, configInfo) {
+	for _, resource := range deployments {
 		if resource.Namespace == "" {
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Create(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "create", "Deployment", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Creating resource of GetDeployment()")
 		}
 	}
-	for _, resource := range nfResource.GetService() {
+	for _, resource := range nfResource.GetService(logger, ranDeployment, configInfo) {
 		if resource.Namespace == "" {
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Create(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "create", "Service", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Creating resource of GetService()")
@@ -129,11 +182,26 @@ func (r *RANDeploymentReconciler) DeleteAll(ctx context.Context, ranDeployment *
 	outErrorList := []error{}
 	var err error
 	namespaceProvided := ranDeployment.Namespace
+	networkAttachmentDefinitions, err := nfResource.GetNetworkAttachmentDefinitions(logger, ranDeployment, configInfo)
+	if err != nil {
+		outErrorList = append(outErrorList, err)
+		logger.Error(err, "Cannot render the NetworkAttachmentDefinitions to delete")
+	}
+	for _, resource := range networkAttachmentDefinitions {
+		err = r.Delete(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "delete", "NetworkAttachmentDefinition", resource, err)
+		if err != nil {
+			outErrorList = append(outErrorList, err)
+			logger.Error(err, "Error During Deleting resource of GetNetworkAttachmentDefinitions()")
+		}
+	}
+
 	for _, resource := range nfResource.GetServiceAccount() {
 		if resource.Namespace == "" {
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Delete(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "delete", "ServiceAccount", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Deleting resource of GetServiceAccount()")
@@ -145,6 +213,7 @@ func (r *RANDeploymentReconciler) DeleteAll(ctx context.Context, ranDeployment *
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Delete(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "delete", "ConfigMap", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Deleting resource of GetConfigMap()")
@@ -156,6 +225,7 @@ func (r *RANDeploymentReconciler) DeleteAll(ctx context.Context, ranDeployment *
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Delete(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "delete", "Deployment", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Deleting resource of GetDeployment()")
@@ -163,11 +233,12 @@ func (r *RANDeploymentReconciler) DeleteAll(ctx context.Context, ranDeployment *
 
 	}
 
-	for _, resource := range nfResource.GetService() {
+	for _, resource := range nfResource.GetService(logger, ranDeployment, configInfo) {
 		if resource.Namespace == "" {
 			resource.Namespace = namespaceProvided
 		}
 		err = r.Delete(ctx, resource)
+		r.recordResourceEvent(ranDeployment, "delete", "Service", resource, err)
 		if err != nil {
 			outErrorList = append(outErrorList, err)
 			logger.Error(err, "Error During Deleting resource of GetService()")
@@ -222,12 +293,18 @@ func (r *RANDeploymentReconciler) GetConfigs(ctx context.Context, ranDeployment
 			}
 
 			if !CheckMandatoryKinds(configInfo.ConfigSelfInfo) {
-				err := fmt.Errorf("not all mandatory Kinds available")
+				err := errMissingMandatoryKinds
 				logger.Error(err, "Config for Self get error")
 				return configInfo, err
 			}
 		default:
-			err := fmt.Errorf("not supported API version %q", configItem.APIVersion)
+			err := fmt.Errorf("%w %q", errUnsupportedAPIVersion, configItem.APIVersion)
+			logger.Error(err, "Config for Self get error")
+			return configInfo, err
+		}
+	}
+	if _, found := configInfo.ConfigSelfInfo["PLMN"]; found {
+		if err := checkPlmn(configInfo.ConfigSelfInfo); err != nil {
 			logger.Error(err, "Config for Self get error")
 			return configInfo, err
 		}
@@ -240,8 +317,21 @@ For status:
 Accepted Condition-Type (CamelCased) are:
  1. invalidProvider
  2. invalidConfigInfo
- 3. resourceCreation
- 4. resourceDeletion
+ 3. invalidCellConfig
+ 4. missingPeer (only once a peer was missing from the Config refs)
+ 5. resourceCreation
+ 6. resourceDeletion
+ 7. invalidCuUpId (CU-UP only)
+ 8. invalidDuId (DU only)
+ 9. attachedDUs (CU-CP only)
+ 10. attachedCUUPs (CU-CP only)
+ 11. e2AgentConfigured (only when the E2 agent is enabled in OAIConfig)
+ 12. ready
+ 13. dryRun (only when dry-run is enabled)
+ 14. paused (only once the NFDeployment was paused)
+ 15. softmodemFailure (only once a fatal error was found in the logs of the NF container)
+ 16. podSecurity (only once the namespace enforced a Pod Security level rejecting the pods)
+ 17. e2Setup (only when the E2 agent is enabled in OAIConfig and the logs of the NF container are read)
 */
 func (r *RANDeploymentReconciler) updateStatusIfRequired(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, curCondition metav1.Condition) error {
 
@@ -268,6 +358,10 @@ func (r *RANDeploymentReconciler) updateStatusIfRequired(ctx context.Context, ra
 //+kubebuilder:rbac:groups=workload.nephio.org,resources=randeployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=workload.nephio.org,resources=randeployments/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=workload.nephio.org,resources=randeployments/finalizers,verbs=update
+//+kubebuilder:rbac:groups=workload.nephio.org,resources=nfdeployments,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+//+kubebuilder:rbac:groups=k8s.cni.cncf.io,resources=network-attachment-definitions,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -278,21 +372,27 @@ func (r *RANDeploymentReconciler) updateStatusIfRequired(ctx context.Context, ra
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.15.0/pkg/reconcile
-func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	logger := log.FromContext(ctx).WithValues("RANDeployment", req.NamespacedName)
 	logger.Info("Reconcile for RANDeployment")
 
 	instance := &workloadv1alpha1.NFDeployment{}
-	err := r.Get(ctx, req.NamespacedName, instance)
+	err = r.Get(ctx, req.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("RANDeployment resource not found, ignoring because object must be deleted")
+			managedNfDeployments.remove(req.NamespacedName)
+			deleteNfMetrics(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 
 		logger.Error(err, "Failed to get RANDeployment")
 		return ctrl.Result{}, err
 	}
+	defer func() {
+		recordReconcile(instance, result, err)
+		managedNfDeployments.update(instance)
+	}()
 
 	if !slices.Contains(GetSupportedProviders(), instance.Spec.Provider) {
 		logger.Info("Reconcile called for not supported provider", "Provider", instance.Spec.Provider)
@@ -308,6 +408,9 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			Reason:             "invalidProvider",
 			Message:            instance.Spec.Provider + " Not supported| Supported Providers are " + supportedProvider,
 		}
+		if !meta.IsStatusConditionFalse(instance.Status.Conditions, curCondition.Type) {
+			r.recordEvent(instance, corev1.EventTypeWarning, ReasonInvalidProvider, "Provider %q is not supported", instance.Spec.Provider)
+		}
 		err = r.updateStatusIfRequired(ctx, instance, curCondition)
 		if err != nil {
 			logger.Error(err, " | Unable to update status with type: invalidProvider")
@@ -317,9 +420,20 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 	logger.Info("RANDeployment", "RANDeployment CR", instance.Spec)
 
+	if isPaused(instance) {
+		logger.Info("Reconciliation is paused by the " + PausedAnnotation + " annotation")
+		if err := r.ReconcilePaused(ctx, instance); err != nil {
+			logger.Error(err, " | Unable to update status with type: paused")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
 	configInfo, err := r.GetConfigs(ctx, instance)
 	if err != nil || configInfo == nil {
 		logger.Error(err, "Failed to get required ConfigInfo")
+		r.recordEvent(instance, corev1.EventTypeWarning, ReasonConfigResolutionFailed, "Cannot resolve the configs: %v", err)
+		recordConfigResolutionFailure(instance, err)
 		curCondition := metav1.Condition{
 			Type:               "invalidConfigInfo",
 			LastTransitionTime: metav1.Time{Time: time.Now()},
@@ -334,13 +448,110 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 		return ctrl.Result{}, err
 	}
-	// name of our custom finalizer
-	myFinalizerName := "batch.tutorial.kubebuilder.io/finalizer"
+	if instance.DeletionTimestamp.IsZero() {
+		if err := r.UpdateResumedStatus(ctx, instance, configInfo); err != nil {
+			logger.Error(err, " | Unable to update status with type: paused")
+		}
+		if r.isDryRun(instance) {
+			// Nothing is created, the differences of the resources with the live ones are reported
+			if err := r.UpdateDryRunStatus(ctx, instance, configInfo); err != nil {
+				logger.Error(err, " | Unable to update status with type: dryRun")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+		if meta.IsStatusConditionTrue(instance.Status.Conditions, "dryRun") {
+			curCondition := metav1.Condition{
+				Type:               "dryRun",
+				LastTransitionTime: metav1.Time{Time: time.Now()},
+				Status:             metav1.ConditionFalse,
+				Reason:             "dryRun",
+				Message:            "Dry-run is disabled, the resources are created",
+			}
+			if err := r.updateStatusIfRequired(ctx, instance, curCondition); err != nil {
+				logger.Error(err, " | Unable to update status with type: dryRun")
+			}
+		}
+	}
 	// examine DeletionTimestamp to determine if object is under deletion
 	if instance.DeletionTimestamp.IsZero() {
 		// Adding a Finaliser also adds the DeletionTimestamp while deleting
-		if !controllerutil.ContainsFinalizer(instance, myFinalizerName) {
+		if !controllerutil.ContainsFinalizer(instance, finalizerName) {
 			// Assumed to be called only during CR-Creation
+			if err := r.CheckCellsOfCuCp(ctx, instance, configInfo); err != nil {
+				logger.Error(err, "Cells conflict with the DUs of the same CU-CP")
+				curCondition := metav1.Condition{
+					Type:               "invalidCellConfig",
+					LastTransitionTime: metav1.Time{Time: time.Now()},
+					Status:             metav1.ConditionFalse,
+					Reason:             "invalidCellConfig",
+					Message:            "Cells conflict with the DUs of the same CU-CP | Error: " + err.Error(),
+				}
+				if statusErr := r.updateStatusIfRequired(ctx, instance, curCondition); statusErr != nil {
+					logger.Error(statusErr, " | Unable to update status with type: invalidCellConfig")
+				}
+				return ctrl.Result{}, err
+			}
+			r.recordEvent(instance, corev1.EventTypeNormal, ReasonConfigResolved, "Resolved %d Config refs and %d NFConfig kinds", countConfigRefs(configInfo), len(configInfo.ConfigSelfInfo))
+			if missingPeers := getMissingPeers(logger, instance, configInfo); len(missingPeers) > 0 {
+				// Nothing is created until the peers are added to the Config refs, the resources would not work without them
+				for _, missingPeer := range missingPeers {
+					r.recordEvent(instance, corev1.EventTypeWarning, ReasonMissingPeer, "Missing peer, %s", missingPeer)
+				}
+				err := fmt.Errorf("missing peers: %s", strings.Join(missingPeers, ", "))
+				logger.Error(err, "Peers not found in the Config refs")
+				curCondition := metav1.Condition{
+					Type:               "missingPeer",
+					LastTransitionTime: metav1.Time{Time: time.Now()},
+					Status:             metav1.ConditionFalse,
+					Reason:             "missingPeer",
+					Message:            "Peers not found in the Config refs | Error: " + err.Error(),
+				}
+				if statusErr := r.updateStatusIfRequired(ctx, instance, curCondition); statusErr != nil {
+					logger.Error(statusErr, " | Unable to update status with type: missingPeer")
+				}
+				return ctrl.Result{}, err
+			}
+			if meta.FindStatusCondition(instance.Status.Conditions, "missingPeer") != nil {
+				curCondition := metav1.Condition{
+					Type:               "missingPeer",
+					LastTransitionTime: metav1.Time{Time: time.Now()},
+					Status:             metav1.ConditionTrue,
+					Reason:             "missingPeer",
+					Message:            "All the peers are in the Config refs",
+				}
+				if err := r.updateStatusIfRequired(ctx, instance, curCondition); err != nil {
+					logger.Error(err, " | Unable to update status with type: missingPeer")
+				}
+			}
+			if err := r.CheckPodSecurity(ctx, instance, configInfo); err != nil {
+				// Nothing is created, the pods would be rejected by the Pod Security admission
+				r.recordEvent(instance, corev1.EventTypeWarning, ReasonPodSecurityViolation, "Pods rejected by the namespace: %v", err)
+				logger.Error(err, "Pod Security level of the namespace rejects the pods")
+				curCondition := metav1.Condition{
+					Type:               "podSecurity",
+					LastTransitionTime: metav1.Time{Time: time.Now()},
+					Status:             metav1.ConditionFalse,
+					Reason:             "podSecurity",
+					Message:            "Pod Security level of the namespace rejects the pods | Error: " + err.Error(),
+				}
+				if statusErr := r.updateStatusIfRequired(ctx, instance, curCondition); statusErr != nil {
+					logger.Error(statusErr, " | Unable to update status with type: podSecurity")
+				}
+				return ctrl.Result{}, err
+			}
+			if meta.FindStatusCondition(instance.Status.Conditions, "podSecurity") != nil {
+				curCondition := metav1.Condition{
+					Type:               "podSecurity",
+					LastTransitionTime: metav1.Time{Time: time.Now()},
+					Status:             metav1.ConditionTrue,
+					Reason:             "podSecurity",
+					Message:            "Pod Security level of the namespace admits the pods",
+				}
+				if err := r.updateStatusIfRequired(ctx, instance, curCondition); err != nil {
+					logger.Error(err, " | Unable to update status with type: podSecurity")
+				}
+			}
 			var errList []error
 			switch resourceType := instance.Spec.Provider; resourceType {
 			case "cucp.openairinterface.org":
@@ -350,12 +561,40 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				logger.Info("--- CUCP Created")
 			case "cuup.openairinterface.org":
 				logger.Info("--- Creation for CUUP")
-				cuupResource := CuUpResources{}
+				if err := r.AllocateCuUpId(ctx, instance); err != nil {
+					logger.Error(err, "Cannot allocate the gNB_CU_UP_ID")
+					curCondition := metav1.Condition{
+						Type:               "invalidCuUpId",
+						LastTransitionTime: metav1.Time{Time: time.Now()},
+						Status:             metav1.ConditionFalse,
+						Reason:             "invalidCuUpId",
+						Message:            "Cannot allocate the gNB_CU_UP_ID | Error: " + err.Error(),
+					}
+					if statusErr := r.updateStatusIfRequired(ctx, instance, curCondition); statusErr != nil {
+						logger.Error(statusErr, " | Unable to update status with type: invalidCuUpId")
+					}
+					return ctrl.Result{}, err
+				}
+				cuupResource := CuUpResources{Name: getCuUpResourceName(instance)}
 				errList = r.CreateAll(ctx, instance, cuupResource, configInfo)
 				logger.Info("--- CUUP Created")
 			case "du.openairinterface.org":
 				logger.Info("--- Creation for DU")
-				duResource := DuResources{}
+				if err := r.AllocateDuId(ctx, instance); err != nil {
+					logger.Error(err, "Cannot allocate the gNB_DU_ID")
+					curCondition := metav1.Condition{
+						Type:               "invalidDuId",
+						LastTransitionTime: metav1.Time{Time: time.Now()},
+						Status:             metav1.ConditionFalse,
+						Reason:             "invalidDuId",
+						Message:            "Cannot allocate the gNB_DU_ID | Error: " + err.Error(),
+					}
+					if statusErr := r.updateStatusIfRequired(ctx, instance, curCondition); statusErr != nil {
+						logger.Error(statusErr, " | Unable to update status with type: invalidDuId")
+					}
+					return ctrl.Result{}, err
+				}
+				duResource := DuResources{Name: getDuResourceName(instance)}
 				errList = r.CreateAll(ctx, instance, duResource, configInfo)
 				logger.Info("--- DU Created")
 
@@ -389,14 +628,54 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				logger.Error(err, " | Unable to update status with type: resourceCreation")
 			}
 
-			controllerutil.AddFinalizer(instance, myFinalizerName)
+			if instance.Spec.Provider == "du.openairinterface.org" {
+				// Recorded before the finalizer, a DU with the finalizer but without the annotation has legacy resources
+				if instance.Annotations == nil {
+					instance.Annotations = map[string]string{}
+				}
+				instance.Annotations[DuResourceNameAnnotation] = getDuResourceName(instance)
+			}
+			controllerutil.AddFinalizer(instance, finalizerName)
 			if err := r.Update(ctx, instance); err != nil {
 				return ctrl.Result{}, err
 			}
 		}
+		if instance.Spec.Provider == "du.openairinterface.org" {
+			if err := r.deleteLegacyDuTelnetService(ctx, instance); err != nil {
+				logger.Error(err, "Cannot delete the legacy telnet Service "+legacyDuTelnetServiceName)
+			}
+		}
+		if err := r.UpdateE2AgentStatus(ctx, instance, configInfo); err != nil {
+			logger.Error(err, " | Unable to update status with type: e2AgentConfigured")
+		}
+		if err := r.UpdateE2SetupStatus(ctx, instance, configInfo); err != nil {
+			logger.Error(err, " | Unable to update status with type: e2Setup")
+		}
+		if err := r.UpdateReadyStatus(ctx, instance); err != nil {
+			logger.Error(err, " | Unable to update status with type: ready")
+		}
+		if err := r.UpdateSoftmodemFailureStatus(ctx, instance); err != nil {
+			logger.Error(err, " | Unable to update status with type: softmodemFailure")
+		}
+		if instance.Spec.Provider == "cucp.openairinterface.org" {
+			if err := r.UpdateAttachedDus(ctx, instance); err != nil {
+				logger.Error(err, " | Unable to update status with type: attachedDUs")
+			}
+			if err := r.UpdateAttachedCuUps(ctx, instance); err != nil {
+				logger.Error(err, " | Unable to update status with type: attachedCUUPs")
+			}
+		}
 	} else {
 		// The object is assumed to be deleted
-		if controllerutil.ContainsFinalizer(instance, myFinalizerName) {
+		if controllerutil.ContainsFinalizer(instance, finalizerName) && r.isDryRun(instance) {
+			// Nothing is deleted, the finalizer holds the deletion until the dry-run is disabled
+			if err := r.UpdateDryRunDeletionStatus(ctx, instance, configInfo); err != nil {
+				logger.Error(err, " | Unable to update status with type: dryRun")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+		if controllerutil.ContainsFinalizer(instance, finalizerName) {
 			var errList []error
 			switch resourceType := instance.Spec.Provider; resourceType {
 			case "cucp.openairinterface.org":
@@ -406,13 +685,19 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 				logger.Info("--- CUCP Deleted")
 			case "cuup.openairinterface.org":
 				logger.Info("--- Deletion for CUUP")
-				cuupResource := CuUpResources{}
+				cuupResource := CuUpResources{Name: getCuUpResourceName(instance)}
 				errList = r.DeleteAll(ctx, instance, cuupResource, configInfo)
 				logger.Info("--- CUUP Deleted")
 			case "du.openairinterface.org":
 				logger.Info("--- Deletion for DU")
-				duResource := DuResources{}
+				duResource := DuResources{Name: getDuResourceName(instance)}
 				errList = r.DeleteAll(ctx, instance, duResource, configInfo)
+				if duResource.Name == legacyDuResourceName {
+					// The telnet Service of the legacy DU is not one of the resources of this release
+					if err := r.deleteLegacyDuTelnetService(ctx, instance); err != nil {
+						errList = append(errList, err)
+					}
+				}
 				logger.Info("--- DU Deleted")
 
 			}
@@ -447,10 +732,12 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			}
 
 			// remove our finalizer from the list and update it.
-			controllerutil.RemoveFinalizer(instance, myFinalizerName)
+			controllerutil.RemoveFinalizer(instance, finalizerName)
 			if err := r.Update(ctx, instance); err != nil {
+				r.recordEvent(instance, corev1.EventTypeWarning, ReasonUpdateFailed, "Cannot remove the finalizer: %v", err)
 				return ctrl.Result{}, err
 			}
+			r.recordEvent(instance, corev1.EventTypeNormal, ReasonFinalizerRemoved, "Removed the finalizer %s", finalizerName)
 		}
 
 		// Stop reconciliation as the item is being deleted
@@ -464,5 +751,9 @@ func (r *RANDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 func (r *RANDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&workloadv1alpha1.NFDeployment{}).
+		// DU and CU-UP changes are reflected in the status of their CU-CP
+		Watches(&workloadv1alpha1.NFDeployment{}, handler.EnqueueRequestsFromMapFunc(r.findCuCpOfNf)).
+		// The readiness of the pods of the NFs is reflected in their ready condition
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.findNfOfDeployment)).
 		Complete(r)
 }
@@ -0,0 +1,375 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// envtestTimeout bounds the wait for the manager to reconcile a change
+	envtestTimeout  = 30 * time.Second
+	envtestInterval = 250 * time.Millisecond
+)
+
+// eventually polls condition until it returns no error, and fails the test with its last error on timeout
+func eventually(t *testing.T, description string, condition func(ctx context.Context) error) {
+	t.Helper()
+	var lastErr error
+	err := wait.PollUntilContextTimeout(context.Background(), envtestInterval, envtestTimeout, true, func(ctx context.Context) (bool, error) {
+		lastErr = condition(ctx)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Timed out waiting for %s: %v", description, lastErr)
+	}
+}
+
+// createNamespace creates a namespace per test, the namespaces are never deleted by envtest
+func createNamespace(t *testing.T, k8sClient client.Client, name string) {
+	t.Helper()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if err := k8sClient.Create(context.TODO(), namespace); err != nil {
+		t.Fatalf("Cannot create the namespace %s: %v", name, err)
+	}
+}
+
+// applyFixtures creates in namespace the objects of the YAML files of testdata/envtest
+func applyFixtures(t *testing.T, k8sClient client.Client, namespace string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join("testdata", "envtest", name))
+		if err != nil {
+			t.Fatalf("Cannot read the fixture %s: %v", name, err)
+		}
+		for _, document := range strings.Split(string(content), "\n---\n") {
+			object := &unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(document), &object.Object); err != nil {
+				t.Fatalf("Cannot parse the fixture %s: %v", name, err)
+			}
+			object.SetNamespace(namespace)
+			if err := k8sClient.Create(context.TODO(), object); err != nil {
+				t.Fatalf("Cannot create %s %s of the fixture %s: %v", object.GetKind(), object.GetName(), name, err)
+			}
+		}
+	}
+}
+
+// checkCondition returns an error until the NFDeployment has the condition with status, and a message containing message
+func checkCondition(ctx context.Context, k8sClient client.Client, key types.NamespacedName, conditionType string, status metav1.ConditionStatus, message string) error {
+	ranDeployment := &workloadv1alpha1.NFDeployment{}
+	if err := k8sClient.Get(ctx, key, ranDeployment); err != nil {
+		return err
+	}
+	condition := meta.FindStatusCondition(ranDeployment.Status.Conditions, conditionType)
+	if condition == nil {
+		return fmt.Errorf("no %s condition in %v", conditionType, ranDeployment.Status.Conditions)
+	}
+	if condition.Status != status || !strings.Contains(condition.Message, message) {
+		return fmt.Errorf("%s condition is %s %q, expected %s %q", conditionType, condition.Status, condition.Message, status, message)
+	}
+	return nil
+}
+
+// checkEvent returns an error until an event with reason was recorded on the NFDeployment
+func checkEvent(ctx context.Context, k8sClient client.Client, key types.NamespacedName, reason string) error {
+	events := &corev1.EventList{}
+	if err := k8sClient.List(ctx, events, client.InNamespace(key.Namespace)); err != nil {
+		return err
+	}
+	reasons := []string{}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name != key.Name {
+			continue
+		}
+		if event.Reason == reason {
+			return nil
+		}
+		reasons = append(reasons, event.Reason)
+	}
+	return fmt.Errorf("no %s event, recorded %v", reason, reasons)
+}
+
+// checkResources returns an error until each object exists, or until none exists when absent is set
+func checkResources(ctx context.Context, k8sClient client.Client, namespace string, objects map[string]client.Object, absent bool) error {
+	for name, object := range objects {
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, object)
+		if absent && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("%T %s is not deleted: %v", object, name, err)
+		}
+		if !absent && err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enableE2Agent adds an E2 agent to the OAIConfig of a NFConfig
+func enableE2Agent(nfConfig *workloadv1alpha1.NFConfig, ricAddress string) error {
+	for index, configRef := range nfConfig.Spec.ConfigRefs {
+		var config map[string]any
+		if err := json.Unmarshal(configRef.Raw, &config); err != nil {
+			return err
+		}
+		if config["kind"] != "OAIConfig" {
+			continue
+		}
+		config["spec"].(map[string]any)["e2Agent"] = map[string]any{"ricAddress": ricAddress}
+		raw, err := json.Marshal(config)
+		if err != nil {
+			return err
+		}
+		nfConfig.Spec.ConfigRefs[index].Raw = raw
+		return nil
+	}
+	return fmt.Errorf("no OAIConfig in NFConfig %s", nfConfig.Name)
+}
+
+func TestEnvtestLifecycle(t *testing.T) {
+	cases := map[string]struct {
+		namespace string
+		fixture   string
+		nfName    string
+		configMap string
+		resources func() map[string]client.Object
+	}{
+		"CU-CP": {
+			namespace: "lifecycle-cucp",
+			fixture:   "cucp.yaml",
+			nfName:    "cucp-regional",
+			configMap: "oai-cu-cp-configmap",
+			resources: func() map[string]client.Object {
+				return map[string]client.Object{
+					"oai-cu-cp-sa":        &corev1.ServiceAccount{},
+					"oai-cu-cp-configmap": &corev1.ConfigMap{},
+					"oai-cu-cp":           &appsv1.Deployment{},
+				}
+			},
+		},
+		"CU-UP": {
+			namespace: "lifecycle-cuup",
+			fixture:   "cuup.yaml",
+			nfName:    "cuup-regional",
+			configMap: "oai-cu-up-configmap",
+			resources: func() map[string]client.Object {
+				return map[string]client.Object{
+					"oai-cu-up-sa":        &corev1.ServiceAccount{},
+					"oai-cu-up-configmap": &corev1.ConfigMap{},
+					"oai-cu-up":           &appsv1.Deployment{},
+				}
+			},
+		},
+		"DU": {
+			namespace: "lifecycle-du",
+			fixture:   "du.yaml",
+			nfName:    "du-edge",
+			configMap: "oai-du-du-edge-configmap",
+			resources: func() map[string]client.Object {
+				return map[string]client.Object{
+					"oai-du-du-edge-sa":        &corev1.ServiceAccount{},
+					"oai-du-du-edge-configmap": &corev1.ConfigMap{},
+					"oai-du-du-edge":           &appsv1.Deployment{},
+					"oai-du-du-edge-telnet":    &corev1.Service{},
+				}
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			k8sClient := startEnvtest(t)
+			createNamespace(t, k8sClient, tc.namespace)
+			applyFixtures(t, k8sClient, tc.namespace, "peers.yaml", "nfconfig.yaml", tc.fixture)
+			key := types.NamespacedName{Namespace: tc.namespace, Name: tc.nfName}
+
+			// Create
+			eventually(t, "the creation of the resources", func(ctx context.Context) error {
+				if err := checkCondition(ctx, k8sClient, key, "resourceCreation", metav1.ConditionTrue, "All resources created successfully"); err != nil {
+					return err
+				}
+				return checkResources(ctx, k8sClient, tc.namespace, tc.resources(), false)
+			})
+			ranDeployment := &workloadv1alpha1.NFDeployment{}
+			if err := k8sClient.Get(context.TODO(), key, ranDeployment); err != nil {
+				t.Fatalf("Cannot get the NFDeployment: %v", err)
+			}
+			if !controllerutil.ContainsFinalizer(ranDeployment, finalizerName) {
+				t.Errorf("The NFDeployment has no finalizer %s", finalizerName)
+			}
+			eventually(t, "the ConfigResolved event", func(ctx context.Context) error {
+				return checkEvent(ctx, k8sClient, key, ReasonConfigResolved)
+			})
+			eventually(t, "the Created event", func(ctx context.Context) error {
+				return checkEvent(ctx, k8sClient, key, ReasonCreated)
+			})
+
+			// Config change: the NFConfigs are not watched, the NFDeployment is annotated to be reconciled again.
+			// The status follows the new NFConfig but the existing resources are not updated, the gnb.conf
+			// keeps the configuration they were created with
+			configMap := &corev1.ConfigMap{}
+			if err := k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: tc.namespace, Name: tc.configMap}, configMap); err != nil {
+				t.Fatalf("Cannot get the ConfigMap: %v", err)
+			}
+			gnbConf := configMap.Data["gnb.conf"]
+			nfConfig := &workloadv1alpha1.NFConfig{}
+			if err := k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: tc.namespace, Name: "oai-ran"}, nfConfig); err != nil {
+				t.Fatalf("Cannot get the NFConfig: %v", err)
+			}
+			if err := enableE2Agent(nfConfig, "10.0.0.10"); err != nil {
+				t.Fatalf("Cannot enable the E2 agent: %v", err)
+			}
+			if err := k8sClient.Update(context.TODO(), nfConfig); err != nil {
+				t.Fatalf("Cannot update the NFConfig: %v", err)
+			}
+			eventually(t, "the annotation of the NFDeployment", func(ctx context.Context) error {
+				ranDeployment := &workloadv1alpha1.NFDeployment{}
+				if err := k8sClient.Get(ctx, key, ranDeployment); err != nil {
+					return err
+				}
+				metav1.SetMetaDataAnnotation(&ranDeployment.ObjectMeta, "envtest.nephio.org/config-changed", "true")
+				return k8sClient.Update(ctx, ranDeployment)
+			})
+			eventually(t, "the e2AgentConfigured condition", func(ctx context.Context) error {
+				return checkCondition(ctx, k8sClient, key, "e2AgentConfigured", metav1.ConditionTrue, "near-RT RIC 10.0.0.10:36421")
+			})
+			if err := k8sClient.Get(context.TODO(), types.NamespacedName{Namespace: tc.namespace, Name: tc.configMap}, configMap); err != nil {
+				t.Fatalf("Cannot get the ConfigMap: %v", err)
+			}
+			if configMap.Data["gnb.conf"] != gnbConf || strings.Contains(configMap.Data["gnb.conf"], "e2_agent") {
+				t.Errorf("The gnb.conf of the existing ConfigMap was re-rendered after the config change:\n%s", configMap.Data["gnb.conf"])
+			}
+
+			// Delete
+			if err := k8sClient.Delete(context.TODO(), ranDeployment); err != nil {
+				t.Fatalf("Cannot delete the NFDeployment: %v", err)
+			}
+			eventually(t, "the removal of the finalizer", func(ctx context.Context) error {
+				err := k8sClient.Get(ctx, key, &workloadv1alpha1.NFDeployment{})
+				if !apierrors.IsNotFound(err) {
+					return fmt.Errorf("the NFDeployment is not deleted: %v", err)
+				}
+				return nil
+			})
+			eventually(t, "the deletion of the resources", func(ctx context.Context) error {
+				return checkResources(ctx, k8sClient, tc.namespace, tc.resources(), true)
+			})
+			eventually(t, "the Deleted event", func(ctx context.Context) error {
+				return checkEvent(ctx, k8sClient, key, ReasonDeleted)
+			})
+		})
+	}
+}
+
+func TestEnvtestMissingPeer(t *testing.T) {
+	cases := map[string]struct {
+		fixtures  []string
+		name      string
+		resources map[string]client.Object
+	}{
+		"DU Without CU-CP": {
+			fixtures: []string{"nfconfig.yaml", "du-without-cucp.yaml"},
+			name:     "du-edge",
+			resources: map[string]client.Object{
+				"oai-du-du-edge-sa":        &corev1.ServiceAccount{},
+				"oai-du-du-edge-configmap": &corev1.ConfigMap{},
+				"oai-du-du-edge":           &appsv1.Deployment{},
+			},
+		},
+		"CU-CP Without AMF": {
+			fixtures: []string{"nfconfig.yaml", "cucp-without-amf.yaml"},
+			name:     "cucp-regional",
+			resources: map[string]client.Object{
+				"oai-cu-cp-sa":        &corev1.ServiceAccount{},
+				"oai-cu-cp-configmap": &corev1.ConfigMap{},
+				"oai-cu-cp":           &appsv1.Deployment{},
+			},
+		},
+	}
+
+	k8sClient := startEnvtest(t)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			namespace := "missing-peer-" + strings.ReplaceAll(strings.ToLower(name), " ", "-")
+			createNamespace(t, k8sClient, namespace)
+			applyFixtures(t, k8sClient, namespace, tc.fixtures...)
+			key := types.NamespacedName{Namespace: namespace, Name: tc.name}
+
+			eventually(t, "the MissingPeer event", func(ctx context.Context) error {
+				return checkEvent(ctx, k8sClient, key, ReasonMissingPeer)
+			})
+			eventually(t, "the missingPeer condition", func(ctx context.Context) error {
+				return checkCondition(ctx, k8sClient, key, "missingPeer", metav1.ConditionFalse, "missing peers")
+			})
+			// Nothing is created without the peers
+			if err := checkResources(context.TODO(), k8sClient, namespace, tc.resources, true); err != nil {
+				t.Errorf("Resources were created without the peers: %v", err)
+			}
+		})
+	}
+}
+
+func TestEnvtestInvalidProvider(t *testing.T) {
+	k8sClient := startEnvtest(t)
+	createNamespace(t, k8sClient, "invalid-provider")
+	applyFixtures(t, k8sClient, "invalid-provider", "invalid-provider.yaml")
+	key := types.NamespacedName{Namespace: "invalid-provider", Name: "upf-edge"}
+
+	eventually(t, "the invalidProvider condition", func(ctx context.Context) error {
+		return checkCondition(ctx, k8sClient, key, "invalidProvider", metav1.ConditionFalse, "upf.free5gc.io Not supported")
+	})
+	eventually(t, "the InvalidProvider event", func(ctx context.Context) error {
+		return checkEvent(ctx, k8sClient, key, ReasonInvalidProvider)
+	})
+	ranDeployment := &workloadv1alpha1.NFDeployment{}
+	if err := k8sClient.Get(context.TODO(), key, ranDeployment); err != nil {
+		t.Fatalf("Cannot get the NFDeployment: %v", err)
+	}
+	if controllerutil.ContainsFinalizer(ranDeployment, finalizerName) {
+		t.Errorf("The NFDeployment of a not supported provider got the finalizer %s", finalizerName)
+	}
+
+	// Without finalizer the NFDeployment is deleted right away
+	if err := k8sClient.Delete(context.TODO(), ranDeployment); err != nil {
+		t.Fatalf("Cannot delete the NFDeployment: %v", err)
+	}
+	eventually(t, "the deletion of the NFDeployment", func(ctx context.Context) error {
+		if err := k8sClient.Get(ctx, key, &workloadv1alpha1.NFDeployment{}); !apierrors.IsNotFound(err) {
+			return fmt.Errorf("the NFDeployment is not deleted: %v", err)
+		}
+		return nil
+	})
+}
@@ -20,6 +20,7 @@ import (
 	context "context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,13 +29,17 @@ import (
 	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
 
 type MockStatusWriter struct {
@@ -106,7 +111,9 @@ func TestGetConfigs(t *testing.T) {
 			mock3rdArgsType: "*v1alpha1.NFConfig",
 			mockReturnRefVal: &workloadv1alpha1.NFConfig{Spec: workloadv1alpha1.NFConfigSpec{
 				ConfigRefs: []runtime.RawExtension{
-					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "PLMN"})},
+					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "PLMN", "spec": workloadnfconfig.PLMNSpec{
+						PLMNInfo: []workloadnfconfig.PLMNInfo{{NSSAI: []workloadnfconfig.NSSAI{{SST: 1, SD: ptr.To("ffffff")}}}},
+					}})},
 					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "RANConfig"})},
 					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "OAIConfig"})},
 				},
@@ -114,6 +121,24 @@ func TestGetConfigs(t *testing.T) {
 			mockReturnError: nil,
 			wantError:       "nil",
 		},
+		"PLMN Without Slice | Api-version: workload.nephio.org/v1alpha1 ": {
+			ranDeploymentParameterRef: []workloadv1alpha1.ObjectReference{{
+				APIVersion: "workload.nephio.org/v1alpha1",
+				Name:       ptr.To("ABC"),
+			}},
+			mock3rdArgsType: "*v1alpha1.NFConfig",
+			mockReturnRefVal: &workloadv1alpha1.NFConfig{Spec: workloadv1alpha1.NFConfigSpec{
+				ConfigRefs: []runtime.RawExtension{
+					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "PLMN", "spec": workloadnfconfig.PLMNSpec{
+						PLMNInfo: []workloadnfconfig.PLMNInfo{{}},
+					}})},
+					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "RANConfig"})},
+					{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "OAIConfig"})},
+				},
+			}},
+			mockReturnError: nil,
+			wantError:       "PLMN without PLMN info, slice or slice differentiator",
+		},
 		"Mock-Error (k8s not able to get the object as requested by ranDeploymentParameterRef) | Api-version: workload.nephio.org/v1alpha1 ": {
 			ranDeploymentParameterRef: []workloadv1alpha1.ObjectReference{{
 				APIVersion: "workload.nephio.org/v1alpha1",
@@ -189,8 +214,8 @@ func TestGetConfigs(t *testing.T) {
 			})
 
 			ranReconcilerObj := RANDeploymentReconciler{
-				clientMock,
-				runtime.NewScheme(),
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
 			}
 
 			ranDeploymentObj := workloadv1alpha1.NFDeployment{
@@ -251,14 +276,15 @@ func TestCreateAll(t *testing.T) {
 		"ConfigMap Failed to Create":       {errorGivingMethodIndex: 1},
 		"Deployment Failed to Create":      {errorGivingMethodIndex: 2},
 		"Service Failed to Create":         {errorGivingMethodIndex: 3},
+		"NAD Failed to Create":             {errorGivingMethodIndex: 4},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 
-			nfResourceMethods := []string{"GetServiceAccount", "GetConfigMap", "GetDeployment", "GetService"}
-			methodArguments := [][]string{{}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {}}
-			returnTypes := []string{"*v1.ServiceAccount", "*v1.ConfigMap", "*v1.Deployment", "*v1.Service"}
+			nfResourceMethods := []string{"GetServiceAccount", "GetConfigMap", "GetDeployment", "GetService", "GetNetworkAttachmentDefinitions"}
+			methodArguments := [][]string{{}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}}
+			returnTypes := []string{"*v1.ServiceAccount", "*v1.ConfigMap", "*v1.Deployment", "*v1.Service", "*unstructured.Unstructured"}
 
 			clientMock := new(MockClient)
 			for i := 0; i < len(nfResourceMethods); i++ {
@@ -271,8 +297,8 @@ func TestCreateAll(t *testing.T) {
 			}
 
 			ranReconcilerObj := RANDeploymentReconciler{
-				clientMock,
-				runtime.NewScheme(),
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
 			}
 
 			nfResourceMock := new(MockNfResource)
@@ -290,6 +316,8 @@ func TestCreateAll(t *testing.T) {
 					call.ReturnArguments = append(call.ReturnArguments, []*appsv1.Deployment{{}})
 				case 3:
 					call.ReturnArguments = append(call.ReturnArguments, []*corev1.Service{{}})
+				case 4:
+					call.ReturnArguments = append(call.ReturnArguments, []*unstructured.Unstructured{{}}, nil)
 				}
 			}
 
@@ -312,14 +340,15 @@ func TestDeleteAll(t *testing.T) {
 		"ConfigMap Failed to Delete":       {errorGivingMethodIndex: 1},
 		"GetDeployment Failed to Delete":   {errorGivingMethodIndex: 2},
 		"Service Failed to Delete":         {errorGivingMethodIndex: 3},
+		"NAD Failed to Delete":             {errorGivingMethodIndex: 4},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 
-			nfResourceMethods := []string{"GetServiceAccount", "GetConfigMap", "GetDeployment", "GetService"}
-			methodArguments := [][]string{{}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {}}
-			returnTypes := []string{"*v1.ServiceAccount", "*v1.ConfigMap", "*v1.Deployment", "*v1.Service"}
+			nfResourceMethods := []string{"GetServiceAccount", "GetConfigMap", "GetDeployment", "GetService", "GetNetworkAttachmentDefinitions"}
+			methodArguments := [][]string{{}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}, {"Logger", "*v1alpha1.NFDeployment", "*controller.ConfigInfo"}}
+			returnTypes := []string{"*v1.ServiceAccount", "*v1.ConfigMap", "*v1.Deployment", "*v1.Service", "*unstructured.Unstructured"}
 
 			clientMock := new(MockClient)
 			for i := 0; i < len(nfResourceMethods); i++ {
@@ -332,8 +361,8 @@ func TestDeleteAll(t *testing.T) {
 			}
 
 			ranReconcilerObj := RANDeploymentReconciler{
-				clientMock,
-				runtime.NewScheme(),
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
 			}
 
 			nfResourceMock := new(MockNfResource)
@@ -351,6 +380,8 @@ func TestDeleteAll(t *testing.T) {
 					call.ReturnArguments = append(call.ReturnArguments, []*appsv1.Deployment{{}})
 				case 3:
 					call.ReturnArguments = append(call.ReturnArguments, []*corev1.Service{{}})
+				case 4:
+					call.ReturnArguments = append(call.ReturnArguments, []*unstructured.Unstructured{{}}, nil)
 				}
 			}
 			ranReconcilerObj.DeleteAll(context.TODO(), &workloadv1alpha1.NFDeployment{}, nfResourceMock, &ConfigInfo{})
@@ -427,8 +458,8 @@ func TestReconcileErrorScenarios(t *testing.T) {
 			clientMock.On("Status").Return(statusWriterMock)
 
 			ranReconcilerObj := RANDeploymentReconciler{
-				clientMock,
-				runtime.NewScheme(),
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
 			}
 
 			_, err := ranReconcilerObj.Reconcile(context.TODO(), controllerruntime.Request{NamespacedName: types.NamespacedName{Namespace: "myns", Name: "mynf"}})
@@ -451,6 +482,8 @@ func TestReconcileCreateScenarios(t *testing.T) {
 
 	cases := map[string]struct {
 		ranDeployment *workloadv1alpha1.NFDeployment
+		// peerProvider is the provider of the NFDeployment of the Config ref of the NF, none when empty
+		peerProvider  string
 		expectedError error
 	}{
 		"Create DU": {
@@ -463,6 +496,7 @@ func TestReconcileCreateScenarios(t *testing.T) {
 					},
 				},
 			},
+			peerProvider:  "cucp.openairinterface.org",
 			expectedError: nil,
 		},
 		"Create CUCP": {
@@ -475,6 +509,7 @@ func TestReconcileCreateScenarios(t *testing.T) {
 					},
 				},
 			},
+			peerProvider:  "amf.openairinterface.org",
 			expectedError: nil,
 		},
 		"Create CUUP": {
@@ -487,13 +522,37 @@ func TestReconcileCreateScenarios(t *testing.T) {
 					},
 				},
 			},
+			peerProvider:  "cucp.openairinterface.org",
 			expectedError: nil,
 		},
+		"Create CUCP Without AMF": {
+			ranDeployment: &workloadv1alpha1.NFDeployment{
+				Spec: workloadv1alpha1.NFDeploymentSpec{Provider: "cucp.openairinterface.org"},
+			},
+			expectedError: errors.New("missing peers: no AMF NFDeployment in the Config refs"),
+		},
+		"Create DU Without CU-CP": {
+			ranDeployment: &workloadv1alpha1.NFDeployment{
+				Spec: workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+			},
+			expectedError: errors.New("missing peers: no CU-CP to attach to"),
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			clientMock := new(MockClient)
+			if tc.peerProvider != "" {
+				tc.ranDeployment.Spec.ParametersRefs = []workloadv1alpha1.ObjectReference{{APIVersion: "ref.nephio.org/v1alpha1", Name: ptr.To("peer")}}
+				peer := workloadv1alpha1.NFDeployment{
+					TypeMeta:   metav1.TypeMeta{Kind: "NFDeployment"},
+					ObjectMeta: metav1.ObjectMeta{Name: "peer"},
+					Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: tc.peerProvider},
+				}
+				clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1alpha1.Config")).Return(nil).Run(func(args mock.Arguments) {
+					*args.Get(2).(*configref.Config) = configref.Config{Spec: configref.ConfigSpec{Config: runtime.RawExtension{Raw: marshalJsonReturnByteOnly(peer)}}}
+				})
+			}
 			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil).Run(func(args mock.Arguments) {
 				configObj := args.Get(2).(*workloadv1alpha1.NFDeployment)
 				*configObj = *tc.ranDeployment // tc.ranDeployment is what r.Get will store in 3rd Argument
@@ -505,19 +564,35 @@ func TestReconcileCreateScenarios(t *testing.T) {
 				1) GetDeployment, GetConfigMap are separatly unit-tested for (corner-scenarios)
 				2) Much Significant test would be the Integration test
 			*/
-			clientMock.On("Create", context.TODO(), mock.AnythingOfType("*v1.ServiceAccount")).Return(nil)     // For GetServiceAccount
-			clientMock.On("Create", context.TODO(), mock.AnythingOfType("*v1.Service")).Return(nil)            // For GetService
-			clientMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil) // For r.Update (whose significance is adding a finalizer)
+			clientMock.On("Create", context.TODO(), mock.AnythingOfType("*v1.ServiceAccount")).Return(nil)                      // For GetServiceAccount
+			clientMock.On("Create", context.TODO(), mock.AnythingOfType("*v1.Service")).Return(nil)                             // For GetService
+			clientMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)                  // For r.Update (whose significance is adding a finalizer)
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeploymentList"), mock.Anything).Return(nil) // For the DUs attached to a CU-CP
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+			// For the ready condition
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Deployment")).Return(nil)
+			// For the legacy telnet Service of the DUs
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Service")).Return(apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, legacyDuTelnetServiceName))
+			// For the Pod Security level of the namespace, none is enforced
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Namespace")).Return(nil)
 			ranReconcilerObj := RANDeploymentReconciler{
-				clientMock,
-				runtime.NewScheme(),
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
 			}
 			_, err := ranReconcilerObj.Reconcile(context.TODO(), controllerruntime.Request{NamespacedName: types.NamespacedName{Namespace: "myns", Name: "mynf"}})
 			if tc.expectedError == nil {
 				if err != nil {
 					t.Errorf("Reconcile During Creation gives Error %v while NO Error was expected", err)
 				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError.Error()) {
+				t.Errorf("Reconcile During Creation gives Error %v while %v was expected", err, tc.expectedError)
 			}
+			// Nothing is created without the peers
+			clientMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 
 		})
 	}
@@ -602,9 +677,11 @@ func TestReconcileDeleteScenarios(t *testing.T) {
 			clientMock.On("Delete", context.TODO(), mock.AnythingOfType("*v1.ServiceAccount")).Return(nil)     // For GetServiceAccount
 			clientMock.On("Delete", context.TODO(), mock.AnythingOfType("*v1.Service")).Return(nil)            // For GetService
 			clientMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil) // For r.Update (whose significance is deleting the finalizer)
+			// For the legacy telnet Service of the DUs created by the previous releases
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Service")).Return(apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, legacyDuTelnetServiceName))
 			ranReconcilerObj := RANDeploymentReconciler{
-				clientMock,
-				runtime.NewScheme(),
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
 			}
 			_, err := ranReconcilerObj.Reconcile(context.TODO(), controllerruntime.Request{NamespacedName: types.NamespacedName{Namespace: "myns", Name: "mynf"}})
 			if tc.expectedError == nil {
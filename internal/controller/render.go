@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// RenderedResources are the resources CreateAll creates for a NFDeployment
+type RenderedResources struct {
+	NetworkAttachmentDefinitions []*unstructured.Unstructured
+	ServiceAccounts              []*corev1.ServiceAccount
+	ConfigMaps                   []*corev1.ConfigMap
+	Deployments                  []*appsv1.Deployment
+	Services                     []*corev1.Service
+}
+
+// GetNfResource returns the NfResource generating the resources of the provider of a NFDeployment
+func GetNfResource(ranDeployment *workloadv1alpha1.NFDeployment) (NfResource, error) {
+	switch ranDeployment.Spec.Provider {
+	case "cucp.openairinterface.org":
+		return CuCpResources{}, nil
+	case "cuup.openairinterface.org":
+		return CuUpResources{Name: getCuUpResourceName(ranDeployment)}, nil
+	case "du.openairinterface.org":
+		return DuResources{Name: getDuResourceName(ranDeployment)}, nil
+	default:
+		return nil, fmt.Errorf("not supported provider %q", ranDeployment.Spec.Provider)
+	}
+}
+
+// Render returns the resources the reconciler creates for a NFDeployment, without creating them.
+// The Config and NFConfig referenced by the NFDeployment are read with the client of the reconciler
+func (r *RANDeploymentReconciler) Render(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) (*RenderedResources, error) {
+	configInfo, err := r.GetConfigs(ctx, ranDeployment)
+	if err != nil {
+		return nil, err
+	}
+	return renderResources(ctx, ranDeployment, configInfo)
+}
+
+// renderResources returns the resources of a NFDeployment generated from its configs, in their namespace
+func renderResources(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) (*RenderedResources, error) {
+	namespacedName := types.NamespacedName{Namespace: ranDeployment.Namespace, Name: ranDeployment.Name}
+	logger := log.FromContext(ctx).WithValues("RANDeployment", namespacedName)
+
+	nfResource, err := GetNfResource(ranDeployment)
+	if err != nil {
+		return nil, err
+	}
+
+	defer observeRenderDuration(ranDeployment, time.Now())
+	networkAttachmentDefinitions, err := nfResource.GetNetworkAttachmentDefinitions(logger, ranDeployment, configInfo)
+	if err != nil {
+		return nil, fmt.Errorf("cannot render the NetworkAttachmentDefinitions of %s: %w", namespacedName, err)
+	}
+	rendered := &RenderedResources{
+		NetworkAttachmentDefinitions: networkAttachmentDefinitions,
+		ServiceAccounts:              nfResource.GetServiceAccount(),
+		ConfigMaps:                   nfResource.GetConfigMap(logger, ranDeployment, configInfo),
+		Deployments:                  nfResource.GetDeployment(logger, ranDeployment, configInfo),
+		Services:                     nfResource.GetService(logger, ranDeployment, configInfo),
+	}
+	// The resources log their errors and return nil, which CreateAll would skip
+	if rendered.ConfigMaps == nil {
+		return nil, fmt.Errorf("cannot render the ConfigMap of %s, see the logs", namespacedName)
+	}
+	if rendered.Deployments == nil {
+		return nil, fmt.Errorf("cannot render the Deployment of %s, see the logs", namespacedName)
+	}
+
+	for _, resource := range rendered.ServiceAccounts {
+		if resource.Namespace == "" {
+			resource.Namespace = ranDeployment.Namespace
+		}
+	}
+	for _, resource := range rendered.ConfigMaps {
+		if resource.Namespace == "" {
+			resource.Namespace = ranDeployment.Namespace
+		}
+	}
+	for _, resource := range rendered.Deployments {
+		if resource.Namespace == "" {
+			resource.Namespace = ranDeployment.Namespace
+		}
+	}
+	for _, resource := range rendered.Services {
+		if resource.Namespace == "" {
+			resource.Namespace = ranDeployment.Namespace
+		}
+	}
+	return rendered, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	configref "github.com/nephio-project/api/references/v1alpha1"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestGetNfResource(t *testing.T) {
+	cases := map[string]struct {
+		provider   string
+		annotation string
+		want       NfResource
+	}{
+		"CU-CP": {provider: "cucp.openairinterface.org", want: CuCpResources{}},
+		"CU-UP": {provider: "cuup.openairinterface.org", annotation: "3585", want: CuUpResources{Name: "oai-cu-up-1"}},
+		"DU":    {provider: "du.openairinterface.org", want: DuResources{Name: "oai-du-du-edge"}},
+		"AMF":   {provider: "amf.openairinterface.org"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "du-edge"},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: tc.provider},
+			}
+			if tc.annotation != "" {
+				ranDeployment.Annotations = map[string]string{CuUpIdAnnotation: tc.annotation}
+			}
+			got, err := GetNfResource(ranDeployment)
+			if (err != nil) != (tc.want == nil) {
+				t.Fatalf("GetNfResource returned error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("GetNfResource returned %v, expected %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	cuCpInterfaces := []workloadv1alpha1.InterfaceConfig{
+		{Name: "n2", IPv4: &workloadv1alpha1.IPv4{Address: "172.6.0.254/24", Gateway: ptr.To("172.6.0.1")}},
+		{Name: "e1", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.3/24", Gateway: ptr.To("172.5.1.1")}},
+		{Name: "f1c", IPv4: &workloadv1alpha1.IPv4{Address: "172.6.0.7/24", Gateway: ptr.To("172.6.0.1")}},
+	}
+	cases := map[string]struct {
+		provider   string
+		interfaces []workloadv1alpha1.InterfaceConfig
+		getError   error
+		wantError  string
+	}{
+		"CU-CP": {
+			provider:   "cucp.openairinterface.org",
+			interfaces: cuCpInterfaces,
+		},
+		"Not Supported Provider": {
+			provider:  "amf.openairinterface.org",
+			wantError: "not supported provider",
+		},
+		"NFConfig Not Found": {
+			provider:   "cucp.openairinterface.org",
+			interfaces: cuCpInterfaces,
+			getError:   errors.New("NFConfig not found"),
+			wantError:  "NFConfig not found",
+		},
+		"Interfaces Not Provided": {
+			provider:  "cucp.openairinterface.org",
+			wantError: "cannot render the ConfigMap",
+		},
+	}
+
+	amf := workloadv1alpha1.NFDeployment{Spec: workloadv1alpha1.NFDeploymentSpec{
+		Provider:   "amf.openairinterface.org",
+		Interfaces: []workloadv1alpha1.InterfaceConfig{{Name: "n2", IPv4: &workloadv1alpha1.IPv4{Address: "172.2.0.254/24"}}},
+	}}
+	amf.Kind = "NFDeployment"
+	nfConfig := workloadv1alpha1.NFConfig{Spec: workloadv1alpha1.NFConfigSpec{ConfigRefs: []runtime.RawExtension{
+		{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "PLMN", "spec": workloadnfconfig.PLMNSpec{
+			PLMNInfo: []workloadnfconfig.PLMNInfo{{PLMNID: workloadnfconfig.PLMNID{MCC: "001", MNC: "01"}, TAC: 1, NSSAI: []workloadnfconfig.NSSAI{{SST: 1, SD: ptr.To("ffffff")}}}},
+		}})},
+		{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "RANConfig", "spec": workloadnfconfig.RANConfigSpec{}})},
+		{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "OAIConfig", "spec": workloadnfconfig.OAIConfigSpec{Image: "oai-gnb:develop"}})},
+	}}}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1alpha1.Config")).Return(nil).Run(func(args mock.Arguments) {
+				*args.Get(2).(*configref.Config) = configref.Config{Spec: configref.ConfigSpec{Config: runtime.RawExtension{Raw: marshalJsonReturnByteOnly(amf)}}}
+			})
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1alpha1.NFConfig")).Return(tc.getError).Run(func(args mock.Arguments) {
+				*args.Get(2).(*workloadv1alpha1.NFConfig) = nfConfig
+			})
+			r := RANDeploymentReconciler{Client: clientMock, Scheme: runtime.NewScheme()}
+
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "cucp-regional", Namespace: "oai-ran-cucp"},
+				Spec: workloadv1alpha1.NFDeploymentSpec{
+					Provider:   tc.provider,
+					Interfaces: tc.interfaces,
+					ParametersRefs: []workloadv1alpha1.ObjectReference{
+						{APIVersion: "ref.nephio.org/v1alpha1", Name: ptr.To("amf")},
+						{APIVersion: "workload.nephio.org/v1alpha1", Name: ptr.To("cucp-regional")},
+					},
+				},
+			}
+			got, err := r.Render(context.TODO(), ranDeployment)
+			if tc.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantError) {
+					t.Errorf("Render returned error %v, expected %s", err, tc.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render returned error %v", err)
+			}
+
+			if len(got.ServiceAccounts) != 1 || len(got.ConfigMaps) != 1 || len(got.Deployments) != 1 {
+				t.Fatalf("Render returned %v, expected a ServiceAccount, a ConfigMap and a Deployment", got)
+			}
+			if !strings.Contains(got.ConfigMaps[0].Data["gnb.conf"], "\"172.2.0.254\"") {
+				t.Errorf("gnb.conf doesn't reach the AMF of the Config: %s", got.ConfigMaps[0].Data["gnb.conf"])
+			}
+			for _, object := range []metav1.Object{got.ServiceAccounts[0], got.ConfigMaps[0], got.Deployments[0]} {
+				if object.GetNamespace() != "oai-ran-cucp" {
+					t.Errorf("%s is in namespace %q, expected the namespace of the NFDeployment", object.GetName(), object.GetNamespace())
+				}
+			}
+		})
+	}
+}
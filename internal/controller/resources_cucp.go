@@ -17,6 +17,7 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 
 	"github.com/go-logr/logr"
@@ -24,6 +25,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/ptr"
 	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
@@ -48,40 +50,30 @@ func (resource CuCpResources) GetServiceAccount() []*corev1.ServiceAccount {
 
 func (resource CuCpResources) GetConfigMap(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*corev1.ConfigMap {
 
-	n2Ip, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "n2")
+	interfaceValues, err := getInterfaceTemplateValues("cucp.openairinterface.org", &ranDeployment.Spec)
 	if err != nil {
-		log.Error(err, "Interface n2 not found in RANDeployment Spec")
+		log.Error(err, "Interfaces not found in RANDeployment Spec")
 		return nil
 	}
 
-	quotedN2Ip := strconv.Quote(n2Ip)
-
-	e1Ip, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "e1")
-	if err != nil {
-		log.Error(err, "Interface e1 not found in RANDeployment Spec")
+	amfDeployment := getConfigInstanceByProvider(log, configInfo.ConfigRefInfo["NFDeployment"], "amf.openairinterface.org")
+	if amfDeployment == nil {
+		log.Error(fmt.Errorf("no AMF NFDeployment in the Config refs"), "AMF not found in Config Refs RANDeployment")
 		return nil
 	}
 
-	quotedE1Ip := strconv.Quote(e1Ip)
-
-	f1cIp, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "f1c")
-	if err != nil {
-		log.Error(err, "Interface f1c not found in RANDeployment Spec")
-		return nil
+	// The NGAP association uses IPv4 unless the n2 interface of the CU-CP is IPv6 only
+	var amfIp, amfIpv6 string
+	if interfaceValues["N2_IP"] != "" {
+		amfIp, err = GetFirstInterfaceConfigIPv4(amfDeployment.Spec.Interfaces, "n2")
+	} else {
+		amfIpv6, err = GetFirstInterfaceConfigIPv6(amfDeployment.Spec.Interfaces, "n2")
 	}
-
-	quotedF1CIp := strconv.Quote(f1cIp)
-
-	amfDeployment := getConfigInstanceByProvider(log, configInfo.ConfigRefInfo["NFDeployment"], "amf.openairinterface.org")
-
-	amfIp, err := GetFirstInterfaceConfigIPv4(amfDeployment.Spec.Interfaces, "n2")
 	if err != nil {
 		log.Error(err, "AMF IP not found in Config Refs AMFDeployment")
 		return nil
 	}
 
-	quotedAmfIp := strconv.Quote(amfIp)
-
 	paramsRanNf := &workloadnfconfig.RANConfig{}
 	if err := json.Unmarshal(configInfo.ConfigSelfInfo["RANConfig"].Raw, paramsRanNf); err != nil {
 		log.Error(err, "Cannot Unmarshal RANConfig")
@@ -94,11 +86,19 @@ func (resource CuCpResources) GetConfigMap(log logr.Logger, ranDeployment *workl
 		return nil
 	}
 
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the E2 agent from OAIConfig")
+		return nil
+	}
+
 	templateValues := configurationTemplateValuesForCuCp{
-		E1_IP:           quotedE1Ip,
-		F1C_IP:          quotedF1CIp,
-		N2_IP:           quotedN2Ip,
-		AMF_IP:          quotedAmfIp,
+		E1_IP:           interfaceValues["E1_IP"],
+		F1C_IP:          interfaceValues["F1C_IP"],
+		N2_IP:           interfaceValues["N2_IP"],
+		N2_IPV6:         interfaceValues["N2_IPV6"],
+		AMF_IP:          quoteIPIfSet(amfIp),
+		AMF_IPV6:        quoteIPIfSet(amfIpv6),
 		TAC:             paramsPlmn.Spec.PLMNInfo[0].TAC,
 		CELL_ID:         paramsRanNf.Spec.CellIdentity,
 		PHY_CELL_ID:     paramsRanNf.Spec.PhysicalCellID,
@@ -113,6 +113,7 @@ func (resource CuCpResources) GetConfigMap(log logr.Logger, ranDeployment *workl
 		PLMN_MNC_LENGTH: strconv.Itoa(int(len(paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC))),
 		NSSAI_SST:       paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SST,
 		NSSAI_SD:        *paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SD,
+		E2_AGENT:        getE2AgentTemplateValues(e2Agent),
 	}
 
 	configuration, err := renderConfigurationTemplateForCuCp(templateValues)
@@ -137,27 +138,39 @@ func (resource CuCpResources) GetConfigMap(log logr.Logger, ranDeployment *workl
 	return []*corev1.ConfigMap{configMap1}
 }
 
-func (resource CuCpResources) createNetworkAttachmentDefinitionNetworks(templateName string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) (string, error) {
-	return CreateNetworkAttachmentDefinitionNetworks(templateName, map[string][]workloadv1alpha1.InterfaceConfig{
-		"e1":  GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "e1"),
-		"n2":  GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "n2"),
-		"f1c": GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "f1c"),
-	})
+// getInterfaceConfigs returns the interfaces of the NF attached through Multus
+func (resource CuCpResources) getInterfaceConfigs(ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) map[string][]workloadv1alpha1.InterfaceConfig {
+	return getInterfaceConfigsOfProvider("cucp.openairinterface.org", ranDeploymentSpec)
+}
+
+func (resource CuCpResources) createNetworkAttachmentDefinitionNetworks(templateName string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, networkConfigs []workloadnfconfig.NetworkConfig) (string, error) {
+	return CreateNetworkAttachmentDefinitionNetworks(templateName, resource.getInterfaceConfigs(ranDeploymentSpec), networkConfigs)
+}
+
+func (resource CuCpResources) GetNetworkAttachmentDefinitions(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) ([]*unstructured.Unstructured, error) {
+	return getNetworkAttachmentDefinitions(ranDeployment, configInfo, resource.getInterfaceConfigs(&ranDeployment.Spec))
 }
 
 func (resource CuCpResources) GetDeployment(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*appsv1.Deployment {
 
 	spec := ranDeployment.Spec
 
-	networkAttachmentDefinitionNetworks, err := resource.createNetworkAttachmentDefinitionNetworks(ranDeployment.Name, &spec)
+	paramsOAI := &workloadnfconfig.OAIConfig{}
+	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, paramsOAI); err != nil {
+		log.Error(err, "Cannot Unmarshal OAIConfig")
+		return nil
+	}
+
+	networkAttachmentDefinitionNetworks, err := resource.createNetworkAttachmentDefinitionNetworks(ranDeployment.Name, &spec, paramsOAI.Spec.Networks)
 
 	if err != nil {
+		log.Error(err, "Cannot create the networks annotation")
 		return nil
 	}
 
-	paramsOAI := &workloadnfconfig.OAIConfig{}
-	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, paramsOAI); err != nil {
-		log.Error(err, "Cannot Unmarshal OAIConfig")
+	runtimeOptions, err := getRuntimeOptions(ranDeployment.Spec.Provider, paramsOAI.Spec.Runtime)
+	if err != nil {
+		log.Error(err, "Invalid runtime options in OAIConfig")
 		return nil
 	}
 
@@ -194,9 +207,6 @@ func (resource CuCpResources) GetDeployment(log logr.Logger, ranDeployment *work
 					Containers: []corev1.Container{
 
 						corev1.Container{
-							SecurityContext: &corev1.SecurityContext{
-								Privileged: ptr.To(true),
-							},
 							Stdin:     false,
 							StdinOnce: false,
 							TTY:       false,
@@ -213,11 +223,11 @@ func (resource CuCpResources) GetDeployment(log logr.Logger, ranDeployment *work
 
 								corev1.EnvVar{
 									Name:  "TZ",
-									Value: "Europe/Paris",
+									Value: runtimeOptions.Timezone,
 								},
 								corev1.EnvVar{
 									Name:  "USE_ADDITIONAL_OPTIONS",
-									Value: "--sa --log_config.global_log_options level,nocolor,time",
+									Value: getAdditionalOptions([]string{"--sa"}, runtimeOptions),
 								},
 								corev1.EnvVar{
 									Name:  "USE_VOLUMED_CONF",
@@ -276,9 +286,54 @@ func (resource CuCpResources) GetDeployment(log logr.Logger, ranDeployment *work
 		},
 	}
 
+	addRuntimeEnv(&deployment1.Spec.Template.Spec.Containers[0], runtimeOptions)
+
+	telnet, err := getTelnet(ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the telnet server from OAIConfig")
+		return nil
+	}
+	if telnet != nil {
+		listenAddress, err := getTelnetListenAddress(ranDeployment, telnet)
+		if err != nil {
+			log.Error(err, "Cannot get the listen address of the telnet server")
+			return nil
+		}
+		addTelnetServer(&deployment1.Spec.Template.Spec.Containers[0], ranDeployment, telnet, listenAddress)
+	}
+
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the E2 agent from OAIConfig")
+		return nil
+	}
+	addE2ServiceModels(&deployment1.Spec.Template.Spec, e2Agent, paramsOAI.Spec.Image)
+
+	if err := applyCompute(&deployment1.Spec.Template.Spec, paramsOAI.Spec.Compute); err != nil {
+		log.Error(err, "Invalid compute config in OAIConfig")
+		return nil
+	}
+
+	if err := applySecurity(&deployment1.Spec.Template.Spec, ranDeployment.Spec.Provider, paramsOAI.Spec.Security); err != nil {
+		log.Error(err, "Invalid security config in OAIConfig")
+		return nil
+	}
+
+	addProbes(&deployment1.Spec.Template.Spec.Containers[0], ranDeployment.Spec.Provider, paramsOAI.Spec.Probes, telnet)
+
 	return []*appsv1.Deployment{deployment1}
 }
 
-func (resource CuCpResources) GetService() []*corev1.Service {
-	return []*corev1.Service{}
+func (resource CuCpResources) GetService(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*corev1.Service {
+	telnet, err := getTelnet(ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the telnet server from OAIConfig")
+		return nil
+	}
+
+	services := []*corev1.Service{}
+	if telnetService := getTelnetService("oai-cu-cp", telnet); telnetService != nil {
+		services = append(services, telnetService)
+	}
+	return services
 }
@@ -96,11 +96,11 @@ func TestCreateNetworkAttachmentDefinitionNetworksCuCP(t *testing.T) {
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			dummyNfSpec.Interfaces = tc.inputInterfaceConfig
-			got, err := cucpResource.createNetworkAttachmentDefinitionNetworks("abc", &dummyNfSpec)
+			got, err := cucpResource.createNetworkAttachmentDefinitionNetworks("abc", &dummyNfSpec, nil)
 			if err != nil {
 				t.Errorf("CucpResource| createNetworkAttachmentDefinitionNetworks Error %v ", err)
 			}
-			if !compareStringLineByLineTrimmed(got, tc.want) {
+			if !compareJson(got, tc.want) {
 				t.Errorf("CucpResource| createNetworkAttachmentDefinitionNetworks returned %s wanted %s", got, tc.want)
 			}
 		})
@@ -168,16 +168,20 @@ func TestGetDeploymentCuCp(t *testing.T) {
 					Interfaces: []workloadv1alpha1.InterfaceConfig{
 						{
 							Name: "e1",
-							IPv4: &workloadv1alpha1.IPv4{
-								Address: "172.5.1.3/24", // Gateway is removed
-							},
+							// Neither IPv4 nor IPv6 address
 							VLANID: uint16Ptr(2),
 						},
 					},
 				},
 			},
-			configInfo: &ConfigInfo{},
-			want:       "error",
+			configInfo: &ConfigInfo{
+				ConfigSelfInfo: map[string]runtime.RawExtension{
+					"OAIConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Image: "dummy-image"}}),
+					},
+				},
+			},
+			want: "error",
 		},
 		"OAI-Config Unmarshal Error": {
 			ranDeployment: workloadv1alpha1.NFDeployment{
@@ -275,7 +279,7 @@ func TestGetServiceAccountCuCp(t *testing.T) {
 
 func TestGetServiceCuCp(t *testing.T) {
 	cucpResource := CuCpResources{}
-	got := cucpResource.GetService()
+	got := cucpResource.GetService(log.Log, &workloadv1alpha1.NFDeployment{}, &ConfigInfo{})
 	/*
 		More cases will be added when more code will be added to GetService
 	*/
@@ -335,8 +339,10 @@ func TestGetConfigMapCuCp(t *testing.T) {
 			},
 			paramsRanNf: workloadnfconfig.RANConfig{
 				Spec: workloadnfconfig.RANConfigSpec{
-					CellIdentity:   "12345678L",
-					PhysicalCellID: uint32(0),
+					RANCellConfig: workloadnfconfig.RANCellConfig{
+						CellIdentity:   "12345678L",
+						PhysicalCellID: uint32(0),
+					},
 				},
 			},
 			paramsPlmn: workloadnfconfig.PLMN{
@@ -530,6 +536,34 @@ func TestGetConfigMapCuCp(t *testing.T) {
 			paramsPlmn:  workloadnfconfig.PLMN{},
 			wantedError: "PLMN Marshal Error",
 		},
+		"AMF Not In Config Refs": {
+			ranDeploymentSpec: workloadv1alpha1.NFDeploymentSpec{
+				Interfaces: []workloadv1alpha1.InterfaceConfig{
+					{
+						Name: "e1",
+						IPv4: &workloadv1alpha1.IPv4{
+							Address: "172.5.1.3/24",
+							Gateway: ptr.To("172.5.1.1"),
+						},
+					}, {
+						Name: "n2",
+						IPv4: &workloadv1alpha1.IPv4{
+							Address: "172.6.0.254/24",
+							Gateway: ptr.To("172.6.0.1"),
+						},
+					}, {
+						Name: "f1c",
+						IPv4: &workloadv1alpha1.IPv4{
+							Address: "172.6.0.7/24",
+							Gateway: ptr.To("172.6.0.1"),
+						},
+					},
+				},
+			},
+			paramsRanNf: workloadnfconfig.RANConfig{},
+			paramsPlmn:  workloadnfconfig.PLMN{},
+			wantedError: "AMF Not In Config Refs",
+		},
 	}
 
 	logger := log.Log
@@ -565,6 +599,8 @@ func TestGetConfigMapCuCp(t *testing.T) {
 				configInfo.ConfigSelfInfo["RANConfig"] = runtime.RawExtension{Raw: []byte("")}
 			case "PLMN Marshal Error":
 				configInfo.ConfigSelfInfo["PLMN"] = runtime.RawExtension{Raw: []byte("")}
+			case "AMF Not In Config Refs":
+				delete(configInfo.ConfigRefInfo, "NFDeployment")
 			}
 
 			got := cuCpResource.GetConfigMap(logger, &ranDeploymentDummy, &configInfo)
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 
 	"github.com/go-logr/logr"
@@ -27,32 +28,54 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/ptr"
 )
 
 type CuUpResources struct {
+	// Name prefixes the resources of the CU-UP, "oai-cu-up" when empty
+	Name string
 }
 
-func (resource CuUpResources) createNetworkAttachmentDefinitionNetworks(templateName string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) (string, error) {
-	return CreateNetworkAttachmentDefinitionNetworks(templateName, map[string][]workloadv1alpha1.InterfaceConfig{
-		"e1":  GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "e1"),
-		"n3":  GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "n3"),
-		"f1u": GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "f1u"),
-	})
+func (resource CuUpResources) name() string {
+	if resource.Name == "" {
+		return "oai-cu-up"
+	}
+	return resource.Name
+}
+
+// getInterfaceConfigs returns the interfaces of the NF attached through Multus
+func (resource CuUpResources) getInterfaceConfigs(ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) map[string][]workloadv1alpha1.InterfaceConfig {
+	return getInterfaceConfigsOfProvider("cuup.openairinterface.org", ranDeploymentSpec)
+}
+
+func (resource CuUpResources) createNetworkAttachmentDefinitionNetworks(templateName string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, networkConfigs []workloadnfconfig.NetworkConfig) (string, error) {
+	return CreateNetworkAttachmentDefinitionNetworks(templateName, resource.getInterfaceConfigs(ranDeploymentSpec), networkConfigs)
+}
+
+func (resource CuUpResources) GetNetworkAttachmentDefinitions(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) ([]*unstructured.Unstructured, error) {
+	return getNetworkAttachmentDefinitions(ranDeployment, configInfo, resource.getInterfaceConfigs(&ranDeployment.Spec))
 }
 func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*appsv1.Deployment {
 
 	spec := ranDeployment.Spec
 
-	networkAttachmentDefinitionNetworks, err := resource.createNetworkAttachmentDefinitionNetworks(ranDeployment.Name, &spec)
+	paramsOAI := &workloadnfconfig.OAIConfig{}
+	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, paramsOAI); err != nil {
+		log.Error(err, "Cannot Unmarshal OAIConfig")
+		return nil
+	}
+
+	networkAttachmentDefinitionNetworks, err := resource.createNetworkAttachmentDefinitionNetworks(ranDeployment.Name, &spec, paramsOAI.Spec.Networks)
 
 	if err != nil {
+		log.Error(err, "Cannot create the networks annotation")
 		return nil
 	}
 
-	paramsOAI := &workloadnfconfig.OAIConfig{}
-	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, paramsOAI); err != nil {
-		log.Error(err, "Cannot Unmarshal OAIConfig")
+	runtimeOptions, err := getRuntimeOptions(ranDeployment.Spec.Provider, paramsOAI.Spec.Runtime)
+	if err != nil {
+		log.Error(err, "Invalid runtime options in OAIConfig")
 		return nil
 	}
 
@@ -62,16 +85,16 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 	deployment1 := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: map[string]string{
-				"app.kubernetes.io/name": "oai-cu-up",
+				"app.kubernetes.io/name": resource.name(),
 			},
-			Name: "oai-cu-up",
+			Name: resource.name(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Paused:   false,
 			Replicas: ptr.To(int32(1)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					"app.kubernetes.io/name": "oai-cu-up",
+					"app.kubernetes.io/name": resource.name(),
 				},
 			},
 			Strategy: appsv1.DeploymentStrategy{
@@ -81,8 +104,8 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: podAnnotations,
 					Labels: map[string]string{
-						"app":                    "oai-cu-up",
-						"app.kubernetes.io/name": "oai-cu-up",
+						"app":                    resource.name(),
+						"app.kubernetes.io/name": resource.name(),
 					},
 				},
 				Spec: corev1.PodSpec{
@@ -107,11 +130,11 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 
 								corev1.EnvVar{
 									Name:  "TZ",
-									Value: "Europe/Paris",
+									Value: runtimeOptions.Timezone,
 								},
 								corev1.EnvVar{
 									Name:  "USE_ADDITIONAL_OPTIONS",
-									Value: "--sa --log_config.global_log_options level,nocolor,time",
+									Value: getAdditionalOptions([]string{"--sa"}, runtimeOptions),
 								},
 								corev1.EnvVar{
 									Name:  "USE_VOLUMED_CONF",
@@ -136,9 +159,6 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 									Protocol:      corev1.Protocol("UDP"),
 								},
 							},
-							SecurityContext: &corev1.SecurityContext{
-								Privileged: ptr.To(true),
-							},
 							Stdin:     false,
 							StdinOnce: false,
 							TTY:       false,
@@ -148,7 +168,7 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 					},
 					DNSPolicy:          corev1.DNSPolicy("ClusterFirst"),
 					SchedulerName:      "default-scheduler",
-					ServiceAccountName: "oai-cu-up-sa",
+					ServiceAccountName: resource.name() + "-sa",
 					Volumes: []corev1.Volume{
 
 						corev1.Volume{
@@ -156,7 +176,7 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 							VolumeSource: corev1.VolumeSource{
 								ConfigMap: &corev1.ConfigMapVolumeSource{
 									LocalObjectReference: corev1.LocalObjectReference{
-										Name: "oai-cu-up-configmap",
+										Name: resource.name() + "-configmap",
 									},
 								},
 							},
@@ -171,6 +191,41 @@ func (resource CuUpResources) GetDeployment(log logr.Logger, ranDeployment *work
 		},
 	}
 
+	addRuntimeEnv(&deployment1.Spec.Template.Spec.Containers[0], runtimeOptions)
+
+	telnet, err := getTelnet(ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the telnet server from OAIConfig")
+		return nil
+	}
+	if telnet != nil {
+		listenAddress, err := getTelnetListenAddress(ranDeployment, telnet)
+		if err != nil {
+			log.Error(err, "Cannot get the listen address of the telnet server")
+			return nil
+		}
+		addTelnetServer(&deployment1.Spec.Template.Spec.Containers[0], ranDeployment, telnet, listenAddress)
+	}
+
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the E2 agent from OAIConfig")
+		return nil
+	}
+	addE2ServiceModels(&deployment1.Spec.Template.Spec, e2Agent, paramsOAI.Spec.Image)
+
+	if err := applyCompute(&deployment1.Spec.Template.Spec, paramsOAI.Spec.Compute); err != nil {
+		log.Error(err, "Invalid compute config in OAIConfig")
+		return nil
+	}
+
+	if err := applySecurity(&deployment1.Spec.Template.Spec, ranDeployment.Spec.Provider, paramsOAI.Spec.Security); err != nil {
+		log.Error(err, "Invalid security config in OAIConfig")
+		return nil
+	}
+
+	addProbes(&deployment1.Spec.Template.Spec.Containers[0], ranDeployment.Spec.Provider, paramsOAI.Spec.Probes, telnet)
+
 	return []*appsv1.Deployment{deployment1}
 }
 
@@ -178,7 +233,7 @@ func (resource CuUpResources) GetServiceAccount() []*corev1.ServiceAccount {
 
 	serviceAccount1 := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "oai-cu-up-sa",
+			Name: resource.name() + "-sa",
 		},
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -191,33 +246,25 @@ func (resource CuUpResources) GetServiceAccount() []*corev1.ServiceAccount {
 
 func (resource CuUpResources) GetConfigMap(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*corev1.ConfigMap {
 
-	n3Ip, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "n3")
+	interfaceValues, err := getInterfaceTemplateValues("cuup.openairinterface.org", &ranDeployment.Spec)
 	if err != nil {
-		log.Error(err, "Interface n3 not found in RANDeployment Spec")
+		log.Error(err, "Interfaces not found in RANDeployment Spec")
 		return nil
 	}
 
-	quotedN3Ip := strconv.Quote(n3Ip)
-
-	e1Ip, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "e1")
+	e1Ip, err := getInterfaceIPOfProvider("cuup.openairinterface.org", &ranDeployment.Spec, "e1")
 	if err != nil {
 		log.Error(err, "Interface e1 not found in RANDeployment Spec")
 		return nil
 	}
 
-	quotedE1Ip := strconv.Quote(e1Ip)
-
-	f1uIp, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "f1u")
+	ranDeploymentConfigRef, err := getCuCpDeployment(log, ranDeployment, configInfo)
 	if err != nil {
-		log.Error(err, "Interface F1 U not found in RANDeployment Spec")
+		log.Error(err, "CU-CP not found in Config Refs RANDeployment")
 		return nil
 	}
 
-	quotedF1UIp := strconv.Quote(f1uIp)
-
-	ranDeploymentConfigRef := getConfigInstanceByProvider(log, configInfo.ConfigRefInfo["NFDeployment"], "cucp.openairinterface.org")
-
-	cuCpIp, err := GetFirstInterfaceConfigIPv4(ranDeploymentConfigRef.Spec.Interfaces, "e1")
+	cuCpIp, err := getPeerInterfaceIPOfProvider(e1Ip, "cucp.openairinterface.org", &ranDeploymentConfigRef.Spec, "e1")
 	if err != nil {
 		log.Error(err, "CU CP IP not found in Config Refs RANDeployment")
 		return nil
@@ -231,17 +278,40 @@ func (resource CuUpResources) GetConfigMap(log logr.Logger, ranDeployment *workl
 		return nil
 	}
 
+	cuUpId, found, err := getCuUpId(ranDeployment)
+	if err != nil {
+		log.Error(err, "Cannot get the gNB_CU_UP_ID of the CU-UP")
+		return nil
+	}
+	if !found {
+		cuUpId = firstGnbCuUpId
+	}
+
+	slices, err := getCuUpSlices(ranDeployment, &paramsPlmn.Spec.PLMNInfo[0])
+	if err != nil {
+		log.Error(err, "Cannot select the slices of the CU-UP")
+		return nil
+	}
+
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the E2 agent from OAIConfig")
+		return nil
+	}
+
 	templateValues := configurationTemplateValuesForCuUp{
-		E1_IP:           quotedE1Ip,
-		F1U_IP:          quotedF1UIp,
-		N3_IP:           quotedN3Ip,
+		E1_IP:           interfaceValues["E1_IP"],
+		F1U_IP:          interfaceValues["F1U_IP"],
+		N3_IP:           interfaceValues["N3_IP"],
+		N3_IPV6:         interfaceValues["N3_IPV6"],
 		CUCP_E1:         quotedCuCpIp,
 		TAC:             paramsPlmn.Spec.PLMNInfo[0].TAC,
 		PLMN_MCC:        paramsPlmn.Spec.PLMNInfo[0].PLMNID.MCC,
 		PLMN_MNC:        paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC,
 		PLMN_MNC_LENGTH: strconv.Itoa(int(len(paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC))),
-		NSSAI_SST:       paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SST,
-		NSSAI_SD:        *paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SD,
+		CU_UP_ID:        fmt.Sprintf("0x%x", cuUpId),
+		SLICES:          getSliceTemplateValues(slices),
+		E2_AGENT:        getE2AgentTemplateValues(e2Agent),
 	}
 
 	configuration, err := renderConfigurationTemplateForCuUp(templateValues)
@@ -252,7 +322,7 @@ func (resource CuUpResources) GetConfigMap(log logr.Logger, ranDeployment *workl
 
 	configMap1 := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "oai-cu-up-configmap",
+			Name: resource.name() + "-configmap",
 		},
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -266,6 +336,16 @@ func (resource CuUpResources) GetConfigMap(log logr.Logger, ranDeployment *workl
 	return []*corev1.ConfigMap{configMap1}
 }
 
-func (resource CuUpResources) GetService() []*corev1.Service {
-	return []*corev1.Service{}
+func (resource CuUpResources) GetService(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*corev1.Service {
+	telnet, err := getTelnet(ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the telnet server from OAIConfig")
+		return nil
+	}
+
+	services := []*corev1.Service{}
+	if telnetService := getTelnetService(resource.name(), telnet); telnetService != nil {
+		services = append(services, telnetService)
+	}
+	return services
 }
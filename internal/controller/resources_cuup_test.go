@@ -95,11 +95,11 @@ func TestCreateNetworkAttachmentDefinitionNetworksCuUP(t *testing.T) {
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			dummyNfSpec.Interfaces = tc.inputInterfaceConfig
-			got, err := cuupResource.createNetworkAttachmentDefinitionNetworks("abc", &dummyNfSpec)
+			got, err := cuupResource.createNetworkAttachmentDefinitionNetworks("abc", &dummyNfSpec, nil)
 			if err != nil {
 				t.Errorf("CuupResource| createNetworkAttachmentDefinitionNetworks Error %v ", err)
 			}
-			if !compareStringLineByLineTrimmed(got, tc.want) {
+			if !compareJson(got, tc.want) {
 				t.Errorf("CuupResource| createNetworkAttachmentDefinitionNetworks returned %s wanted %s", got, tc.want)
 			}
 		})
@@ -166,14 +166,19 @@ func TestGetDeploymentCuUp(t *testing.T) {
 					Interfaces: []workloadv1alpha1.InterfaceConfig{
 						{
 							Name: "e1",
-							IPv4: &workloadv1alpha1.IPv4{
-								Address: "172.5.1.3/24", // Gateway is removed
-							},
+							// Neither IPv4 nor IPv6 address
 							VLANID: uint16Ptr(2),
 						},
 					},
 				},
 			},
+			configInfo: &ConfigInfo{
+				ConfigSelfInfo: map[string]runtime.RawExtension{
+					"OAIConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Image: "dummy-image"}}),
+					},
+				},
+			},
 			want: "error",
 		},
 		"OAI-Config Unmarshal Error": {
@@ -298,16 +303,86 @@ func TestGetConfigMapCuUp(t *testing.T) {
 		PLMN_MCC:        defaultParamsPlmn.Spec.PLMNInfo[0].PLMNID.MCC,
 		PLMN_MNC:        defaultParamsPlmn.Spec.PLMNInfo[0].PLMNID.MNC,
 		PLMN_MNC_LENGTH: strconv.Itoa(int(len(defaultParamsPlmn.Spec.PLMNInfo[0].PLMNID.MNC))),
-		NSSAI_SST:       defaultParamsPlmn.Spec.PLMNInfo[0].NSSAI[0].SST,
-		NSSAI_SD:        *defaultParamsPlmn.Spec.PLMNInfo[0].NSSAI[0].SD,
+		CU_UP_ID:        "0xe00",
+		SLICES:          []configurationTemplateValuesForSlice{{SST: 1, SD: "ffffff"}},
+	})
+	slicedParamsPlmn := *defaultParamsPlmn.DeepCopy()
+	slicedParamsPlmn.Spec.PLMNInfo[0].NSSAI = append(slicedParamsPlmn.Spec.PLMNInfo[0].NSSAI, workloadnfconfig.NSSAI{SST: 2})
+	slicedConfiguration, _ := renderConfigurationTemplateForCuUp(configurationTemplateValuesForCuUp{
+		E1_IP:           "\"172.5.1.3\"",
+		F1U_IP:          "\"172.6.0.7\"",
+		N3_IP:           "\"172.6.0.254\"",
+		CUCP_E1:         "\"172.5.1.3\"",
+		TAC:             defaultParamsPlmn.Spec.PLMNInfo[0].TAC,
+		PLMN_MCC:        defaultParamsPlmn.Spec.PLMNInfo[0].PLMNID.MCC,
+		PLMN_MNC:        defaultParamsPlmn.Spec.PLMNInfo[0].PLMNID.MNC,
+		PLMN_MNC_LENGTH: strconv.Itoa(int(len(defaultParamsPlmn.Spec.PLMNInfo[0].PLMNID.MNC))),
+		CU_UP_ID:        "0xe01",
+		SLICES:          []configurationTemplateValuesForSlice{{SST: 2}},
 	})
+	normalInterfaces := []workloadv1alpha1.InterfaceConfig{
+		{
+			Name: "e1",
+			IPv4: &workloadv1alpha1.IPv4{
+				Address: "172.5.1.3/24",
+				Gateway: ptr.To("172.5.1.1"),
+			},
+			VLANID: uint16Ptr(2),
+		}, {
+			Name: "n3",
+			IPv4: &workloadv1alpha1.IPv4{
+				Address: "172.6.0.254/24",
+				Gateway: ptr.To("172.6.0.1"),
+			},
+			VLANID: uint16Ptr(6),
+		}, {
+			Name: "f1u",
+			IPv4: &workloadv1alpha1.IPv4{
+				Address: "172.6.0.7/24",
+				Gateway: ptr.To("172.6.0.1"),
+			},
+			VLANID: uint16Ptr(7),
+		},
+	}
+	cuCpNfDeploymentSpec := workloadv1alpha1.NFDeploymentSpec{
+		Provider: "cucp.openairinterface.org",
+		Interfaces: []workloadv1alpha1.InterfaceConfig{
+			{
+				Name: "e1",
+				IPv4: &workloadv1alpha1.IPv4{
+					Address: "172.5.1.3/24",
+					Gateway: ptr.To("172.5.1.1"),
+				},
+				VLANID: uint16Ptr(2),
+			},
+		},
+	}
 
 	cases := map[string]struct {
+		annotations            map[string]string
 		ranDeploymentSpec      workloadv1alpha1.NFDeploymentSpec
 		configNfDeploymentSpec workloadv1alpha1.NFDeploymentSpec
 		configSelfInfo         map[string]runtime.RawExtension
 		wantedConfiguration    string
 	}{
+		"Second CU-UP Serving One Slice": {
+			annotations:            map[string]string{CuUpIdAnnotation: "0xe01", SlicesAnnotation: "2"},
+			ranDeploymentSpec:      workloadv1alpha1.NFDeploymentSpec{Interfaces: normalInterfaces},
+			configNfDeploymentSpec: cuCpNfDeploymentSpec,
+			configSelfInfo: map[string]runtime.RawExtension{
+				"PLMN": runtime.RawExtension{Raw: marshalJsonReturnByteOnly(slicedParamsPlmn)},
+			},
+			wantedConfiguration: slicedConfiguration,
+		},
+		"Slice Not In PLMN": {
+			annotations:            map[string]string{SlicesAnnotation: "3"},
+			ranDeploymentSpec:      workloadv1alpha1.NFDeploymentSpec{Interfaces: normalInterfaces},
+			configNfDeploymentSpec: cuCpNfDeploymentSpec,
+			configSelfInfo: map[string]runtime.RawExtension{
+				"PLMN": runtime.RawExtension{Raw: marshalJsonReturnByteOnly(slicedParamsPlmn)},
+			},
+			wantedConfiguration: "nil",
+		},
 		"Normal": {
 			ranDeploymentSpec: workloadv1alpha1.NFDeploymentSpec{
 				Interfaces: []workloadv1alpha1.InterfaceConfig{
@@ -483,7 +558,8 @@ func TestGetConfigMapCuUp(t *testing.T) {
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			ranDeploymentDummy := workloadv1alpha1.NFDeployment{
-				Spec: tc.ranDeploymentSpec,
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec:       tc.ranDeploymentSpec,
 			}
 			configInstanceMap := map[string][]*configref.Config{
 				"NFDeployment": []*configref.Config{
@@ -521,7 +597,7 @@ func TestGetConfigMapCuUp(t *testing.T) {
 
 func TestGetServiceCuUp(t *testing.T) {
 	cuupResource := CuUpResources{}
-	got := cuupResource.GetService()
+	got := cuupResource.GetService(log.Log, &workloadv1alpha1.NFDeployment{}, &ConfigInfo{})
 	/*
 		More cases will be added when more code will be added to GetService
 	*/
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 
 	"github.com/go-logr/logr"
@@ -26,33 +27,118 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	resourcev1 "k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
 
+const (
+	// DuResourceNameAnnotation records on a DU the prefix of its resources when they are created
+	DuResourceNameAnnotation = "workload.nephio.org/du-resource-name"
+
+	// legacyDuResourceName is the prefix of the resources of the DUs created by the releases naming them all the same
+	legacyDuResourceName = "oai-du"
+)
+
 type DuResources struct {
+	// Name prefixes the resources of the DU, "oai-du" when empty
+	Name string
 }
 
-func (resource DuResources) createNetworkAttachmentDefinitionNetworks(templateName string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) (string, error) {
-	return CreateNetworkAttachmentDefinitionNetworks(templateName, map[string][]workloadv1alpha1.InterfaceConfig{
-		"f1": GetInterfaceConfigs(ranDeploymentSpec.Interfaces, "f1"),
-	})
+func (resource DuResources) name() string {
+	if resource.Name == "" {
+		return legacyDuResourceName
+	}
+	return resource.Name
+}
+
+// getDuResourceName returns the prefix of the resources of a DU, built from the name of its NFDeployment
+// so that several DUs can share a namespace. The DUs created by the previous releases have the finalizer but not the
+// DuResourceNameAnnotation, their resources keep the legacy prefix so that they are still deleted with them
+func getDuResourceName(ranDeployment *workloadv1alpha1.NFDeployment) string {
+	if name, found := ranDeployment.Annotations[DuResourceNameAnnotation]; found {
+		return name
+	}
+	if controllerutil.ContainsFinalizer(ranDeployment, finalizerName) {
+		return legacyDuResourceName
+	}
+	return "oai-du-" + ranDeployment.Name
+}
+
+// getRadioUnit returns the radio mode of the cell and its RU parameters with the defaults applied
+func getRadioUnit(ranConfigSpec *workloadnfconfig.RANConfigSpec) (workloadnfconfig.RadioMode, workloadnfconfig.RadioUnit, error) {
+	radioMode := ranConfigSpec.RadioMode
+	if radioMode == "" {
+		radioMode = workloadnfconfig.RadioModeRFSim
+	}
+
+	radioUnit := workloadnfconfig.RadioUnit{}
+	if ranConfigSpec.RadioUnit != nil {
+		radioUnit = *ranConfigSpec.RadioUnit
+	}
+
+	switch radioMode {
+	case workloadnfconfig.RadioModeRFSim:
+		radioUnit.SdrAddrs = ""
+	case workloadnfconfig.RadioModeUSRP:
+		if radioUnit.SdrAddrs == "" {
+			return radioMode, radioUnit, fmt.Errorf("missing `RANConfig.radioUnit.sdrAddrs` for radio mode %q", radioMode)
+		}
+	default:
+		return radioMode, radioUnit, fmt.Errorf("radio mode %q not supported", radioMode)
+	}
+
+	if radioUnit.ClockSource == "" {
+		radioUnit.ClockSource = "internal"
+	}
+	if radioUnit.NbTx == 0 {
+		radioUnit.NbTx = 1
+	}
+	if radioUnit.NbRx == 0 {
+		radioUnit.NbRx = 1
+	}
+	if radioUnit.MaxRxGain == 0 {
+		radioUnit.MaxRxGain = 114
+	}
+	return radioMode, radioUnit, nil
+}
+
+// getInterfaceConfigs returns the interfaces of the NF attached through Multus
+func (resource DuResources) getInterfaceConfigs(ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec) map[string][]workloadv1alpha1.InterfaceConfig {
+	return getInterfaceConfigsOfProvider("du.openairinterface.org", ranDeploymentSpec)
+}
+
+func (resource DuResources) createNetworkAttachmentDefinitionNetworks(templateName string, ranDeploymentSpec *workloadv1alpha1.NFDeploymentSpec, networkConfigs []workloadnfconfig.NetworkConfig) (string, error) {
+	return CreateNetworkAttachmentDefinitionNetworks(templateName, resource.getInterfaceConfigs(ranDeploymentSpec), networkConfigs)
+}
+
+func (resource DuResources) GetNetworkAttachmentDefinitions(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) ([]*unstructured.Unstructured, error) {
+	return getNetworkAttachmentDefinitions(ranDeployment, configInfo, resource.getInterfaceConfigs(&ranDeployment.Spec))
 }
 
 func (resource DuResources) GetConfigMap(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*corev1.ConfigMap {
 
-	f1cIp, err := GetFirstInterfaceConfigIPv4(ranDeployment.Spec.Interfaces, "f1")
+	interfaceValues, err := getInterfaceTemplateValues("du.openairinterface.org", &ranDeployment.Spec)
 	if err != nil {
-		log.Error(err, "Interface f1-du not found in RANDeployment Spec")
+		log.Error(err, "Interfaces not found in RANDeployment Spec")
 		return nil
 	}
 
-	quotedF1Ip := strconv.Quote(f1cIp)
+	f1cIp, err := getFirstInterfaceIPOfProvider("du.openairinterface.org", &ranDeployment.Spec, "f1c", "f1")
+	if err != nil {
+		log.Error(err, "Interface f1c or f1 not found in RANDeployment Spec")
+		return nil
+	}
 
-	ranDeploymentConfigRef := getConfigInstanceByProvider(log, configInfo.ConfigRefInfo["NFDeployment"], "cucp.openairinterface.org")
+	ranDeploymentConfigRef, err := getCuCpDeployment(log, ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "CU-CP not found in Config Refs RANDeployment")
+		return nil
+	}
 
-	cuCpIp, err := GetFirstInterfaceConfigIPv4(ranDeploymentConfigRef.Spec.Interfaces, "f1c")
+	cuCpIp, err := getPeerInterfaceIPOfProvider(f1cIp, "cucp.openairinterface.org", &ranDeploymentConfigRef.Spec, "f1c")
 	if err != nil {
 		log.Error(err, "f1c not found in Config Refs RANDeployment")
 		return nil
@@ -72,23 +158,56 @@ func (resource DuResources) GetConfigMap(log logr.Logger, ranDeployment *workloa
 		return nil
 	}
 
+	if err := CheckCellUniqueness(map[string][]workloadnfconfig.RANCellConfig{ranDeployment.Name: paramsRanNf.Spec.GetCells()}); err != nil {
+		log.Error(err, "Invalid cells in RANConfig")
+		return nil
+	}
+
+	radioMode, radioUnit, err := getRadioUnit(&paramsRanNf.Spec)
+	if err != nil {
+		log.Error(err, "Invalid radio unit in RANConfig")
+		return nil
+	}
+
+	quotedSdrAddrs := ""
+	if radioUnit.SdrAddrs != "" {
+		quotedSdrAddrs = strconv.Quote(radioUnit.SdrAddrs)
+	}
+
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the E2 agent from OAIConfig")
+		return nil
+	}
+
+	duId, err := getDuIdOrDefault(ranDeployment)
+	if err != nil {
+		log.Error(err, "Invalid gNB_DU_ID")
+		return nil
+	}
+
 	templateValues := configurationTemplateValuesForDu{
-		F1C_DU_IP:       quotedF1Ip,
+		GNB_ID:          fmt.Sprintf("0x%x", gnbId),
+		GNB_DU_ID:       fmt.Sprintf("0x%x", duId),
+		F1C_DU_IP:       interfaceValues["F1C_DU_IP"],
+		F1U_DU_IP:       interfaceValues["F1U_DU_IP"],
 		F1C_CU_IP:       quotedCuCpIp,
 		TAC:             paramsPlmn.Spec.PLMNInfo[0].TAC,
-		CELL_ID:         paramsRanNf.Spec.CellIdentity,
-		PHY_CELL_ID:     paramsRanNf.Spec.PhysicalCellID,
-		DL_FREQ_BAND:    paramsRanNf.Spec.DownlinkFrequencyBand,
-		DL_SCS:          paramsRanNf.Spec.DownlinkSubCarrierSpacing,
-		DL_CARRIER_BW:   paramsRanNf.Spec.DownlinkCarrierBandwidth,
-		UL_FREQ_BAND:    paramsRanNf.Spec.UplinkFrequencyBand,
-		UL_SCS:          paramsRanNf.Spec.UplinkSubCarrierSpacing,
-		UL_CARRIER_BW:   paramsRanNf.Spec.UplinkCarrierBandwidth,
+		CELLS:           getCellTemplateValues(&paramsRanNf.Spec),
 		PLMN_MCC:        paramsPlmn.Spec.PLMNInfo[0].PLMNID.MCC,
 		PLMN_MNC:        paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC,
 		PLMN_MNC_LENGTH: strconv.Itoa(int(len(paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC))),
 		NSSAI_SST:       paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SST,
 		NSSAI_SD:        *paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SD,
+		RFSIM:           radioMode == workloadnfconfig.RadioModeRFSim,
+		RU_SDR_ADDRS:    quotedSdrAddrs,
+		RU_CLOCK_SRC:    strconv.Quote(radioUnit.ClockSource),
+		RU_NB_TX:        radioUnit.NbTx,
+		RU_NB_RX:        radioUnit.NbRx,
+		RU_ATT_TX:       radioUnit.AttTx,
+		RU_ATT_RX:       radioUnit.AttRx,
+		RU_MAX_RXGAIN:   radioUnit.MaxRxGain,
+		E2_AGENT:        getE2AgentTemplateValues(e2Agent),
 	}
 
 	configuration, err := renderConfigurationTemplateForDu(templateValues)
@@ -102,7 +221,7 @@ func (resource DuResources) GetConfigMap(log logr.Logger, ranDeployment *workloa
 			"gnb.conf": configuration,
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "oai-du-configmap",
+			Name: resource.name() + "-configmap",
 		},
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -116,33 +235,96 @@ func (resource DuResources) GetConfigMap(log logr.Logger, ranDeployment *workloa
 func (resource DuResources) GetDeployment(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*appsv1.Deployment {
 	spec := ranDeployment.Spec
 
-	networkAttachmentDefinitionNetworks, err := resource.createNetworkAttachmentDefinitionNetworks(ranDeployment.Name, &spec)
+	paramsOAI := &workloadnfconfig.OAIConfig{}
+	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, paramsOAI); err != nil {
+		log.Error(err, "Cannot Unmarshal OAIConfig")
+		return nil
+	}
+
+	networkAttachmentDefinitionNetworks, err := resource.createNetworkAttachmentDefinitionNetworks(ranDeployment.Name, &spec, paramsOAI.Spec.Networks)
 
 	if err != nil {
+		log.Error(err, "Cannot create the networks annotation")
 		return nil
 	}
 
-	paramsOAI := &workloadnfconfig.OAIConfig{}
-	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, paramsOAI); err != nil {
-		log.Error(err, "Cannot Unmarshal OAIConfig")
+	runtimeOptions, err := getRuntimeOptions(ranDeployment.Spec.Provider, paramsOAI.Spec.Runtime)
+	if err != nil {
+		log.Error(err, "Invalid runtime options in OAIConfig")
+		return nil
+	}
+
+	paramsRanNf := &workloadnfconfig.RANConfig{}
+	if err := json.Unmarshal(configInfo.ConfigSelfInfo["RANConfig"].Raw, paramsRanNf); err != nil {
+		log.Error(err, "Cannot Unmarshal RANConfig")
+		return nil
+	}
+
+	radioMode, _, err := getRadioUnit(&paramsRanNf.Spec)
+	if err != nil {
+		log.Error(err, "Invalid radio unit in RANConfig")
 		return nil
 	}
 
 	podAnnotations := make(map[string]string)
 	podAnnotations[NetworksAnnotation] = networkAttachmentDefinitionNetworks
 
+	requiredOptions := []string{"--sa"}
+	if radioMode == workloadnfconfig.RadioModeRFSim {
+		requiredOptions = append(requiredOptions, "--rfsim")
+	}
+
+	volumes := []corev1.Volume{
+
+		corev1.Volume{
+			Name: "configuration",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: resource.name() + "-configmap",
+					},
+				},
+			},
+		},
+	}
+	volumeMounts := []corev1.VolumeMount{
+
+		corev1.VolumeMount{
+			Name:      "configuration",
+			ReadOnly:  false,
+			SubPath:   "gnb.conf",
+			MountPath: "/opt/oai-gnb/etc/gnb.conf",
+		},
+	}
+	if radioMode == workloadnfconfig.RadioModeUSRP {
+		// USB attached radios (B2xx) are reached through the host usb bus, network attached ones (N3xx, X3xx) through the f1/sdr interfaces
+		volumes = append(volumes, corev1.Volume{
+			Name: "usrp-devices",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: "/dev/bus/usb",
+					Type: ptr.To(corev1.HostPathDirectory),
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "usrp-devices",
+			MountPath: "/dev/bus/usb",
+		})
+	}
+
 	deployment1 := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: map[string]string{
-				"app.kubernetes.io/name": "oai-du",
+				"app.kubernetes.io/name": resource.name(),
 			},
-			Name: "oai-du",
+			Name: resource.name(),
 		},
 		Spec: appsv1.DeploymentSpec{
 			Paused: false,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					"app.kubernetes.io/name": "oai-du",
+					"app.kubernetes.io/name": resource.name(),
 				},
 			},
 			Strategy: appsv1.DeploymentStrategy{
@@ -152,36 +334,27 @@ func (resource DuResources) GetDeployment(log logr.Logger, ranDeployment *worklo
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: podAnnotations,
 					Labels: map[string]string{
-						"app":                    "oai-du",
-						"app.kubernetes.io/name": "oai-du",
+						"app":                    resource.name(),
+						"app.kubernetes.io/name": resource.name(),
 					},
 				},
 				Spec: corev1.PodSpec{
 					HostIPC:                       false,
 					HostNetwork:                   false,
-					ServiceAccountName:            "oai-du-sa",
+					ServiceAccountName:            resource.name() + "-sa",
 					TerminationGracePeriodSeconds: ptr.To(int64(5)),
-					Volumes: []corev1.Volume{
-
-						corev1.Volume{
-							Name: "configuration",
-							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: "oai-du-configmap",
-									},
-								},
-							},
-						},
-					},
+					Volumes:                       volumes,
 					Containers: []corev1.Container{
 
 						corev1.Container{
 							Env: []corev1.EnvVar{
 								corev1.EnvVar{
-									Name: "USE_ADDITIONAL_OPTIONS",
-									Value: "--sa --rfsim --log_config.global_log_options level,nocolor,time" +
-										" --telnetsrv --telnetsrv.shrmod o1 --telnetsrv.listenaddr 192.168.74.2",
+									Name:  "TZ",
+									Value: runtimeOptions.Timezone,
+								},
+								corev1.EnvVar{
+									Name:  "USE_ADDITIONAL_OPTIONS",
+									Value: getAdditionalOptions(requiredOptions, runtimeOptions),
 								},
 							},
 							Image: paramsOAI.Spec.Image,
@@ -208,22 +381,11 @@ func (resource DuResources) GetDeployment(log logr.Logger, ranDeployment *worklo
 									corev1.ResourceMemory: resourcev1.MustParse("1Gi"),
 								},
 							},
-							Stdin: false,
-							TTY:   false,
-							VolumeMounts: []corev1.VolumeMount{
-
-								corev1.VolumeMount{
-									Name:      "configuration",
-									ReadOnly:  false,
-									SubPath:   "gnb.conf",
-									MountPath: "/opt/oai-gnb/etc/gnb.conf",
-								},
-							},
-							Name: "du",
-							SecurityContext: &corev1.SecurityContext{
-								Privileged: ptr.To(true),
-							},
-							StdinOnce: false,
+							Stdin:        false,
+							TTY:          false,
+							VolumeMounts: volumeMounts,
+							Name:         "du",
+							StdinOnce:    false,
 						},
 					},
 					DNSPolicy:     corev1.DNSPolicy("ClusterFirst"),
@@ -239,6 +401,41 @@ func (resource DuResources) GetDeployment(log logr.Logger, ranDeployment *worklo
 		},
 	}
 
+	addRuntimeEnv(&deployment1.Spec.Template.Spec.Containers[0], runtimeOptions)
+
+	telnet, err := getTelnet(ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the telnet server from OAIConfig")
+		return nil
+	}
+	if telnet != nil {
+		listenAddress, err := getTelnetListenAddress(ranDeployment, telnet)
+		if err != nil {
+			log.Error(err, "Cannot get the listen address of the telnet server")
+			return nil
+		}
+		addTelnetServer(&deployment1.Spec.Template.Spec.Containers[0], ranDeployment, telnet, listenAddress)
+	}
+
+	e2Agent, err := getE2Agent(configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the E2 agent from OAIConfig")
+		return nil
+	}
+	addE2ServiceModels(&deployment1.Spec.Template.Spec, e2Agent, paramsOAI.Spec.Image)
+
+	if err := applyCompute(&deployment1.Spec.Template.Spec, paramsOAI.Spec.Compute); err != nil {
+		log.Error(err, "Invalid compute config in OAIConfig")
+		return nil
+	}
+
+	if err := applySecurity(&deployment1.Spec.Template.Spec, ranDeployment.Spec.Provider, paramsOAI.Spec.Security); err != nil {
+		log.Error(err, "Invalid security config in OAIConfig")
+		return nil
+	}
+
+	addProbes(&deployment1.Spec.Template.Spec.Containers[0], ranDeployment.Spec.Provider, paramsOAI.Spec.Probes, telnet)
+
 	return []*appsv1.Deployment{deployment1}
 }
 
@@ -246,7 +443,7 @@ func (resource DuResources) GetServiceAccount() []*corev1.ServiceAccount {
 
 	serviceAccount1 := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "oai-du-sa",
+			Name: resource.name() + "-sa",
 		},
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -257,18 +454,23 @@ func (resource DuResources) GetServiceAccount() []*corev1.ServiceAccount {
 	return []*corev1.ServiceAccount{serviceAccount1}
 }
 
-func (resource DuResources) GetService() []*corev1.Service {
+func (resource DuResources) GetService(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) []*corev1.Service {
+	telnet, err := getTelnet(ranDeployment, configInfo)
+	if err != nil {
+		log.Error(err, "Cannot get the telnet server from OAIConfig")
+		return nil
+	}
 
 	service1 := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Labels: map[string]string{
-				"app.kubernetes.io/name": "oai-du",
+				"app.kubernetes.io/name": resource.name(),
 			},
-			Name: "oai-du",
+			Name: resource.name(),
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: map[string]string{
-				"app.kubernetes.io/name": "oai-du",
+				"app.kubernetes.io/name": resource.name(),
 			},
 			Type:      corev1.ServiceType("ClusterIP"),
 			ClusterIP: "None",
@@ -307,35 +509,12 @@ func (resource DuResources) GetService() []*corev1.Service {
 		},
 	}
 
-	// Telent Service
-	service2 := &corev1.Service{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Service",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Labels: map[string]string{
-				"app.kubernetes.io/name": "oai-du-telnet-lb",
-			},
-			Name: "oai-du-telnet-lb",
-		},
-		Spec: corev1.ServiceSpec{
-			Selector: map[string]string{
-				"app.kubernetes.io/name": "oai-du",
-			},
-			Type: corev1.ServiceType("LoadBalancer"),
-			Ports: []corev1.ServicePort{
-				corev1.ServicePort{
-					Port:     9090,
-					Protocol: corev1.Protocol("TCP"),
-					NodePort: 32500,
-					TargetPort: intstr.IntOrString{
-						IntVal: 9090,
-					},
-				},
-			},
-		},
+	ipFamilies := GetIPFamilies(ranDeployment.Spec.Interfaces)
+	SetServiceIPFamilies(service1, ipFamilies)
+	services := []*corev1.Service{service1}
+	if telnetService := getTelnetService(resource.name(), telnet); telnetService != nil {
+		SetServiceIPFamilies(telnetService, ipFamilies)
+		services = append(services, telnetService)
 	}
-
-	return []*corev1.Service{service1, service2}
+	return services
 }
@@ -17,8 +17,10 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
@@ -26,10 +28,18 @@ import (
 
 	configref "github.com/nephio-project/api/references/v1alpha1"
 	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
 )
@@ -50,21 +60,18 @@ func marshalJsonReturnByteOnly(obj any) []byte {
 	return marshalObj
 }
 
-func compareStringLineByLineTrimmed(str1 string, str2 string) bool {
-	str1List := strings.Split(str1, "\n")
-	str2List := strings.Split(str2, "\n")
-	if len(str1List) != len(str2List) {
-		fmt.Println("length doesn't match in compare string LineByLine | ", len(str1List), " | ", len(str2List))
+// compareJson compares two JSON documents regardless of their formatting
+func compareJson(json1 string, json2 string) bool {
+	var value1, value2 any
+	if err := json.Unmarshal([]byte(json1), &value1); err != nil {
+		fmt.Println("invalid JSON in compareJson | ", err)
 		return false
 	}
-	for i := 0; i < len(str1List); i++ {
-		str1List[i] = strings.Trim(str1List[i], "\t")
-		str2List[i] = strings.Trim(str2List[i], "\t")
-		if strings.TrimSpace(str1List[i]) != strings.TrimSpace(str2List[i]) {
-			return false
-		}
+	if err := json.Unmarshal([]byte(json2), &value2); err != nil {
+		fmt.Println("invalid JSON in compareJson | ", err)
+		return false
 	}
-	return true
+	return reflect.DeepEqual(value1, value2)
 }
 
 func TestCreateNetworkAttachmentDefinitionNetworksDu(t *testing.T) {
@@ -101,16 +108,84 @@ func TestCreateNetworkAttachmentDefinitionNetworksDu(t *testing.T) {
 				}
 			   ] `,
 		},
+		"With E2": {
+			inputInterfaceConfig: []workloadv1alpha1.InterfaceConfig{
+				{
+					Name: "f1",
+					IPv4: &workloadv1alpha1.IPv4{
+						Address: "172.5.1.3/24",
+						Gateway: ptr.To("172.5.1.1"),
+					},
+					VLANID: uint16Ptr(2),
+				},
+				{
+					Name: "e2",
+					IPv4: &workloadv1alpha1.IPv4{
+						Address: "172.9.0.3/24",
+						Gateway: ptr.To("172.9.0.1"),
+					},
+					VLANID: uint16Ptr(9),
+				},
+			},
+			want: `[
+				{
+				 "name": "abc-e2",
+				 "interface": "e2",
+				 "ips": ["172.9.0.3/24"],
+				 "gateways": ["172.9.0.1"]
+				},
+				{
+				 "name": "abc-f1",
+				 "interface": "f1",
+				 "ips": ["172.5.1.3/24"],
+				 "gateways": ["172.5.1.1"]
+				}
+			   ] `,
+		},
+		"Split F1": {
+			inputInterfaceConfig: []workloadv1alpha1.InterfaceConfig{
+				{
+					Name: "f1c",
+					IPv4: &workloadv1alpha1.IPv4{
+						Address: "172.5.1.3/24",
+						Gateway: ptr.To("172.5.1.1"),
+					},
+					VLANID: uint16Ptr(2),
+				},
+				{
+					Name: "f1u",
+					IPv4: &workloadv1alpha1.IPv4{
+						Address: "172.6.1.3/24",
+						Gateway: ptr.To("172.6.1.1"),
+					},
+					VLANID: uint16Ptr(3),
+				},
+			},
+			want: `[
+				{
+				 "name": "abc-f1c",
+				 "interface": "f1c",
+				 "ips": ["172.5.1.3/24"],
+				 "gateways": ["172.5.1.1"]
+				},
+				{
+				 "name": "abc-f1u",
+				 "interface": "f1u",
+				 "ips": ["172.6.1.3/24"],
+				 "gateways": ["172.6.1.1"]
+				}
+			   ] `,
+		},
 	}
 	duresource := DuResources{}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
 			dummyNfSpec.Interfaces = tc.inputInterfaceConfig
-			got, err := duresource.createNetworkAttachmentDefinitionNetworks("abc", &dummyNfSpec)
+			got, err := duresource.createNetworkAttachmentDefinitionNetworks("abc", &dummyNfSpec, nil)
 			if err != nil {
 				t.Errorf("DuResource| createNetworkAttachmentDefinitionNetworks Error %v ", err)
 			}
-			if !compareStringLineByLineTrimmed(got, tc.want) {
+			if !compareJson(got, tc.want) {
 				t.Errorf("DuResource| createNetworkAttachmentDefinitionNetworks returned %s wanted %s", got, tc.want)
 			}
 		})
@@ -188,8 +263,10 @@ func TestGetConfigMapDu(t *testing.T) {
 			},
 			paramsRanNf: workloadnfconfig.RANConfig{
 				Spec: workloadnfconfig.RANConfigSpec{
-					CellIdentity:   "12345678L",
-					PhysicalCellID: uint32(0),
+					RANCellConfig: workloadnfconfig.RANCellConfig{
+						CellIdentity:   "12345678L",
+						PhysicalCellID: uint32(0),
+					},
 				},
 			},
 			paramsPlmn: workloadnfconfig.PLMN{
@@ -334,22 +411,34 @@ func TestGetConfigMapDu(t *testing.T) {
 			got := duresource.GetConfigMap(logger, &workloadv1alpha1.NFDeployment{Spec: tc.nfF1Spec}, &configInfo)
 			if tc.wantedError == "nil" {
 				defaultWantConfigurations, _ := renderConfigurationTemplateForDu(configurationTemplateValuesForDu{
-					F1C_DU_IP:       "\"172.5.1.3\"",
-					F1C_CU_IP:       "\"172.5.1.254\"",
-					TAC:             tc.paramsPlmn.Spec.PLMNInfo[0].TAC,
-					CELL_ID:         tc.paramsRanNf.Spec.CellIdentity,
-					PHY_CELL_ID:     tc.paramsRanNf.Spec.PhysicalCellID,
-					DL_FREQ_BAND:    tc.paramsRanNf.Spec.DownlinkFrequencyBand,
-					DL_SCS:          tc.paramsRanNf.Spec.DownlinkSubCarrierSpacing,
-					DL_CARRIER_BW:   tc.paramsRanNf.Spec.DownlinkCarrierBandwidth,
-					UL_FREQ_BAND:    tc.paramsRanNf.Spec.UplinkFrequencyBand,
-					UL_SCS:          tc.paramsRanNf.Spec.UplinkSubCarrierSpacing,
-					UL_CARRIER_BW:   tc.paramsRanNf.Spec.UplinkCarrierBandwidth,
+					GNB_ID:    "0xe00",
+					GNB_DU_ID: "0xe00",
+					F1C_DU_IP: "\"172.5.1.3\"",
+					F1C_CU_IP: "\"172.5.1.254\"",
+					TAC:       tc.paramsPlmn.Spec.PLMNInfo[0].TAC,
+					CELLS: []configurationTemplateValuesForCell{{
+						GNB_NAME:      "oai-du",
+						CELL_ID:       tc.paramsRanNf.Spec.CellIdentity,
+						PHY_CELL_ID:   tc.paramsRanNf.Spec.PhysicalCellID,
+						ABS_FREQ_SSB:  640704,
+						DL_POINT_A:    639996,
+						DL_FREQ_BAND:  tc.paramsRanNf.Spec.DownlinkFrequencyBand,
+						DL_SCS:        tc.paramsRanNf.Spec.DownlinkSubCarrierSpacing,
+						DL_CARRIER_BW: tc.paramsRanNf.Spec.DownlinkCarrierBandwidth,
+						UL_FREQ_BAND:  tc.paramsRanNf.Spec.UplinkFrequencyBand,
+						UL_SCS:        tc.paramsRanNf.Spec.UplinkSubCarrierSpacing,
+						UL_CARRIER_BW: tc.paramsRanNf.Spec.UplinkCarrierBandwidth,
+					}},
 					PLMN_MCC:        tc.paramsPlmn.Spec.PLMNInfo[0].PLMNID.MCC,
 					PLMN_MNC:        tc.paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC,
 					PLMN_MNC_LENGTH: strconv.Itoa(int(len(tc.paramsPlmn.Spec.PLMNInfo[0].PLMNID.MNC))),
 					NSSAI_SST:       tc.paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SST,
 					NSSAI_SD:        *tc.paramsPlmn.Spec.PLMNInfo[0].NSSAI[0].SD,
+					RFSIM:           true,
+					RU_CLOCK_SRC:    "\"internal\"",
+					RU_NB_TX:        1,
+					RU_NB_RX:        1,
+					RU_MAX_RXGAIN:   114,
 				})
 
 				if !reflect.DeepEqual(got[0].Data["gnb.conf"], defaultWantConfigurations) {
@@ -405,30 +494,108 @@ func TestGetDeploymentDu(t *testing.T) {
 					"OAIConfig": runtime.RawExtension{
 						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Image: "dummy-image"}}),
 					},
+					"RANConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.RANConfig{}),
+					},
 				},
 			},
 
 			want: "Pod-Annotations",
 		},
-		"NAD Error": {
+		"USRP Radio": {
 			ranDeployment: workloadv1alpha1.NFDeployment{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "dummy-du",
+					Name:      "dummy-du",
+					Namespace: "dummy-du-ns",
 				},
 				Spec: workloadv1alpha1.NFDeploymentSpec{
+					Provider: "du.openairinterface.org",
 					Interfaces: []workloadv1alpha1.InterfaceConfig{
 						{
 							Name: "f1",
 							IPv4: &workloadv1alpha1.IPv4{
-								Address: "172.5.1.3/24", // Gateway is removed
+								Address: "172.5.1.3/24",
+								Gateway: ptr.To("172.5.1.1"),
 							},
 							VLANID: uint16Ptr(2),
 						},
 					},
 				},
 			},
-			configInfo: &ConfigInfo{},
-			want:       "error",
+			configInfo: &ConfigInfo{
+				ConfigSelfInfo: map[string]runtime.RawExtension{
+					"OAIConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Image: "dummy-image"}}),
+					},
+					"RANConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.RANConfig{Spec: workloadnfconfig.RANConfigSpec{
+							RadioMode: workloadnfconfig.RadioModeUSRP,
+							RadioUnit: &workloadnfconfig.RadioUnit{SdrAddrs: "type=b200"},
+						}}),
+					},
+				},
+			},
+
+			want: "USRP",
+		},
+		"USRP Radio Without SDR Address": {
+			ranDeployment: workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dummy-du",
+					Namespace: "dummy-du-ns",
+				},
+				Spec: workloadv1alpha1.NFDeploymentSpec{
+					Provider: "du.openairinterface.org",
+					Interfaces: []workloadv1alpha1.InterfaceConfig{
+						{
+							Name: "f1",
+							IPv4: &workloadv1alpha1.IPv4{
+								Address: "172.5.1.3/24",
+								Gateway: ptr.To("172.5.1.1"),
+							},
+							VLANID: uint16Ptr(2),
+						},
+					},
+				},
+			},
+			configInfo: &ConfigInfo{
+				ConfigSelfInfo: map[string]runtime.RawExtension{
+					"OAIConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Image: "dummy-image"}}),
+					},
+					"RANConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.RANConfig{Spec: workloadnfconfig.RANConfigSpec{
+							RadioMode: workloadnfconfig.RadioModeUSRP,
+						}}),
+					},
+				},
+			},
+
+			want: "error",
+		},
+		"NAD Error": {
+			ranDeployment: workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "dummy-du",
+				},
+				Spec: workloadv1alpha1.NFDeploymentSpec{
+					Interfaces: []workloadv1alpha1.InterfaceConfig{
+						{
+							Name: "f1",
+							// Neither IPv4 nor IPv6 address
+							VLANID: uint16Ptr(2),
+						},
+					},
+				},
+			},
+			configInfo: &ConfigInfo{
+				ConfigSelfInfo: map[string]runtime.RawExtension{
+					"OAIConfig": runtime.RawExtension{
+						Raw: marshalJsonReturnByteOnly(&workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Image: "dummy-image"}}),
+					},
+				},
+			},
+			want: "error",
 		},
 		"OAI-Config Unmarshal Error": {
 			ranDeployment: workloadv1alpha1.NFDeployment{
@@ -490,6 +657,19 @@ func TestGetDeploymentDu(t *testing.T) {
 				if gotImage != "dummy-image" {
 					t.Errorf("Image Got %s wanted %s", gotImage, "dummy-image")
 				}
+				gotOptions := ""
+				for _, env := range got[0].Spec.Template.Spec.Containers[0].Env {
+					if env.Name == "USE_ADDITIONAL_OPTIONS" {
+						gotOptions = env.Value
+					}
+				}
+				if strings.Contains(gotOptions, "--rfsim") == (tc.want == "USRP") {
+					t.Errorf("USE_ADDITIONAL_OPTIONS %q doesn't match the radio mode of %s", gotOptions, name)
+				}
+				gotVolumes := got[0].Spec.Template.Spec.Volumes
+				if (len(gotVolumes) == 2) != (tc.want == "USRP") {
+					t.Errorf("Volumes %v don't match the radio mode of %s", gotVolumes, name)
+				}
 			}
 
 		})
@@ -529,8 +709,279 @@ func TestGetServiceAccountDu(t *testing.T) {
 
 func TestGetService(t *testing.T) {
 	duResource := DuResources{}
-	got := duResource.GetService()
-	if len(got) == 0 {
-		t.Errorf("GetService returned Empty Service ")
+	got := duResource.GetService(log.Log, &workloadv1alpha1.NFDeployment{
+		Spec: workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+	}, &ConfigInfo{})
+	if len(got) != 2 {
+		t.Fatalf("GetService returned %d Services, expected the DU and telnet Services", len(got))
+	}
+	if got[1].Name != "oai-du-telnet" || got[1].Spec.Type != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("GetService returned the telnet Service %s of type %s", got[1].Name, got[1].Spec.Type)
+	}
+}
+
+func TestTwoDusInOneNamespace(t *testing.T) {
+	firstDu, objects := loadGoldenInput(t, filepath.Join("testdata", "golden", "du-rfsim-band-n78"))
+	secondDu := firstDu.DeepCopy()
+	secondDu.Name = "du-edge-2"
+	scheme := newManagerScheme()
+	objects = append(objects, firstDu, secondDu)
+	r := RANDeploymentReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), Scheme: scheme}
+
+	names := map[string]string{}
+	duIds := map[string]string{}
+	for _, ranDeployment := range []*workloadv1alpha1.NFDeployment{firstDu, secondDu} {
+		if err := r.AllocateDuId(context.TODO(), ranDeployment); err != nil {
+			t.Fatalf("AllocateDuId of %s returned error %v", ranDeployment.Name, err)
+		}
+		nfResource, err := GetNfResource(ranDeployment)
+		if err != nil {
+			t.Fatalf("GetNfResource returned error %v", err)
+		}
+		configInfo, err := r.GetConfigs(context.TODO(), ranDeployment)
+		if err != nil {
+			t.Fatalf("GetConfigs returned error %v", err)
+		}
+		if errList := r.CreateAll(context.TODO(), ranDeployment, nfResource, configInfo); len(errList) != 0 {
+			t.Fatalf("CreateAll of %s returned errors %v", ranDeployment.Name, errList)
+		}
+
+		// The readiness, the telnet poller and the log inspector find the resources of the DU by these names
+		deploymentName := getDeploymentName(ranDeployment)
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: goldenNamespace, Name: deploymentName}, &appsv1.Deployment{}); err != nil {
+			t.Errorf("Deployment %s of %s not found: %v", deploymentName, ranDeployment.Name, err)
+		}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: goldenNamespace, Name: deploymentName + "-telnet"}, &corev1.Service{}); err != nil {
+			t.Errorf("telnet Service of %s not found: %v", ranDeployment.Name, err)
+		}
+		if owner, found := names[deploymentName]; found {
+			t.Errorf("%s and %s share the Deployment %s", owner, ranDeployment.Name, deploymentName)
+		}
+		names[deploymentName] = ranDeployment.Name
+
+		// The CU-CP rejects the F1 Setup of a DU reusing the gNB_DU_ID of another one
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: goldenNamespace, Name: deploymentName + "-configmap"}, configMap); err != nil {
+			t.Fatalf("ConfigMap of %s not found: %v", ranDeployment.Name, err)
+		}
+		duId := ranDeployment.Annotations[DuIdAnnotation]
+		if !strings.Contains(configMap.Data["gnb.conf"], "gNB_DU_ID = "+duId+";") {
+			t.Errorf("gnb.conf of %s doesn't use its gNB_DU_ID %s", ranDeployment.Name, duId)
+		}
+		if owner, found := duIds[duId]; found {
+			t.Errorf("%s and %s share the gNB_DU_ID %s", owner, ranDeployment.Name, duId)
+		}
+		duIds[duId] = ranDeployment.Name
+	}
+}
+
+func TestCreateAllUnsupportedNetworkType(t *testing.T) {
+	ranDeployment, objects := loadGoldenInput(t, filepath.Join("testdata", "golden", "du-rfsim-band-n78"))
+	recorder := record.NewFakeRecorder(10)
+	r := RANDeploymentReconciler{
+		Client:   fake.NewClientBuilder().WithScheme(newManagerScheme()).WithObjects(objects...).Build(),
+		Recorder: recorder,
+	}
+	configInfo, err := r.GetConfigs(context.TODO(), ranDeployment)
+	if err != nil {
+		t.Fatalf("GetConfigs returned error %v", err)
+	}
+	oaiConfig := workloadnfconfig.OAIConfig{}
+	if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, &oaiConfig); err != nil {
+		t.Fatalf("Cannot read the OAIConfig: %v", err)
+	}
+	oaiConfig.Spec.Networks = []workloadnfconfig.NetworkConfig{{Interface: ranDeployment.Spec.Interfaces[0].Name, Type: "ovs", Master: "br-ran"}}
+	configInfo.ConfigSelfInfo["OAIConfig"] = runtime.RawExtension{Raw: marshalJsonReturnByteOnly(oaiConfig)}
+	duResource := DuResources{Name: getDuResourceName(ranDeployment)}
+
+	errList := r.CreateAll(context.TODO(), ranDeployment, duResource, configInfo)
+	if len(errList) != 1 || !strings.Contains(errList[0].Error(), "not supported network type") {
+		t.Errorf("CreateAll returned the errors %v, expected the one of the network type", errList)
+	}
+	// The pods would wait for the networks forever
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(context.TODO(), deployments, client.InNamespace(goldenNamespace)); err != nil || len(deployments.Items) != 0 {
+		t.Errorf("CreateAll created the Deployments %v (%v), expected none", deployments.Items, err)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, ReasonRenderFailed) {
+		t.Errorf("CreateAll recorded the event %q, expected %s", event, ReasonRenderFailed)
+	}
+
+	if _, err := renderResources(context.TODO(), ranDeployment, configInfo); err == nil || !strings.Contains(err.Error(), "not supported network type") {
+		t.Errorf("renderResources returned error %v, expected the one of the network type", err)
+	}
+}
+
+func TestGetRadioUnit(t *testing.T) {
+	cases := map[string]struct {
+		ranConfigSpec workloadnfconfig.RANConfigSpec
+		wantMode      workloadnfconfig.RadioMode
+		wantRadioUnit workloadnfconfig.RadioUnit
+		wantError     bool
+	}{
+		"Default rfsim": {
+			ranConfigSpec: workloadnfconfig.RANConfigSpec{},
+			wantMode:      workloadnfconfig.RadioModeRFSim,
+			wantRadioUnit: workloadnfconfig.RadioUnit{ClockSource: "internal", NbTx: 1, NbRx: 1, MaxRxGain: 114},
+		},
+		"USRP": {
+			ranConfigSpec: workloadnfconfig.RANConfigSpec{
+				RadioMode: workloadnfconfig.RadioModeUSRP,
+				RadioUnit: &workloadnfconfig.RadioUnit{SdrAddrs: "addr=192.168.10.2", ClockSource: "external", NbTx: 2, NbRx: 2, AttTx: 10, MaxRxGain: 90},
+			},
+			wantMode:      workloadnfconfig.RadioModeUSRP,
+			wantRadioUnit: workloadnfconfig.RadioUnit{SdrAddrs: "addr=192.168.10.2", ClockSource: "external", NbTx: 2, NbRx: 2, AttTx: 10, MaxRxGain: 90},
+		},
+		"USRP Without SDR Address": {
+			ranConfigSpec: workloadnfconfig.RANConfigSpec{RadioMode: workloadnfconfig.RadioModeUSRP},
+			wantError:     true,
+		},
+		"Unknown Radio Mode": {
+			ranConfigSpec: workloadnfconfig.RANConfigSpec{RadioMode: "oran-7.2"},
+			wantError:     true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			gotMode, gotRadioUnit, err := getRadioUnit(&tc.ranConfigSpec)
+			if tc.wantError {
+				if err == nil {
+					t.Errorf("getRadioUnit returned no error for %v", tc.ranConfigSpec)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("getRadioUnit returned error %v", err)
+			}
+			if gotMode != tc.wantMode || !reflect.DeepEqual(gotRadioUnit, tc.wantRadioUnit) {
+				t.Errorf("getRadioUnit returned %s %v wanted %s %v", gotMode, gotRadioUnit, tc.wantMode, tc.wantRadioUnit)
+			}
+		})
+	}
+}
+
+func TestRenderConfigurationTemplateForDuRadioMode(t *testing.T) {
+	rfsimConfiguration, err := renderConfigurationTemplateForDu(configurationTemplateValuesForDu{CELLS: []configurationTemplateValuesForCell{{DL_FREQ_BAND: 78}}, RFSIM: true, RU_CLOCK_SRC: "\"internal\""})
+	if err != nil {
+		t.Fatalf("renderConfigurationTemplateForDu returned error %v", err)
+	}
+	if !strings.Contains(rfsimConfiguration, "rfsimulator:") || strings.Contains(rfsimConfiguration, "sdr_addrs") {
+		t.Errorf("rfsim configuration must contain the rfsimulator block and no sdr_addrs:\n%s", rfsimConfiguration)
+	}
+
+	usrpConfiguration, err := renderConfigurationTemplateForDu(configurationTemplateValuesForDu{CELLS: []configurationTemplateValuesForCell{{DL_FREQ_BAND: 41}}, RU_SDR_ADDRS: "\"type=b200\"", RU_CLOCK_SRC: "\"internal\""})
+	if err != nil {
+		t.Fatalf("renderConfigurationTemplateForDu returned error %v", err)
+	}
+	if strings.Contains(usrpConfiguration, "rfsimulator:") || !strings.Contains(usrpConfiguration, `sdr_addrs = "type=b200";`) {
+		t.Errorf("usrp configuration must contain sdr_addrs and no rfsimulator block:\n%s", usrpConfiguration)
+	}
+	if !strings.Contains(usrpConfiguration, "bands          = [41];") {
+		t.Errorf("usrp configuration must derive the RU bands from the DL band:\n%s", usrpConfiguration)
+	}
+}
+
+func TestRenderConfigurationTemplateForDuCells(t *testing.T) {
+	configuration, err := renderConfigurationTemplateForDu(configurationTemplateValuesForDu{
+		GNB_ID:    "0xe00",
+		GNB_DU_ID: "0xe01",
+		CELLS:     []configurationTemplateValuesForCell{{GNB_NAME: "oai-du"}, {GNB_NAME: "oai-du-1"}},
+	})
+	if err != nil {
+		t.Fatalf("renderConfigurationTemplateForDu returned error %v", err)
+	}
+	// Every cell belongs to the same gNB and DU
+	if strings.Count(configuration, "gNB_ID = 0xe00;") != 2 || strings.Count(configuration, "gNB_DU_ID = 0xe01;") != 2 {
+		t.Errorf("every cell must have the gNB_ID and gNB_DU_ID of the DU:\n%s", configuration)
+	}
+}
+
+func TestRenderConfigurationTemplateForDuSplitF1(t *testing.T) {
+	singleF1Configuration, err := renderConfigurationTemplateForDu(configurationTemplateValuesForDu{CELLS: []configurationTemplateValuesForCell{{}}, F1C_DU_IP: `"172.5.1.3"`})
+	if err != nil {
+		t.Fatalf("renderConfigurationTemplateForDu returned error %v", err)
+	}
+	if !strings.Contains(singleF1Configuration, `local_n_address = "172.5.1.3";`) || strings.Contains(singleF1Configuration, "local_n_address_f1u") {
+		t.Errorf("single f1 configuration must only contain local_n_address:\n%s", singleF1Configuration)
+	}
+
+	splitF1Configuration, err := renderConfigurationTemplateForDu(configurationTemplateValuesForDu{CELLS: []configurationTemplateValuesForCell{{}}, F1C_DU_IP: `"172.5.1.3"`, F1U_DU_IP: `"172.6.1.3"`})
+	if err != nil {
+		t.Fatalf("renderConfigurationTemplateForDu returned error %v", err)
+	}
+	if !strings.Contains(splitF1Configuration, `local_n_address = "172.5.1.3";`) || !strings.Contains(splitF1Configuration, `local_n_address_f1u = "172.6.1.3";`) {
+		t.Errorf("split f1 configuration must contain local_n_address and local_n_address_f1u:\n%s", splitF1Configuration)
+	}
+}
+
+func TestGetDuResourceName(t *testing.T) {
+	cases := map[string]struct {
+		annotations map[string]string
+		finalizer   bool
+		want        string
+	}{
+		"New DU":                 {want: "oai-du-du-edge"},
+		"Created By The Release": {annotations: map[string]string{DuResourceNameAnnotation: "oai-du-du-edge"}, finalizer: true, want: "oai-du-du-edge"},
+		"Created Before":         {finalizer: true, want: "oai-du"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ranDeployment := &workloadv1alpha1.NFDeployment{ObjectMeta: metav1.ObjectMeta{Name: "du-edge", Annotations: tc.annotations}}
+			if tc.finalizer {
+				controllerutil.AddFinalizer(ranDeployment, finalizerName)
+			}
+			if got := getDuResourceName(ranDeployment); got != tc.want {
+				t.Errorf("getDuResourceName returned %s, expected %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReconcileDeleteLegacyDu(t *testing.T) {
+	ranDeployment, objects := loadGoldenInput(t, filepath.Join("testdata", "golden", "du-rfsim-band-n78"))
+	scheme := newManagerScheme()
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	r := RANDeploymentReconciler{Client: fakeClient, Scheme: scheme}
+	configInfo, err := r.GetConfigs(context.TODO(), ranDeployment)
+	if err != nil {
+		t.Fatalf("GetConfigs returned error %v", err)
+	}
+
+	// The previous releases named the resources of the DU oai-du and exposed its telnet server with oai-du-telnet-lb
+	if errList := r.CreateAll(context.TODO(), ranDeployment, DuResources{}, configInfo); len(errList) != 0 {
+		t.Fatalf("CreateAll returned errors %v", errList)
+	}
+	legacyService := &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+		Namespace: goldenNamespace,
+		Name:      legacyDuTelnetServiceName,
+		Labels:    map[string]string{"app.kubernetes.io/name": legacyDuTelnetServiceName},
+	}}
+	if err := fakeClient.Create(context.TODO(), legacyService); err != nil {
+		t.Fatalf("Cannot create the legacy telnet Service: %v", err)
+	}
+	controllerutil.AddFinalizer(ranDeployment, finalizerName)
+	if err := fakeClient.Create(context.TODO(), ranDeployment); err != nil {
+		t.Fatalf("Cannot create the NFDeployment: %v", err)
+	}
+	if err := fakeClient.Delete(context.TODO(), ranDeployment); err != nil {
+		t.Fatalf("Cannot delete the NFDeployment: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(ranDeployment)}); err != nil {
+		t.Fatalf("Reconcile returned error %v", err)
+	}
+	legacyResources := map[string]client.Object{
+		"oai-du":                  &appsv1.Deployment{},
+		"oai-du-configmap":        &corev1.ConfigMap{},
+		"oai-du-sa":               &corev1.ServiceAccount{},
+		"oai-du-telnet":           &corev1.Service{},
+		legacyDuTelnetServiceName: &corev1.Service{},
+	}
+	for resourceName, resource := range legacyResources {
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: goldenNamespace, Name: resourceName}, resource); !apierrors.IsNotFound(err) {
+			t.Errorf("%T %s of the legacy DU is not deleted: %v", resource, resourceName, err)
+		}
 	}
 }
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+const defaultTimezone = "Europe/Paris"
+
+var defaultLogOptions = []workloadnfconfig.LogOption{"level", "nocolor", "time"}
+
+// reservedOptions are the softmodem options set by the controller or through the fields of the runtime options,
+// an option is also reserved with any suffix starting with a dot, e.g. --telnetsrv.listenport
+var reservedOptions = []string{
+	"-O",
+	"--sa",
+	"--rfsim",
+	"--telnetsrv",
+	"--log_config.global_log_options",
+	"--log_config.global_log_level",
+	"--thread-pool",
+	"--usrp-tx-thread-config",
+}
+
+// reservedEnv are the environment variables of the softmodem container set by the controller
+var reservedEnv = []string{"TZ", "USE_ADDITIONAL_OPTIONS", "USE_VOLUMED_CONF", podIpEnv}
+
+var (
+	envNameRegexp    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	timezoneRegexp   = regexp.MustCompile(`^[A-Za-z0-9_+\-/]+$`)
+	threadPoolRegexp = regexp.MustCompile(`^-?[0-9]+(,-?[0-9]+)*$`)
+)
+
+// getRuntimeOptions returns the runtime options of the OAIConfig with their defaults applied, rejecting the ones conflicting with the controller
+func getRuntimeOptions(provider string, runtimeOptions *workloadnfconfig.RuntimeOptions) (*workloadnfconfig.RuntimeOptions, error) {
+	options := &workloadnfconfig.RuntimeOptions{}
+	if runtimeOptions != nil {
+		options = runtimeOptions.DeepCopy()
+	}
+	if options.Timezone == "" {
+		options.Timezone = defaultTimezone
+	}
+	if len(options.LogOptions) == 0 {
+		options.LogOptions = defaultLogOptions
+	}
+
+	if !timezoneRegexp.MatchString(options.Timezone) {
+		return nil, fmt.Errorf("invalid timezone %q", options.Timezone)
+	}
+	if provider != "du.openairinterface.org" && (options.ThreadPool != "" || options.USRPTxThread) {
+		return nil, fmt.Errorf("threadPool and usrpTxThread are only supported by the DU")
+	}
+	if options.ThreadPool != "" && !threadPoolRegexp.MatchString(options.ThreadPool) {
+		return nil, fmt.Errorf("invalid thread pool %q", options.ThreadPool)
+	}
+	for _, arg := range options.ExtraArgs {
+		if err := validateExtraArg(arg); err != nil {
+			return nil, err
+		}
+	}
+	envNames := map[string]bool{}
+	for _, env := range options.Env {
+		if !envNameRegexp.MatchString(env.Name) {
+			return nil, fmt.Errorf("invalid environment variable name %q", env.Name)
+		}
+		for _, reserved := range reservedEnv {
+			if env.Name == reserved {
+				return nil, fmt.Errorf("environment variable %s is set by the controller", env.Name)
+			}
+		}
+		if envNames[env.Name] {
+			return nil, fmt.Errorf("environment variable %s is set twice", env.Name)
+		}
+		envNames[env.Name] = true
+	}
+	return options, nil
+}
+
+// validateExtraArg rejects the extra arguments interpreted by the shell of the image or overriding a reserved option
+func validateExtraArg(arg string) error {
+	if strings.TrimSpace(arg) == "" {
+		return fmt.Errorf("empty extra argument")
+	}
+	if strings.ContainsAny(arg, "\"'`$;|&<>\\") {
+		return fmt.Errorf("extra argument %q contains shell characters", arg)
+	}
+	for _, field := range strings.Fields(arg) {
+		if !strings.HasPrefix(field, "-") {
+			continue
+		}
+		option, _, _ := strings.Cut(field, "=")
+		for _, reserved := range reservedOptions {
+			if option == reserved || strings.HasPrefix(option, reserved+".") {
+				return fmt.Errorf("extra argument %q conflicts with the %s option set by the controller", arg, reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// getAdditionalOptions returns the USE_ADDITIONAL_OPTIONS of the softmodem, the required options of the NF followed by the runtime options
+func getAdditionalOptions(requiredOptions []string, runtimeOptions *workloadnfconfig.RuntimeOptions) string {
+	logOptions := make([]string, 0, len(runtimeOptions.LogOptions))
+	for _, logOption := range runtimeOptions.LogOptions {
+		logOptions = append(logOptions, string(logOption))
+	}
+
+	options := append([]string{}, requiredOptions...)
+	options = append(options, "--log_config.global_log_options "+strings.Join(logOptions, ","))
+	if runtimeOptions.LogLevel != "" {
+		options = append(options, "--log_config.global_log_level "+string(runtimeOptions.LogLevel))
+	}
+	if runtimeOptions.ThreadPool != "" {
+		options = append(options, "--thread-pool "+runtimeOptions.ThreadPool)
+	}
+	if runtimeOptions.USRPTxThread {
+		options = append(options, "--usrp-tx-thread-config 1")
+	}
+	options = append(options, runtimeOptions.ExtraArgs...)
+	return strings.Join(options, " ")
+}
+
+// addRuntimeEnv adds the environment variables of the runtime options to the NF container
+func addRuntimeEnv(container *corev1.Container, runtimeOptions *workloadnfconfig.RuntimeOptions) {
+	for _, env := range runtimeOptions.Env {
+		container.Env = append(container.Env, corev1.EnvVar{Name: env.Name, Value: env.Value})
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestGetRuntimeOptions(t *testing.T) {
+	cases := map[string]struct {
+		provider       string
+		runtimeOptions *workloadnfconfig.RuntimeOptions
+		requiredOption []string
+		wantOptions    string
+		wantTimezone   string
+		wantsError     bool
+	}{
+		"CU-CP Default": {
+			provider:       "cucp.openairinterface.org",
+			requiredOption: []string{"--sa"},
+			wantOptions:    "--sa --log_config.global_log_options level,nocolor,time",
+			wantTimezone:   "Europe/Paris",
+		},
+		"DU Tuned": {
+			provider: "du.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{
+				ExtraArgs:    []string{"--gNBs.[0].min_rxtxtime 6", "-E"},
+				Timezone:     "UTC",
+				LogLevel:     "debug",
+				LogOptions:   []workloadnfconfig.LogOption{"level", "thread"},
+				ThreadPool:   "-1,-1,-1",
+				USRPTxThread: true,
+			},
+			requiredOption: []string{"--sa", "--rfsim"},
+			wantOptions: "--sa --rfsim --log_config.global_log_options level,thread --log_config.global_log_level debug" +
+				" --thread-pool -1,-1,-1 --usrp-tx-thread-config 1 --gNBs.[0].min_rxtxtime 6 -E",
+			wantTimezone: "UTC",
+		},
+		"Required Option In Extra Args": {
+			provider:       "du.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{ExtraArgs: []string{"--rfsim"}},
+			wantsError:     true,
+		},
+		"Telnet Option In Extra Args": {
+			provider:       "du.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{ExtraArgs: []string{"--telnetsrv.listenport=9091"}},
+			wantsError:     true,
+		},
+		"Shell Characters In Extra Args": {
+			provider:       "cuup.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{ExtraArgs: []string{"-E; rm -rf /"}},
+			wantsError:     true,
+		},
+		"Thread Pool Of CU-UP": {
+			provider:       "cuup.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{ThreadPool: "-1"},
+			wantsError:     true,
+		},
+		"Invalid Timezone": {
+			provider:       "cucp.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{Timezone: "Europe Paris"},
+			wantsError:     true,
+		},
+		"Reserved Env": {
+			provider:       "cucp.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{Env: []workloadnfconfig.EnvVar{{Name: "USE_VOLUMED_CONF", Value: "no"}}},
+			wantsError:     true,
+		},
+		"Duplicated Env": {
+			provider: "cucp.openairinterface.org",
+			runtimeOptions: &workloadnfconfig.RuntimeOptions{Env: []workloadnfconfig.EnvVar{
+				{Name: "ASAN_OPTIONS", Value: "detect_leaks=0"},
+				{Name: "ASAN_OPTIONS", Value: "detect_leaks=1"},
+			}},
+			wantsError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := getRuntimeOptions(tc.provider, tc.runtimeOptions)
+			if (err != nil) != tc.wantsError {
+				t.Fatalf("getRuntimeOptions returned error %v, wants error %v", err, tc.wantsError)
+			}
+			if tc.wantsError {
+				return
+			}
+			if gotOptions := getAdditionalOptions(tc.requiredOption, got); gotOptions != tc.wantOptions {
+				t.Errorf("getAdditionalOptions returned %q, expected %q", gotOptions, tc.wantOptions)
+			}
+			if got.Timezone != tc.wantTimezone {
+				t.Errorf("getRuntimeOptions returned timezone %q, expected %q", got.Timezone, tc.wantTimezone)
+			}
+		})
+	}
+}
+
+func TestAddRuntimeEnv(t *testing.T) {
+	container := corev1.Container{Env: []corev1.EnvVar{{Name: "TZ", Value: "UTC"}}}
+	addRuntimeEnv(&container, &workloadnfconfig.RuntimeOptions{Env: []workloadnfconfig.EnvVar{{Name: "ASAN_OPTIONS", Value: "detect_leaks=0"}}})
+
+	expected := []corev1.EnvVar{{Name: "TZ", Value: "UTC"}, {Name: "ASAN_OPTIONS", Value: "detect_leaks=0"}}
+	if !reflect.DeepEqual(container.Env, expected) {
+		t.Errorf("addRuntimeEnv returned %v, expected %v", container.Env, expected)
+	}
+}
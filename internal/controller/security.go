@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get
+
+// podSecurityEnforceLabel is the label of a namespace selecting the Pod Security Standard level its pods must comply with
+const podSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// podSecurityLevels are the levels of the Pod Security Standards, from the least to the most restrictive
+var podSecurityLevels = []string{"privileged", "baseline", "restricted"}
+
+// profileCapabilities are the capabilities the capabilities profile adds to the NF container,
+// to configure the interfaces, raise the priority of the realtime threads and lock their memory
+var profileCapabilities = []corev1.Capability{"NET_ADMIN", "SYS_NICE", "IPC_LOCK"}
+
+// getSecurityProfile returns the security profile of a NF, the default of its provider when not set
+func getSecurityProfile(provider string, security *workloadnfconfig.SecurityConfig) workloadnfconfig.SecurityProfile {
+	if security != nil && security.Profile != "" {
+		return security.Profile
+	}
+	if provider == "du.openairinterface.org" {
+		// The radio units and the realtime threads of the DU need the devices and the privileges of the host
+		return workloadnfconfig.SecurityProfilePrivileged
+	}
+	return workloadnfconfig.SecurityProfileCapabilities
+}
+
+// baselineCapabilities are the capabilities the baseline Pod Security Standard level allows to add
+var baselineCapabilities = []corev1.Capability{"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD", "NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+
+// getPodSecurityLevel returns the most restrictive Pod Security Standard level admitting a pod. Host namespaces, host
+// paths, privileged containers and capabilities outside of the baseline ones need the privileged level, the restricted
+// level also needs the containers to run as non-root without privilege escalation nor capabilities but NET_BIND_SERVICE
+func getPodSecurityLevel(podSpec *corev1.PodSpec) string {
+	if podSpec.HostNetwork || podSpec.HostPID || podSpec.HostIPC || len(getHostPaths(podSpec)) > 0 {
+		return "privileged"
+	}
+	podSecurityContext := podSpec.SecurityContext
+	if podSecurityContext == nil {
+		podSecurityContext = &corev1.PodSecurityContext{}
+	}
+	level := "restricted"
+	for _, container := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+		securityContext := container.SecurityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+		if ptr.Deref(securityContext.Privileged, false) {
+			return "privileged"
+		}
+		var added, dropped []corev1.Capability
+		if securityContext.Capabilities != nil {
+			added, dropped = securityContext.Capabilities.Add, securityContext.Capabilities.Drop
+		}
+		for _, capability := range added {
+			if !slices.Contains(baselineCapabilities, capability) {
+				return "privileged"
+			}
+			if capability != "NET_BIND_SERVICE" {
+				level = "baseline"
+			}
+		}
+		seccompProfile := securityContext.SeccompProfile
+		if seccompProfile == nil {
+			seccompProfile = podSecurityContext.SeccompProfile
+		}
+		runAsNonRoot := securityContext.RunAsNonRoot
+		if runAsNonRoot == nil {
+			runAsNonRoot = podSecurityContext.RunAsNonRoot
+		}
+		if ptr.Deref(securityContext.AllowPrivilegeEscalation, true) || !slices.Contains(dropped, "ALL") || !ptr.Deref(runAsNonRoot, false) ||
+			seccompProfile == nil || seccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			level = "baseline"
+		}
+	}
+	return level
+}
+
+// CheckPodSecurity checks the Pod Security Standard level enforced on the namespace of a NFDeployment admits the pods
+// of its rendered Deployment, they would be rejected otherwise
+func (r *RANDeploymentReconciler) CheckPodSecurity(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ranDeployment.Namespace}, namespace); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	enforced := namespace.Labels[podSecurityEnforceLabel]
+	if slices.Index(podSecurityLevels, enforced) <= 0 {
+		return nil
+	}
+
+	nfResource, err := GetNfResource(ranDeployment)
+	if err != nil {
+		return err
+	}
+	// The Deployment which cannot be rendered is reported by CreateAll
+	for _, deployment := range nfResource.GetDeployment(log.FromContext(ctx), ranDeployment, configInfo) {
+		required := getPodSecurityLevel(&deployment.Spec.Template.Spec)
+		if slices.Index(podSecurityLevels, enforced) > slices.Index(podSecurityLevels, required) {
+			return fmt.Errorf("the pods of %s need the %s Pod Security level, namespace %s enforces %s", deployment.Name, required, ranDeployment.Namespace, enforced)
+		}
+	}
+	return nil
+}
+
+// restrictedSecurityContext returns the security context of the containers of the restricted profile
+func restrictedSecurityContext(capabilities []corev1.Capability) *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		Privileged:               ptr.To(false),
+		AllowPrivilegeEscalation: ptr.To(false),
+		RunAsNonRoot:             ptr.To(true),
+		Capabilities: &corev1.Capabilities{
+			Add:  capabilities,
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+}
+
+// getHostPaths returns the paths of the host mounted in a pod, e.g. the usb bus of the USRP radio units
+func getHostPaths(podSpec *corev1.PodSpec) []string {
+	var hostPaths []string
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			hostPaths = append(hostPaths, volume.HostPath.Path)
+		}
+	}
+	return hostPaths
+}
+
+// applySecurity sets the security contexts of the pod of a NF and of its containers from its security profile. Only
+// the privileged profile is accepted when the pod mounts host paths, the devices under them cannot be opened without
+// the privileges of the host
+func applySecurity(podSpec *corev1.PodSpec, provider string, security *workloadnfconfig.SecurityConfig) error {
+	var capabilities []corev1.Capability
+	if security != nil {
+		capabilities = security.Capabilities
+	}
+
+	podSecurityContext := &corev1.PodSecurityContext{}
+	if security != nil && security.RunAsUser != nil {
+		podSecurityContext.RunAsUser = ptr.To(*security.RunAsUser)
+	}
+
+	profile := getSecurityProfile(provider, security)
+	if hostPaths := getHostPaths(podSpec); len(hostPaths) > 0 && profile != workloadnfconfig.SecurityProfilePrivileged {
+		return fmt.Errorf("the %s security profile cannot access the host paths %v, the privileged profile is required", profile, hostPaths)
+	}
+
+	container := &podSpec.Containers[0]
+	switch profile {
+	case workloadnfconfig.SecurityProfilePrivileged:
+		container.SecurityContext = &corev1.SecurityContext{
+			Privileged: ptr.To(true),
+		}
+		if len(capabilities) > 0 {
+			container.SecurityContext.Capabilities = &corev1.Capabilities{Add: capabilities}
+		}
+	case workloadnfconfig.SecurityProfileCapabilities:
+		podSecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+		container.SecurityContext = &corev1.SecurityContext{
+			Privileged: ptr.To(false),
+			Capabilities: &corev1.Capabilities{
+				Add: append(append([]corev1.Capability{}, profileCapabilities...), capabilities...),
+			},
+		}
+	case workloadnfconfig.SecurityProfileRestricted:
+		for _, capability := range capabilities {
+			if capability != "NET_BIND_SERVICE" {
+				return fmt.Errorf("capability %s cannot be added by the restricted profile", capability)
+			}
+		}
+		podSecurityContext.RunAsNonRoot = ptr.To(true)
+		podSecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+		container.SecurityContext = restrictedSecurityContext(capabilities)
+		for index := range podSpec.Containers[1:] {
+			podSpec.Containers[index+1].SecurityContext = restrictedSecurityContext(nil)
+		}
+		for index := range podSpec.InitContainers {
+			podSpec.InitContainers[index].SecurityContext = restrictedSecurityContext(nil)
+		}
+	default:
+		return fmt.Errorf("not supported security profile %q", profile)
+	}
+
+	if !reflect.DeepEqual(podSecurityContext, &corev1.PodSecurityContext{}) {
+		podSpec.SecurityContext = podSecurityContext
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestApplySecurity(t *testing.T) {
+	cases := map[string]struct {
+		provider         string
+		security         *workloadnfconfig.SecurityConfig
+		hostPath         string
+		wantPrivileged   bool
+		wantCapabilities []corev1.Capability
+		wantRestricted   bool
+		wantsError       bool
+	}{
+		"DU Default": {
+			provider:       "du.openairinterface.org",
+			wantPrivileged: true,
+		},
+		"CU-CP Default": {
+			provider:         "cucp.openairinterface.org",
+			wantCapabilities: []corev1.Capability{"NET_ADMIN", "SYS_NICE", "IPC_LOCK"},
+		},
+		"CU-UP Extra Capability": {
+			provider:         "cuup.openairinterface.org",
+			security:         &workloadnfconfig.SecurityConfig{Capabilities: []corev1.Capability{"NET_RAW"}},
+			wantCapabilities: []corev1.Capability{"NET_ADMIN", "SYS_NICE", "IPC_LOCK", "NET_RAW"},
+		},
+		"CU-UP Restricted": {
+			provider:       "cuup.openairinterface.org",
+			security:       &workloadnfconfig.SecurityConfig{Profile: workloadnfconfig.SecurityProfileRestricted, RunAsUser: ptr.To(int64(1000))},
+			wantRestricted: true,
+		},
+		"Restricted With NET_ADMIN": {
+			provider:   "cucp.openairinterface.org",
+			security:   &workloadnfconfig.SecurityConfig{Profile: workloadnfconfig.SecurityProfileRestricted, Capabilities: []corev1.Capability{"NET_ADMIN"}},
+			wantsError: true,
+		},
+		"USRP DU Privileged": {
+			provider:       "du.openairinterface.org",
+			hostPath:       "/dev/bus/usb",
+			wantPrivileged: true,
+		},
+		"USRP DU Capabilities": {
+			provider:   "du.openairinterface.org",
+			security:   &workloadnfconfig.SecurityConfig{Profile: workloadnfconfig.SecurityProfileCapabilities},
+			hostPath:   "/dev/bus/usb",
+			wantsError: true,
+		},
+		"USRP DU Restricted": {
+			provider:   "du.openairinterface.org",
+			security:   &workloadnfconfig.SecurityConfig{Profile: workloadnfconfig.SecurityProfileRestricted},
+			hostPath:   "/dev/bus/usb",
+			wantsError: true,
+		},
+		"Unknown Profile": {
+			provider:   "cucp.openairinterface.org",
+			security:   &workloadnfconfig.SecurityConfig{Profile: "root"},
+			wantsError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			podSpec := corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "e2-service-models"}},
+				Containers:     []corev1.Container{{Name: "nf"}},
+			}
+			if tc.hostPath != "" {
+				podSpec.Volumes = []corev1.Volume{{Name: "devices", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: tc.hostPath}}}}
+			}
+			err := applySecurity(&podSpec, tc.provider, tc.security)
+			if (err != nil) != tc.wantsError {
+				t.Fatalf("applySecurity returned error %v, wants error %v", err, tc.wantsError)
+			}
+			if tc.wantsError {
+				return
+			}
+
+			securityContext := podSpec.Containers[0].SecurityContext
+			if *securityContext.Privileged != tc.wantPrivileged {
+				t.Errorf("Privileged is %v, expected %v", *securityContext.Privileged, tc.wantPrivileged)
+			}
+			if tc.wantCapabilities != nil && !reflect.DeepEqual(securityContext.Capabilities.Add, tc.wantCapabilities) {
+				t.Errorf("Capabilities are %v, expected %v", securityContext.Capabilities.Add, tc.wantCapabilities)
+			}
+			if tc.wantRestricted {
+				if podSpec.SecurityContext == nil || !*podSpec.SecurityContext.RunAsNonRoot || *podSpec.SecurityContext.RunAsUser != 1000 {
+					t.Errorf("Pod security context %v doesn't run as non-root user 1000", podSpec.SecurityContext)
+				}
+				for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+					if *container.SecurityContext.AllowPrivilegeEscalation || container.SecurityContext.Capabilities.Drop[0] != "ALL" {
+						t.Errorf("Container %s is not restricted: %v", container.Name, container.SecurityContext)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetPodSecurityLevel(t *testing.T) {
+	restrictedPodSpec := func() corev1.PodSpec {
+		podSpec := corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "e2-service-models"}},
+			Containers:     []corev1.Container{{Name: "nf"}},
+		}
+		if err := applySecurity(&podSpec, "cuup.openairinterface.org", &workloadnfconfig.SecurityConfig{Profile: workloadnfconfig.SecurityProfileRestricted}); err != nil {
+			t.Fatalf("applySecurity returned error %v", err)
+		}
+		return podSpec
+	}
+	cases := map[string]struct {
+		change func(*corev1.PodSpec)
+		want   string
+	}{
+		"Restricted": {
+			change: func(*corev1.PodSpec) {},
+			want:   "restricted",
+		},
+		"Host Path": {
+			change: func(podSpec *corev1.PodSpec) {
+				podSpec.Volumes = []corev1.Volume{{Name: "usrp-devices", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/dev/bus/usb"}}}}
+			},
+			want: "privileged",
+		},
+		"Host Network": {
+			change: func(podSpec *corev1.PodSpec) { podSpec.HostNetwork = true },
+			want:   "privileged",
+		},
+		"NET_ADMIN": {
+			change: func(podSpec *corev1.PodSpec) {
+				podSpec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"NET_ADMIN"}
+			},
+			want: "privileged",
+		},
+		"Baseline Capability": {
+			change: func(podSpec *corev1.PodSpec) {
+				podSpec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"CHOWN"}
+			},
+			want: "baseline",
+		},
+		"Init Container Running As Root": {
+			change: func(podSpec *corev1.PodSpec) {
+				podSpec.SecurityContext.RunAsNonRoot = nil
+				podSpec.InitContainers[0].SecurityContext.RunAsNonRoot = nil
+			},
+			want: "baseline",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			podSpec := restrictedPodSpec()
+			tc.change(&podSpec)
+			if got := getPodSecurityLevel(&podSpec); got != tc.want {
+				t.Errorf("getPodSecurityLevel returned %s, expected %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckPodSecurity(t *testing.T) {
+	cases := map[string]struct {
+		scenario   string
+		profile    workloadnfconfig.SecurityProfile
+		enforced   string
+		wantsError bool
+	}{
+		"No Level Enforced": {
+			scenario: "du-usrp-band-n41",
+		},
+		"Capabilities Under Privileged": {
+			scenario: "cuup-single-plmn",
+			enforced: "privileged",
+		},
+		"Capabilities Under Baseline": {
+			scenario:   "cuup-single-plmn",
+			enforced:   "baseline",
+			wantsError: true,
+		},
+		"Restricted Under Restricted": {
+			scenario: "cuup-single-plmn",
+			profile:  workloadnfconfig.SecurityProfileRestricted,
+			enforced: "restricted",
+		},
+		"Restricted Under Baseline": {
+			scenario: "cuup-single-plmn",
+			profile:  workloadnfconfig.SecurityProfileRestricted,
+			enforced: "baseline",
+		},
+		"RFsim DU Under Baseline": {
+			scenario:   "du-rfsim-band-n78",
+			enforced:   "baseline",
+			wantsError: true,
+		},
+		"USRP DU Restricted Under Restricted": {
+			// The usb bus of the host cannot be mounted, nothing is rendered
+			scenario: "du-usrp-band-n41",
+			profile:  workloadnfconfig.SecurityProfileRestricted,
+			enforced: "restricted",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ranDeployment, objects := loadGoldenInput(t, filepath.Join("testdata", "golden", tc.scenario))
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: goldenNamespace}}
+			if tc.enforced != "" {
+				namespace.Labels = map[string]string{podSecurityEnforceLabel: tc.enforced}
+			}
+			r := RANDeploymentReconciler{Client: fake.NewClientBuilder().WithScheme(newManagerScheme()).WithObjects(append(objects, namespace)...).Build()}
+			configInfo, err := r.GetConfigs(context.TODO(), ranDeployment)
+			if err != nil {
+				t.Fatalf("GetConfigs returned error %v", err)
+			}
+			if tc.profile != "" {
+				paramsOAI := workloadnfconfig.OAIConfig{}
+				if err := json.Unmarshal(configInfo.ConfigSelfInfo["OAIConfig"].Raw, &paramsOAI); err != nil {
+					t.Fatalf("Cannot read the OAIConfig: %v", err)
+				}
+				paramsOAI.Spec.Security = &workloadnfconfig.SecurityConfig{Profile: tc.profile}
+				configInfo.ConfigSelfInfo["OAIConfig"] = runtime.RawExtension{Raw: marshalJsonReturnByteOnly(paramsOAI)}
+			}
+
+			err = r.CheckPodSecurity(context.TODO(), ranDeployment, configInfo)
+			if (err != nil) != tc.wantsError {
+				t.Errorf("CheckPodSecurity returned error %v, expected an error: %v", err, tc.wantsError)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	refv1alpha1 "github.com/nephio-project/api/references/v1alpha1"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	runscheme "sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+/*
+The envtest suite runs the reconciler in a manager against a real API server, with the Nephio NFDeployment, NFConfig
+and Config CRDs and the CRDs of this repository installed. It needs the kube-apiserver and etcd binaries of envtest,
+found through KUBEBUILDER_ASSETS (see make envtest), and is skipped without them. There are no kube-controller-manager
+nor kubelet: the Deployments never get pods and the deleted objects are not garbage collected.
+*/
+
+var (
+	testEnv        *envtest.Environment
+	envtestClient  client.Client
+	envtestOnce    sync.Once
+	envtestErr     error
+	stopEnvtestMgr context.CancelFunc
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if stopEnvtestMgr != nil {
+		stopEnvtestMgr()
+	}
+	if testEnv != nil {
+		if err := testEnv.Stop(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot stop envtest: %v\n", err)
+		}
+	}
+	os.Exit(code)
+}
+
+// newManagerScheme returns the scheme of the manager, with the kinds main registers
+func newManagerScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	schemeBuilder := &runscheme.Builder{GroupVersion: refv1alpha1.GroupVersion}
+	schemeBuilder.Register(&refv1alpha1.Config{}, &refv1alpha1.ConfigList{})
+	utilruntime.Must(schemeBuilder.AddToScheme(scheme))
+	schemeBuilder = &runscheme.Builder{GroupVersion: workloadv1alpha1.GroupVersion}
+	schemeBuilder.Register(&workloadv1alpha1.NFConfig{}, &workloadv1alpha1.NFConfigList{})
+	schemeBuilder.Register(&workloadv1alpha1.NFDeployment{}, &workloadv1alpha1.NFDeploymentList{})
+	utilruntime.Must(schemeBuilder.AddToScheme(scheme))
+	return scheme
+}
+
+// getNephioCrdPaths returns the NFDeployment, NFConfig and Config CRDs of the version of the Nephio API in go.mod
+func getNephioCrdPaths() ([]string, error) {
+	output, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/nephio-project/api").Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the Nephio API module: %w", err)
+	}
+	bases := filepath.Join(strings.TrimSpace(string(output)), "config", "crd", "bases")
+	return []string{
+		filepath.Join(bases, "workload.nephio.org_nfdeployments.yaml"),
+		filepath.Join(bases, "workload.nephio.org_nfconfigs.yaml"),
+		filepath.Join(bases, "ref.nephio.org_configs.yaml"),
+	}, nil
+}
+
+func setupEnvtest() error {
+	ctrl.SetLogger(zap.New(zap.WriteTo(os.Stderr), zap.UseDevMode(true)))
+	crdPaths, err := getNephioCrdPaths()
+	if err != nil {
+		return err
+	}
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths:     append(crdPaths, filepath.Join("..", "..", "config", "crd", "bases")),
+		ErrorIfCRDPathMissing: true,
+	}
+	config, err := testEnv.Start()
+	if err != nil {
+		testEnv = nil
+		return err
+	}
+
+	scheme := newManagerScheme()
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		return err
+	}
+	if err := (&RANDeploymentReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("ran-deployment-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopEnvtestMgr = cancel
+	go func() {
+		if err := mgr.Start(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "The manager stopped: %v\n", err)
+		}
+	}()
+
+	// The assertions read the API server directly, not the cache of the manager
+	envtestClient, err = client.New(config, client.Options{Scheme: scheme})
+	return err
+}
+
+// startEnvtest starts the API server and the manager on the first call, and returns a client of the API server.
+// The test is skipped when the binaries of envtest are not installed
+func startEnvtest(t *testing.T) client.Client {
+	t.Helper()
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set, run the envtest suite with make envtest")
+	}
+	envtestOnce.Do(func() {
+		envtestErr = setupEnvtest()
+	})
+	if envtestErr != nil {
+		t.Fatalf("Cannot start envtest: %v", envtestErr)
+	}
+	return envtestClient
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+const (
+	// legacyDuTelnetServiceName is the Service the previous releases exposed the telnet server of the DU with, on the
+	// node port 32500
+	legacyDuTelnetServiceName = "oai-du-telnet-lb"
+
+	defaultTelnetPort = 9090
+	// podIpEnv is set from the downward API when the telnet server listens on the pod IP
+	podIpEnv = "POD_IP"
+)
+
+// getTelnet returns the telnet server of the OAIConfig with its defaults applied, nil if the NF doesn't serve one
+func getTelnet(ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) (*workloadnfconfig.TelnetConfig, error) {
+	var telnet *workloadnfconfig.TelnetConfig
+	if rawOaiConfig, found := configInfo.ConfigSelfInfo["OAIConfig"]; found {
+		paramsOAI := &workloadnfconfig.OAIConfig{}
+		if err := json.Unmarshal(rawOaiConfig.Raw, paramsOAI); err != nil {
+			return nil, err
+		}
+		telnet = paramsOAI.Spec.Telnet
+	}
+	if telnet == nil {
+		if ranDeployment.Spec.Provider != "du.openairinterface.org" {
+			return nil, nil
+		}
+		telnet = &workloadnfconfig.TelnetConfig{}
+	}
+
+	if telnet.Port == 0 {
+		telnet.Port = defaultTelnetPort
+	}
+	if telnet.Exposure == "" {
+		telnet.Exposure = workloadnfconfig.TelnetExposureLoadBalancer
+	}
+	switch telnet.Exposure {
+	case workloadnfconfig.TelnetExposureNone, workloadnfconfig.TelnetExposureClusterIP:
+		if telnet.NodePort != 0 {
+			return nil, fmt.Errorf("node port %d of the telnet server needs a NodePort or LoadBalancer exposure", telnet.NodePort)
+		}
+	case workloadnfconfig.TelnetExposureNodePort, workloadnfconfig.TelnetExposureLoadBalancer:
+	default:
+		return nil, fmt.Errorf("not supported telnet exposure %q", telnet.Exposure)
+	}
+	return telnet, nil
+}
+
+// getTelnetListenAddress returns the address of the listen interface of the telnet server, empty to listen on the pod IP
+func getTelnetListenAddress(ranDeployment *workloadv1alpha1.NFDeployment, telnet *workloadnfconfig.TelnetConfig) (string, error) {
+	if telnet.ListenInterface == "" {
+		return "", nil
+	}
+	return getInterfaceIPOfProvider(ranDeployment.Spec.Provider, &ranDeployment.Spec, telnet.ListenInterface)
+}
+
+// addTelnetServer enables the telnet server of the NF container, on the pod IP taken from the downward API unless an address is given;
+// the DU also loads the O1 module of the telnet server
+func addTelnetServer(container *corev1.Container, ranDeployment *workloadv1alpha1.NFDeployment, telnet *workloadnfconfig.TelnetConfig, listenAddress string) {
+	if telnet == nil {
+		return
+	}
+
+	options := " --telnetsrv"
+	if ranDeployment.Spec.Provider == "du.openairinterface.org" {
+		options += " --telnetsrv.shrmod o1"
+	}
+	if listenAddress == "" {
+		// The environment variables referenced by $(...) must be defined before
+		container.Env = append([]corev1.EnvVar{{
+			Name: podIpEnv,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"},
+			},
+		}}, container.Env...)
+		listenAddress = "$(" + podIpEnv + ")"
+	}
+	options += " --telnetsrv.listenaddr " + listenAddress + " --telnetsrv.listenport " + strconv.Itoa(int(telnet.Port))
+
+	for index := range container.Env {
+		if container.Env[index].Name == "USE_ADDITIONAL_OPTIONS" {
+			container.Env[index].Value += options
+		}
+	}
+	container.Ports = append(container.Ports, corev1.ContainerPort{
+		ContainerPort: telnet.Port,
+		Name:          "telnet",
+		Protocol:      corev1.Protocol("TCP"),
+	})
+}
+
+// getTelnetService returns the Service exposing the telnet server of the NF, nil if it is not exposed
+func getTelnetService(name string, telnet *workloadnfconfig.TelnetConfig) *corev1.Service {
+	if telnet == nil || telnet.Exposure == workloadnfconfig.TelnetExposureNone {
+		return nil
+	}
+
+	return &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"app.kubernetes.io/name": name + "-telnet",
+			},
+			Name: name + "-telnet",
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				"app.kubernetes.io/name": name,
+			},
+			Type: corev1.ServiceType(telnet.Exposure),
+			Ports: []corev1.ServicePort{
+				corev1.ServicePort{
+					Name:     "telnet",
+					Port:     telnet.Port,
+					Protocol: corev1.Protocol("TCP"),
+					NodePort: telnet.NodePort,
+					TargetPort: intstr.IntOrString{
+						IntVal: telnet.Port,
+					},
+				},
+			},
+		},
+	}
+}
+
+//+kubebuilder:rbac:groups=core,resources=services,verbs=delete
+
+// deleteLegacyDuTelnetService deletes the telnet Service of the DUs created by the previous releases once the Deployment
+// of the legacy DU it selects is gone, it holds the node port 32500 a DU may now be given
+func (r *RANDeploymentReconciler) deleteLegacyDuTelnetService(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) error {
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ranDeployment.Namespace, Name: legacyDuTelnetServiceName}, service); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if service.Labels["app.kubernetes.io/name"] != legacyDuTelnetServiceName {
+		return nil
+	}
+	// The legacy DU still runs until its NFDeployment is deleted, its telnet server is reached through the Service
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ranDeployment.Namespace, Name: legacyDuResourceName}, &appsv1.Deployment{}); !apierrors.IsNotFound(err) {
+		return err
+	}
+	err := r.Delete(ctx, service)
+	r.recordResourceEvent(ranDeployment, "delete", "Service", service, err)
+	return client.IgnoreNotFound(err)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// telnetTimeout bounds a query of a telnet server when the context has no deadline
+	telnetTimeout = 10 * time.Second
+
+	// Telnet commands starting the negotiation of the options, see RFC 854
+	telnetIac  = 255
+	telnetSb   = 250
+	telnetSe   = 240
+	telnetWill = 251
+	telnetDont = 254
+)
+
+// stripTelnetNegotiation removes the telnet option negotiation the OAI telnet server sends along its output
+func stripTelnetNegotiation(data []byte) []byte {
+	text := make([]byte, 0, len(data))
+	for index := 0; index < len(data); index++ {
+		if data[index] != telnetIac || index+1 == len(data) {
+			text = append(text, data[index])
+			continue
+		}
+		switch command := data[index+1]; {
+		case command == telnetSb:
+			// The sub-negotiation ends with IAC SE
+			end := bytes.Index(data[index:], []byte{telnetIac, telnetSe})
+			if end < 0 {
+				return text
+			}
+			index += end + 1
+		case command >= telnetWill && command <= telnetDont:
+			index += 2
+		case command == telnetIac:
+			// An escaped 255 byte
+			text = append(text, telnetIac)
+			index++
+		default:
+			index++
+		}
+	}
+	return text
+}
+
+// readUntilPrompt reads the output of a telnet server until it ends with prompt, or with "> " for an empty prompt,
+// and returns it without the prompt
+func readUntilPrompt(reader io.Reader, prompt string) (string, error) {
+	var received []byte
+	buffer := make([]byte, 4096)
+	for {
+		count, err := reader.Read(buffer)
+		received = append(received, buffer[:count]...)
+		text := string(stripTelnetNegotiation(received))
+		if prompt != "" && strings.HasSuffix(text, prompt) {
+			return strings.TrimSuffix(text, prompt), nil
+		}
+		if prompt == "" && strings.HasSuffix(text, "> ") {
+			return text, nil
+		}
+		if err != nil {
+			return text, fmt.Errorf("telnet server closed before its prompt: %w", err)
+		}
+	}
+}
+
+// queryTelnet runs a command on the OAI telnet server at address and returns its output, without the prompt
+func queryTelnet(ctx context.Context, address string, command string) (string, error) {
+	dialer := net.Dialer{Timeout: telnetTimeout}
+	connection, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", err
+	}
+	defer connection.Close()
+	deadline, found := ctx.Deadline()
+	if !found {
+		deadline = time.Now().Add(telnetTimeout)
+	}
+	if err := connection.SetDeadline(deadline); err != nil {
+		return "", err
+	}
+	return runTelnetCommand(connection, command)
+}
+
+// runTelnetCommand waits for the prompt of a telnet server, e.g. "softmodem_gnb> ", then runs command and reads its
+// output until the prompt comes back
+func runTelnetCommand(connection io.ReadWriter, command string) (string, error) {
+	banner, err := readUntilPrompt(connection, "")
+	if err != nil {
+		return "", err
+	}
+	prompt := banner[strings.LastIndex(banner, "\n")+1:]
+	if _, err := io.WriteString(connection, command+"\n"); err != nil {
+		return "", err
+	}
+	output, err := readUntilPrompt(connection, prompt)
+	if err != nil {
+		return "", err
+	}
+	// Some servers echo the command
+	output = strings.TrimPrefix(strings.TrimLeft(output, "\r\n"), command)
+	return strings.TrimSpace(output), nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// transcriptServer replays a session of a telnet server recorded in testdata/telnet: its banner up to the first
+// prompt, then the rest of the session once it receives the command
+type transcriptServer struct {
+	banner   string
+	session  string
+	received bytes.Buffer
+	pending  *strings.Reader
+}
+
+func newTranscriptServer(t *testing.T, name string) *transcriptServer {
+	content, err := os.ReadFile(filepath.Join("testdata", "telnet", name))
+	if err != nil {
+		t.Fatalf("Cannot read the transcript %s: %v", name, err)
+	}
+	transcript := strings.TrimSuffix(string(content), "\n")
+	end := strings.Index(transcript, "> ") + len("> ")
+	return &transcriptServer{banner: transcript[:end], session: transcript[end:]}
+}
+
+func (s *transcriptServer) Read(data []byte) (int, error) {
+	if s.pending == nil {
+		s.pending = strings.NewReader(s.banner)
+	}
+	return s.pending.Read(data)
+}
+
+func (s *transcriptServer) Write(data []byte) (int, error) {
+	s.received.Write(data)
+	s.pending = strings.NewReader(s.session)
+	return len(data), nil
+}
+
+// replayTranscripts returns a query of the telnet servers replaying the transcript of each command
+func replayTranscripts(t *testing.T, transcripts map[string]string) func(context.Context, string, string) (string, error) {
+	return func(ctx context.Context, address string, command string) (string, error) {
+		name, found := transcripts[command]
+		if !found {
+			return "", fmt.Errorf("unknown command %s", command)
+		}
+		return runTelnetCommand(newTranscriptServer(t, name), command)
+	}
+}
+
+func TestStripTelnetNegotiation(t *testing.T) {
+	// IAC WILL ECHO, IAC SB NAWS 0 80 0 24 IAC SE and IAC NOP around the prompt
+	data := append([]byte{telnetIac, telnetWill, 1}, []byte("softmodem")...)
+	data = append(data, telnetIac, telnetSb, 31, 0, 80, 0, 24, telnetIac, telnetSe)
+	data = append(data, []byte("_gnb> ")...)
+	data = append(data, telnetIac, 241)
+	if text := string(stripTelnetNegotiation(data)); text != "softmodem_gnb> " {
+		t.Errorf("stripTelnetNegotiation returned %q", text)
+	}
+}
+
+func TestRunTelnetCommand(t *testing.T) {
+	server := newTranscriptServer(t, "du_o1_stats.txt")
+	server.banner = "\xff\xfb\x01" + server.banner
+	output, err := runTelnetCommand(server, "o1 stats")
+	if err != nil {
+		t.Fatalf("runTelnetCommand returned error %v", err)
+	}
+	if server.received.String() != "o1 stats\n" {
+		t.Errorf("runTelnetCommand sent %q", server.received.String())
+	}
+	if !strings.HasPrefix(output, "{") || !strings.HasSuffix(output, "}\nOK") {
+		t.Errorf("runTelnetCommand returned %q, expected the output of o1 stats without prompt", output)
+	}
+}
+
+func TestQueryTelnetClosed(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("Cannot listen on the loopback: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if connection, err := listener.Accept(); err == nil {
+			connection.Close()
+		}
+	}()
+	if _, err := queryTelnet(context.TODO(), listener.Addr().String(), "o1 stats"); err == nil {
+		t.Errorf("queryTelnet returned no error from a server closing before its prompt")
+	}
+}
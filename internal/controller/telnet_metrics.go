@@ -0,0 +1,195 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+
+// TelnetMetricsPoller polls the telnet servers of the NFs managed by the reconciler: the DUs for the state and the KPIs
+// of their cell, the CU-CPs and CU-UPs for the state of their telnet server only, the OAI telnet server printing no
+// PDCP statistics. The NFs whose telnet server is not exposed by a Service are skipped
+type TelnetMetricsPoller struct {
+	client.Client
+	// Interval is the period of the polls
+	Interval time.Duration
+	// MacStatsCommand is the telnet command printing the MAC statistics of the UEs of the DU in the format of its
+	// nrMAC_stats.log, giving their BLER. The BLER is not polled when it is empty
+	MacStatsCommand string
+	// query runs a command on a telnet server, queryTelnet when nil
+	query func(ctx context.Context, address string, command string) (string, error)
+}
+
+// Start polls the telnet servers until the context is done, it implements manager.Runnable
+func (p *TelnetMetricsPoller) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, p.poll, p.Interval)
+	return nil
+}
+
+// poll updates the metrics of all the managed NFs
+func (p *TelnetMetricsPoller) poll(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	nfDeploymentList := &workloadv1alpha1.NFDeploymentList{}
+	if err := p.List(ctx, nfDeploymentList); err != nil {
+		logger.Error(err, "Cannot list the NFDeployments to poll their telnet servers")
+		return
+	}
+	for index := range nfDeploymentList.Items {
+		nfDeployment := &nfDeploymentList.Items[index]
+		if !controllerutil.ContainsFinalizer(nfDeployment, finalizerName) || !nfDeployment.DeletionTimestamp.IsZero() {
+			continue
+		}
+		var err error
+		switch nfDeployment.Spec.Provider {
+		case "du.openairinterface.org":
+			err = p.pollDu(ctx, nfDeployment)
+		case "cucp.openairinterface.org", "cuup.openairinterface.org":
+			err = p.pollCu(ctx, nfDeployment)
+		}
+		if err != nil {
+			logger.V(1).Info("Cannot poll the telnet server", "RANDeployment", client.ObjectKeyFromObject(nfDeployment), "error", err.Error())
+		}
+	}
+}
+
+// pollCu sets the telnet_up metric of a CU-CP or a CU-UP from the "help" command of its telnet server
+func (p *TelnetMetricsPoller) pollCu(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) error {
+	address, err := p.getTelnetAddress(ctx, ranDeployment)
+	if err != nil || address == "" {
+		return err
+	}
+	query := p.query
+	if query == nil {
+		query = queryTelnet
+	}
+
+	namespacedName := types.NamespacedName{Namespace: ranDeployment.Namespace, Name: ranDeployment.Name}
+	up := 1.0
+	if _, err = query(ctx, address, "help"); err != nil {
+		up = 0
+	}
+	managedNfDeployments.setIfTracked(namespacedName, func() {
+		telnetUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, ranDeployment.Spec.Provider).Set(up)
+	})
+	return err
+}
+
+// getTelnetAddress returns the address of the telnet server of a NF from its Service, empty when it is not exposed
+func (p *TelnetMetricsPoller) getTelnetAddress(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) (string, error) {
+	service := &corev1.Service{}
+	if err := p.Get(ctx, types.NamespacedName{Namespace: ranDeployment.Namespace, Name: getDeploymentName(ranDeployment) + "-telnet"}, service); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	for _, port := range service.Spec.Ports {
+		if port.Name == "telnet" && service.Spec.ClusterIP != "" && service.Spec.ClusterIP != corev1.ClusterIPNone {
+			return net.JoinHostPort(service.Spec.ClusterIP, strconv.Itoa(int(port.Port))), nil
+		}
+	}
+	return "", fmt.Errorf("service %s has no telnet port", service.Name)
+}
+
+// getCells returns the cells configured in the RANConfig of a DU, none when its configs cannot be read
+func (p *TelnetMetricsPoller) getCells(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) []workloadnfconfig.RANCellConfig {
+	// GetConfigs logs every config, the polls are not reconciliations
+	reconciler := &RANDeploymentReconciler{Client: p.Client}
+	configInfo, err := reconciler.GetConfigs(log.IntoContext(ctx, logr.Discard()), ranDeployment)
+	if err != nil {
+		return nil
+	}
+	rawRanConfig, found := configInfo.ConfigSelfInfo["RANConfig"]
+	if !found {
+		return nil
+	}
+	ranConfig := &workloadnfconfig.RANConfig{}
+	if err := json.Unmarshal(rawRanConfig.Raw, ranConfig); err != nil {
+		return nil
+	}
+	return ranConfig.Spec.GetCells()
+}
+
+// pollDu sets the telnet_up and cell_up metrics and the KPIs of the cell of a DU from the "o1 stats" of its telnet
+// server, along with the MAC statistics of its UEs when a MacStatsCommand is set
+func (p *TelnetMetricsPoller) pollDu(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment) error {
+	address, err := p.getTelnetAddress(ctx, ranDeployment)
+	if err != nil || address == "" {
+		return err
+	}
+	query := p.query
+	if query == nil {
+		query = queryTelnet
+	}
+
+	namespacedName := types.NamespacedName{Namespace: ranDeployment.Namespace, Name: ranDeployment.Name}
+	output, err := query(ctx, address, "o1 stats")
+	if err != nil {
+		managedNfDeployments.setIfTracked(namespacedName, func() {
+			telnetUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, ranDeployment.Spec.Provider).Set(0)
+			cellUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name).Set(0)
+			deleteCellKpis(nfLabels(namespacedName))
+		})
+		return err
+	}
+	stats, err := parseO1Stats(output)
+	if err != nil || stats.Config.NrCellDu == nil {
+		managedNfDeployments.setIfTracked(namespacedName, func() {
+			telnetUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, ranDeployment.Spec.Provider).Set(1)
+			cellUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name).Set(0)
+			deleteCellKpis(nfLabels(namespacedName))
+		})
+		return err
+	}
+
+	var ues []UeMacStats
+	if p.MacStatsCommand != "" {
+		macStatsOutput, err := query(ctx, address, p.MacStatsCommand)
+		if err == nil {
+			ues, err = parseMacStats(macStatsOutput)
+		}
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Cannot read the MAC statistics", "RANDeployment", namespacedName, "error", err.Error())
+		}
+	}
+	kpis := getCellKpis(stats, ues, p.getCells(ctx, ranDeployment))
+	// The DU may have been deleted during the queries, its metrics are then already dropped
+	managedNfDeployments.setIfTracked(namespacedName, func() {
+		telnetUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name, ranDeployment.Spec.Provider).Set(1)
+		cellUp.WithLabelValues(ranDeployment.Namespace, ranDeployment.Name).Set(1)
+		setCellKpis(namespacedName, kpis)
+	})
+	return nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestPollDu(t *testing.T) {
+	cases := map[string]struct {
+		serviceErr      error
+		transcripts     map[string]string
+		macStatsCommand string
+		wantAddress     string
+		wantErr         bool
+		wantTelnetUp    float64
+		wantCellUp      float64
+		wantKpiSeries   int
+		wantBlerSeries  int
+		wantNoTelnetUp  bool
+		untracked       bool
+	}{
+		"Cell Up": {
+			transcripts:   map[string]string{"o1 stats": "du_o1_stats.txt"},
+			wantAddress:   "10.96.0.12:9090",
+			wantTelnetUp:  1,
+			wantCellUp:    1,
+			wantKpiSeries: 1,
+		},
+		"Cell Up With MAC Statistics": {
+			transcripts:     map[string]string{"o1 stats": "du_o1_stats.txt", "mac stats": "du_mac_stats_synthetic.txt"},
+			macStatsCommand: "mac stats",
+			wantAddress:     "10.96.0.12:9090",
+			wantTelnetUp:    1,
+			wantCellUp:      1,
+			wantKpiSeries:   1,
+			wantBlerSeries:  2,
+		},
+		"Cell Not Reported": {
+			transcripts:  map[string]string{"o1 stats": "du_o1_stats_no_cell.txt"},
+			wantAddress:  "10.96.0.12:9090",
+			wantTelnetUp: 1,
+		},
+		"Telnet Server Down": {
+			wantAddress:  "10.96.0.12:9090",
+			wantErr:      true,
+			wantTelnetUp: 0,
+		},
+		"DU Deleted During The Poll": {
+			transcripts:    map[string]string{"o1 stats": "du_o1_stats.txt"},
+			wantAddress:    "10.96.0.12:9090",
+			wantNoTelnetUp: true,
+			untracked:      true,
+		},
+		"Telnet Not Exposed": {
+			serviceErr:     apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, "oai-du-telnet"),
+			wantNoTelnetUp: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Service")).Return(tc.serviceErr).Run(func(args mock.Arguments) {
+				*args.Get(2).(*corev1.Service) = corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "oai-du-telnet"},
+					Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.12", Ports: []corev1.ServicePort{{Name: "telnet", Port: 9090}}},
+				}
+			})
+			var address string
+			replay := replayTranscripts(t, tc.transcripts)
+			poller := &TelnetMetricsPoller{Client: clientMock, MacStatsCommand: tc.macStatsCommand, query: func(ctx context.Context, queried string, command string) (string, error) {
+				address = queried
+				return replay(ctx, queried, command)
+			}}
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "du-poll", Finalizers: []string{finalizerName}},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+			}
+			if !tc.untracked {
+				managedNfDeployments.update(ranDeployment)
+			}
+			defer managedNfDeployments.remove(types.NamespacedName{Namespace: "oai", Name: ranDeployment.Name})
+
+			if err := poller.pollDu(context.TODO(), ranDeployment); (err != nil) != tc.wantErr {
+				t.Errorf("pollDu returned error %v", err)
+			}
+			if address != tc.wantAddress {
+				t.Errorf("pollDu queried %q, expected %q", address, tc.wantAddress)
+			}
+			if tc.wantNoTelnetUp {
+				if count := testutil.CollectAndCount(telnetUp); count != 0 {
+					t.Errorf("pollDu set telnet_up of a DU without telnet Service or no longer tracked")
+				}
+				if count := testutil.CollectAndCount(connectedUes); count != 0 {
+					t.Errorf("pollDu set connected_ues of a DU without telnet Service or no longer tracked")
+				}
+				return
+			}
+			if got := testutil.ToFloat64(telnetUp.WithLabelValues("oai", "du-poll", "du.openairinterface.org")); got != tc.wantTelnetUp {
+				t.Errorf("telnet_up is %v, expected %v", got, tc.wantTelnetUp)
+			}
+			if got := testutil.ToFloat64(cellUp.WithLabelValues("oai", "du-poll")); got != tc.wantCellUp {
+				t.Errorf("cell_up is %v, expected %v", got, tc.wantCellUp)
+			}
+			if count := testutil.CollectAndCount(connectedUes); count != tc.wantKpiSeries {
+				t.Fatalf("connected_ues has %d series, expected %d", count, tc.wantKpiSeries)
+			}
+			if count := testutil.CollectAndCount(cellBler); count != tc.wantBlerSeries {
+				t.Errorf("cell_bler has %d series, expected %d", count, tc.wantBlerSeries)
+			}
+			if count := testutil.CollectAndCount(cellMcs); count != tc.wantBlerSeries {
+				t.Errorf("cell_mcs has %d series, expected %d", count, tc.wantBlerSeries)
+			}
+			// Two LCIDs by direction
+			if count := testutil.CollectAndCount(cellRlcBytes); count != 2*tc.wantBlerSeries {
+				t.Errorf("cell_rlc_bytes has %d series, expected %d", count, 2*tc.wantBlerSeries)
+			}
+			if tc.wantKpiSeries > 0 {
+				if got := testutil.ToFloat64(connectedUes.WithLabelValues("oai", "du-poll", "pci-1", "00101")); got != 2 {
+					t.Errorf("connected_ues is %v, expected 2", got)
+				}
+				if got := testutil.ToFloat64(cellThroughputBitsPerSecond.WithLabelValues("oai", "du-poll", "pci-1", "00101", "dl")); got != 4800000 {
+					t.Errorf("cell_throughput_bits_per_second of dl is %v, expected 4800000", got)
+				}
+			}
+		})
+	}
+}
+
+func TestPollCu(t *testing.T) {
+	cases := map[string]struct {
+		provider     string
+		queryErr     error
+		wantErr      bool
+		wantTelnetUp float64
+	}{
+		"CU-CP Telnet Server Up": {
+			provider:     "cucp.openairinterface.org",
+			wantTelnetUp: 1,
+		},
+		"CU-UP Telnet Server Down": {
+			provider:     "cuup.openairinterface.org",
+			queryErr:     errors.New("connection refused"),
+			wantErr:      true,
+			wantTelnetUp: 0,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("Get", context.TODO(), mock.AnythingOfType("types.NamespacedName"), mock.AnythingOfType("*v1.Service")).Return(nil).Run(func(args mock.Arguments) {
+				*args.Get(2).(*corev1.Service) = corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: "oai-cu-cp-telnet"},
+					Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.13", Ports: []corev1.ServicePort{{Name: "telnet", Port: 9090}}},
+				}
+			})
+			var commands []string
+			poller := &TelnetMetricsPoller{Client: clientMock, query: func(ctx context.Context, address string, command string) (string, error) {
+				commands = append(commands, command)
+				return "", tc.queryErr
+			}}
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "oai", Name: "cu-poll", Finalizers: []string{finalizerName}},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: tc.provider},
+			}
+			managedNfDeployments.update(ranDeployment)
+			defer managedNfDeployments.remove(types.NamespacedName{Namespace: "oai", Name: ranDeployment.Name})
+
+			if err := poller.pollCu(context.TODO(), ranDeployment); (err != nil) != tc.wantErr {
+				t.Errorf("pollCu returned error %v", err)
+			}
+			if len(commands) != 1 || commands[0] != "help" {
+				t.Errorf("pollCu ran %v, expected help", commands)
+			}
+			if got := testutil.ToFloat64(telnetUp.WithLabelValues("oai", "cu-poll", tc.provider)); got != tc.wantTelnetUp {
+				t.Errorf("telnet_up is %v, expected %v", got, tc.wantTelnetUp)
+			}
+			if count := testutil.CollectAndCount(cellUp); count != 0 {
+				t.Errorf("pollCu set cell_up of a CU")
+			}
+		})
+	}
+}
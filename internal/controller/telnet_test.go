@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func TestGetTelnet(t *testing.T) {
+	cases := map[string]struct {
+		provider   string
+		telnet     *workloadnfconfig.TelnetConfig
+		want       *workloadnfconfig.TelnetConfig
+		wantsError bool
+	}{
+		"DU Default": {
+			provider: "du.openairinterface.org",
+			want:     &workloadnfconfig.TelnetConfig{Port: 9090, Exposure: workloadnfconfig.TelnetExposureLoadBalancer},
+		},
+		"CU-CP Default": {
+			provider: "cucp.openairinterface.org",
+		},
+		"CU-UP Enabled": {
+			provider: "cuup.openairinterface.org",
+			telnet:   &workloadnfconfig.TelnetConfig{Port: 9091, Exposure: workloadnfconfig.TelnetExposureClusterIP},
+			want:     &workloadnfconfig.TelnetConfig{Port: 9091, Exposure: workloadnfconfig.TelnetExposureClusterIP},
+		},
+		"DU NodePort": {
+			provider: "du.openairinterface.org",
+			telnet:   &workloadnfconfig.TelnetConfig{Exposure: workloadnfconfig.TelnetExposureNodePort, NodePort: 32500},
+			want:     &workloadnfconfig.TelnetConfig{Port: 9090, Exposure: workloadnfconfig.TelnetExposureNodePort, NodePort: 32500},
+		},
+		"Node Port Of ClusterIP": {
+			provider:   "du.openairinterface.org",
+			telnet:     &workloadnfconfig.TelnetConfig{Exposure: workloadnfconfig.TelnetExposureClusterIP, NodePort: 32500},
+			wantsError: true,
+		},
+		"Unknown Exposure": {
+			provider:   "du.openairinterface.org",
+			telnet:     &workloadnfconfig.TelnetConfig{Exposure: "Ingress"},
+			wantsError: true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			oaiConfig := workloadnfconfig.OAIConfig{Spec: workloadnfconfig.OAIConfigSpec{Telnet: tc.telnet}}
+			configInfo := &ConfigInfo{
+				ConfigSelfInfo: map[string]runtime.RawExtension{
+					"OAIConfig": {Raw: marshalJsonReturnByteOnly(oaiConfig)},
+				},
+			}
+			ranDeployment := &workloadv1alpha1.NFDeployment{Spec: workloadv1alpha1.NFDeploymentSpec{Provider: tc.provider}}
+			got, err := getTelnet(ranDeployment, configInfo)
+			if (err != nil) != tc.wantsError {
+				t.Fatalf("getTelnet returned error %v, wants error %v", err, tc.wantsError)
+			}
+			if !tc.wantsError && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("getTelnet returned %v, expected %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddTelnetServer(t *testing.T) {
+	cases := map[string]struct {
+		provider      string
+		listenAddress string
+		wantOptions   string
+		wantsPodIp    bool
+	}{
+		"DU On Pod IP": {
+			provider:    "du.openairinterface.org",
+			wantOptions: "--sa --telnetsrv --telnetsrv.shrmod o1 --telnetsrv.listenaddr $(POD_IP) --telnetsrv.listenport 9090",
+			wantsPodIp:  true,
+		},
+		"CU-CP On Interface": {
+			provider:      "cucp.openairinterface.org",
+			listenAddress: "172.4.1.3",
+			wantOptions:   "--sa --telnetsrv --telnetsrv.listenaddr 172.4.1.3 --telnetsrv.listenport 9090",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			container := corev1.Container{Env: []corev1.EnvVar{{Name: "USE_ADDITIONAL_OPTIONS", Value: "--sa"}}}
+			ranDeployment := &workloadv1alpha1.NFDeployment{Spec: workloadv1alpha1.NFDeploymentSpec{Provider: tc.provider}}
+			addTelnetServer(&container, ranDeployment, &workloadnfconfig.TelnetConfig{Port: 9090}, tc.listenAddress)
+
+			if (container.Env[0].Name == podIpEnv) != tc.wantsPodIp {
+				t.Errorf("First environment variable is %s, wants the pod IP %v", container.Env[0].Name, tc.wantsPodIp)
+			}
+			gotOptions := container.Env[len(container.Env)-1].Value
+			if gotOptions != tc.wantOptions {
+				t.Errorf("USE_ADDITIONAL_OPTIONS is %q, expected %q", gotOptions, tc.wantOptions)
+			}
+			if len(container.Ports) != 1 || container.Ports[0].ContainerPort != 9090 {
+				t.Errorf("Container ports are %v, expected the telnet port", container.Ports)
+			}
+		})
+	}
+}
+
+func TestGetTelnetListenAddress(t *testing.T) {
+	ranDeployment := &workloadv1alpha1.NFDeployment{
+		Spec: workloadv1alpha1.NFDeploymentSpec{
+			Provider: "du.openairinterface.org",
+			Interfaces: []workloadv1alpha1.InterfaceConfig{
+				{Name: "f1", IPv4: &workloadv1alpha1.IPv4{Address: "172.5.1.2/24"}},
+			},
+		},
+	}
+
+	got, err := getTelnetListenAddress(ranDeployment, &workloadnfconfig.TelnetConfig{ListenInterface: "f1"})
+	if err != nil || got != "172.5.1.2" {
+		t.Errorf("getTelnetListenAddress returned %q and %v, expected 172.5.1.2", got, err)
+	}
+	got, err = getTelnetListenAddress(ranDeployment, &workloadnfconfig.TelnetConfig{})
+	if err != nil || got != "" {
+		t.Errorf("getTelnetListenAddress returned %q and %v, expected the pod IP", got, err)
+	}
+	if _, err := getTelnetListenAddress(ranDeployment, &workloadnfconfig.TelnetConfig{ListenInterface: "n2"}); err == nil {
+		t.Error("getTelnetListenAddress accepted an interface which is not an interface of the DU")
+	}
+}
+
+func TestGetTelnetService(t *testing.T) {
+	cases := map[string]struct {
+		telnet   *workloadnfconfig.TelnetConfig
+		wantType corev1.ServiceType
+	}{
+		"Disabled": {},
+		"None": {
+			telnet: &workloadnfconfig.TelnetConfig{Port: 9090, Exposure: workloadnfconfig.TelnetExposureNone},
+		},
+		"ClusterIP": {
+			telnet:   &workloadnfconfig.TelnetConfig{Port: 9090, Exposure: workloadnfconfig.TelnetExposureClusterIP},
+			wantType: corev1.ServiceTypeClusterIP,
+		},
+		"NodePort": {
+			telnet:   &workloadnfconfig.TelnetConfig{Port: 9090, Exposure: workloadnfconfig.TelnetExposureNodePort, NodePort: 32500},
+			wantType: corev1.ServiceTypeNodePort,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := getTelnetService("oai-du", tc.telnet)
+			if tc.wantType == "" {
+				if got != nil {
+					t.Errorf("getTelnetService returned %v, expected nil", got)
+				}
+				return
+			}
+			if got == nil || got.Spec.Type != tc.wantType || !strings.HasSuffix(got.Name, "-telnet") {
+				t.Fatalf("getTelnetService returned %v, expected a %s Service", got, tc.wantType)
+			}
+			if got.Spec.Ports[0].NodePort != tc.telnet.NodePort {
+				t.Errorf("getTelnetService returned node port %d, expected %d", got.Spec.Ports[0].NodePort, tc.telnet.NodePort)
+			}
+		})
+	}
+}
+
+func TestDeleteLegacyDuTelnetService(t *testing.T) {
+	cases := map[string]struct {
+		labels      map[string]string
+		legacyDu    bool
+		wantDeleted bool
+		wantEvent   bool
+	}{
+		"Legacy Service": {
+			labels:      map[string]string{"app.kubernetes.io/name": "oai-du-telnet-lb"},
+			wantDeleted: true,
+			wantEvent:   true,
+		},
+		"Legacy DU Still Running": {
+			labels:   map[string]string{"app.kubernetes.io/name": "oai-du-telnet-lb"},
+			legacyDu: true,
+		},
+		"Service Of The User With The Same Name": {
+			labels: map[string]string{"app": "telnet"},
+		},
+		"No Legacy Service": {},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			objects := []client.Object{}
+			if tc.labels != nil {
+				objects = append(objects, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "oai-ran", Name: "oai-du-telnet-lb", Labels: tc.labels}})
+			}
+			if tc.legacyDu {
+				objects = append(objects, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "oai-ran", Name: "oai-du"}})
+			}
+			recorder := record.NewFakeRecorder(1)
+			r := RANDeploymentReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(newManagerScheme()).WithObjects(objects...).Build(),
+				Recorder: recorder,
+			}
+			ranDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "oai-ran", Name: "du-edge"},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "du.openairinterface.org"},
+			}
+			if err := r.deleteLegacyDuTelnetService(context.TODO(), ranDeployment); err != nil {
+				t.Fatalf("deleteLegacyDuTelnetService returned error %v", err)
+			}
+
+			err := r.Get(context.TODO(), types.NamespacedName{Namespace: "oai-ran", Name: "oai-du-telnet-lb"}, &corev1.Service{})
+			if deleted := apierrors.IsNotFound(err); deleted != (tc.wantDeleted || tc.labels == nil) {
+				t.Errorf("deleteLegacyDuTelnetService deleted the Service: %v, expected %v", deleted, tc.wantDeleted)
+			}
+			if (len(recorder.Events) == 1) != tc.wantEvent {
+				t.Errorf("deleteLegacyDuTelnetService recorded %d events, expected an event: %v", len(recorder.Events), tc.wantEvent)
+			}
+		})
+	}
+}
@@ -32,7 +32,9 @@ type configurationTemplateValuesForCuCp struct {
 	E1_IP           string
 	F1C_IP          string
 	N2_IP           string
+	N2_IPV6         string
 	AMF_IP          string
+	AMF_IPV6        string
 	TAC             uint32
 	CELL_ID         string
 	PHY_CELL_ID     uint32
@@ -47,38 +49,71 @@ type configurationTemplateValuesForCuCp struct {
 	UL_FREQ_BAND    uint32
 	UL_SCS          uint16
 	UL_CARRIER_BW   uint32
+	E2_AGENT        *configurationTemplateValuesForE2Agent
 }
 
 type configurationTemplateValuesForCuUp struct {
 	E1_IP           string
 	F1U_IP          string
 	N3_IP           string
+	N3_IPV6         string
 	CUCP_E1         string
 	TAC             uint32
 	PLMN_MCC        string
 	PLMN_MNC        string
 	PLMN_MNC_LENGTH string
-	NSSAI_SST       int
-	NSSAI_SD        string
+	CU_UP_ID        string
+	SLICES          []configurationTemplateValuesForSlice
+	E2_AGENT        *configurationTemplateValuesForE2Agent
+}
+
+type configurationTemplateValuesForSlice struct {
+	SST int
+	SD  string
+}
+
+type configurationTemplateValuesForCell struct {
+	GNB_NAME      string
+	CELL_ID       string
+	PHY_CELL_ID   uint32
+	ABS_FREQ_SSB  uint32
+	DL_POINT_A    uint32
+	DL_FREQ_BAND  uint32
+	DL_SCS        uint16
+	DL_CARRIER_BW uint32
+	UL_FREQ_BAND  uint32
+	UL_SCS        uint16
+	UL_CARRIER_BW uint32
 }
 
 type configurationTemplateValuesForDu struct {
+	GNB_ID          string
+	GNB_DU_ID       string
 	F1C_DU_IP       string
+	F1U_DU_IP       string
 	F1C_CU_IP       string
 	TAC             uint32
-	CELL_ID         string
-	PHY_CELL_ID     uint32
+	CELLS           []configurationTemplateValuesForCell
 	PLMN_MCC        string
 	PLMN_MNC        string
 	PLMN_MNC_LENGTH string
 	NSSAI_SST       int
 	NSSAI_SD        string
-	DL_FREQ_BAND    uint32
-	DL_SCS          uint16
-	DL_CARRIER_BW   uint32
-	UL_FREQ_BAND    uint32
-	UL_SCS          uint16
-	UL_CARRIER_BW   uint32
+	RFSIM           bool
+	RU_SDR_ADDRS    string
+	RU_CLOCK_SRC    string
+	RU_NB_TX        uint32
+	RU_NB_RX        uint32
+	RU_ATT_TX       uint32
+	RU_ATT_RX       uint32
+	RU_MAX_RXGAIN   uint32
+	E2_AGENT        *configurationTemplateValuesForE2Agent
+}
+
+type configurationTemplateValuesForE2Agent struct {
+	RIC_IP   string
+	RIC_PORT uint16
+	SM_DIR   string
 }
 
 func renderConfigurationTemplateForCuCp(values configurationTemplateValuesForCuCp) (string, error) {
@@ -55,7 +55,7 @@ gNBs =
     };
 
     ////////// AMF parameters:
-    amf_ip_address      = ( { ipv4       = {{ .AMF_IP }}; });
+    amf_ip_address      = ( { {{ if .AMF_IPV6 }}ipv6       = {{ .AMF_IPV6 }}; preference = "ipv6"; {{ else }}ipv4       = {{ .AMF_IP }}; {{ end }}});
 
     E1_INTERFACE =
     (
@@ -70,7 +70,12 @@ gNBs =
 
     NETWORK_INTERFACES :
     {
+{{- if .N2_IP }}
         GNB_IPV4_ADDRESS_FOR_NG_AMF              = {{ .N2_IP }};
+{{- end }}
+{{- if .N2_IPV6 }}
+        GNB_IPV6_ADDRESS_FOR_NG_AMF              = {{ .N2_IPV6 }};
+{{- end }}
     };
   }
 );
@@ -91,7 +96,15 @@ security = {
   drb_ciphering = "yes";
   drb_integrity = "no";
 };
-log_config :
+{{ if .E2_AGENT }}e2_agent = {
+  near_ric_ip_addr = {{ .E2_AGENT.RIC_IP }};
+{{- if .E2_AGENT.RIC_PORT }}
+  port = {{ .E2_AGENT.RIC_PORT }};
+{{- end }}
+  sm_dir = {{ .E2_AGENT.SM_DIR }};
+};
+
+{{ end }}log_config :
 {
 global_log_level                      ="info";
 hw_log_level                          ="info";
@@ -116,7 +129,7 @@ gNBs =
  {
     ////////// Identification parameters:
     gNB_ID = 0xe00;
-    gNB_CU_UP_ID = 0xe00;
+    gNB_CU_UP_ID = {{ .CU_UP_ID }};
 
     gNB_name  =  "oai-cu-up";
 
@@ -125,7 +138,7 @@ gNBs =
     plmn_list = ({ mcc = {{ .PLMN_MCC }};
                    mnc = {{ .PLMN_MNC }};
                    mnc_length ={{ .PLMN_MNC_LENGTH }};
-                   snssaiList = ({ sst = {{ .NSSAI_SST }}, sd = 0x{{ .NSSAI_SD }} })
+                   snssaiList = ({{ range $index, $slice := .SLICES }}{{ if $index }}, {{ end }}{ sst = {{ $slice.SST }}{{ if $slice.SD }}, sd = 0x{{ $slice.SD }}{{ end }} }{{ end }})
                 });
 
     tr_s_preference = "f1";
@@ -156,8 +169,14 @@ gNBs =
 
     NETWORK_INTERFACES :
     {
+{{- if .N3_IP }}
         GNB_IPV4_ADDRESS_FOR_NG_AMF              = {{ .N3_IP }};
         GNB_IPV4_ADDRESS_FOR_NGU                 = {{ .N3_IP }};
+{{- end }}
+{{- if .N3_IPV6 }}
+        GNB_IPV6_ADDRESS_FOR_NG_AMF              = {{ .N3_IPV6 }};
+        GNB_IPV6_ADDRESS_FOR_NGU                 = {{ .N3_IPV6 }};
+{{- end }}
         GNB_PORT_FOR_S1U                         = 2152; # Spec 2152
     };
   }
@@ -181,7 +200,15 @@ security = {
 };
 
 
-log_config :
+{{ if .E2_AGENT }}e2_agent = {
+  near_ric_ip_addr = {{ .E2_AGENT.RIC_IP }};
+{{- if .E2_AGENT.RIC_PORT }}
+  port = {{ .E2_AGENT.RIC_PORT }};
+{{- end }}
+  sm_dir = {{ .E2_AGENT.SM_DIR }};
+};
+
+{{ end }}log_config :
 {
 global_log_level                      ="info";
 pdcp_log_level                        ="info";
@@ -191,24 +218,25 @@ ngap_log_level                        ="info";
 `
 
 const configurationTemplateSourceForDuTelnet = `
-Active_gNBs = ( "oai-du");
+Active_gNBs = ( {{ range $index, $cell := .CELLS }}{{ if $index }}, {{ end }}"{{ $cell.GNB_NAME }}"{{ end }});
 # Asn1_verbosity, choice in: none, info, annoying
 Asn1_verbosity = "none";
 gNBs =
 (
+{{- range $index, $cell := .CELLS }}{{ if $index }},{{ end }}
  {
     ////////// Identification parameters:
-    gNB_ID = 0xe00;
-    gNB_DU_ID = 0xe00;
+    gNB_ID = {{ $.GNB_ID }};
+    gNB_DU_ID = {{ $.GNB_DU_ID }};
 
-    gNB_name  =  "oai-du";
+    gNB_name  =  "{{ $cell.GNB_NAME }}";
 
     // Tracking area code, 0x0000 and 0xfffe are reserved values
-    tracking_area_code  =  {{ .TAC }};
-    plmn_list = ({ mcc = {{ .PLMN_MCC }}; mnc = {{ .PLMN_MNC }}; mnc_length = {{ .PLMN_MNC_LENGTH }}; snssaiList = ({ sst = {{ .NSSAI_SST }}, sd = 0x{{ .NSSAI_SD }} }) });
+    tracking_area_code  =  {{ $.TAC }};
+    plmn_list = ({ mcc = {{ $.PLMN_MCC }}; mnc = {{ $.PLMN_MNC }}; mnc_length = {{ $.PLMN_MNC_LENGTH }}; snssaiList = ({ sst = {{ $.NSSAI_SST }}, sd = 0x{{ $.NSSAI_SD }} }) });
 
 
-    nr_cellid = {{ .CELL_ID }};
+    nr_cellid = {{ $cell.CELL_ID }};
 
     ////////// Physical parameters:
 
@@ -217,20 +245,20 @@ gNBs =
 
     servingCellConfigCommon = (
     {
+#  physCellId
+      physCellId                                                       = {{ $cell.PHY_CELL_ID }};
 
 #  downlinkConfigCommon
     #frequencyInfoDL
-      # this is 3610.56 MHz
-      absoluteFrequencySSB                                             = 640704;
-      dl_frequencyBand                                                 = {{ .DL_FREQ_BAND }};
-      # this is 3599.94 MHz
-      dl_absoluteFrequencyPointA                                       = 639996;
+      absoluteFrequencySSB                                             = {{ $cell.ABS_FREQ_SSB }};
+      dl_frequencyBand                                                 = {{ $cell.DL_FREQ_BAND }};
+      dl_absoluteFrequencyPointA                                       = {{ $cell.DL_POINT_A }};
       #scs-SpecificCarrierList
         dl_offstToCarrier                                              = 0;
 # subcarrierSpacing
 # 0=kHz15, 1=kHz30, 2=kHz60, 3=kHz120
-        dl_subcarrierSpacing                                           = {{ .DL_SCS }};
-        dl_carrierBandwidth                                            = {{ .DL_CARRIER_BW }};
+        dl_subcarrierSpacing                                           = {{ $cell.DL_SCS }};
+        dl_carrierBandwidth                                            = {{ $cell.DL_CARRIER_BW }};
      #initialDownlinkBWP
       #genericParameters
         # this is RBstart=27,L=48 (275*(L-1))+RBstart
@@ -244,13 +272,13 @@ gNBs =
 
   #uplinkConfigCommon
      #frequencyInfoUL
-      ul_frequencyBand                                              = {{ .UL_FREQ_BAND }};
+      ul_frequencyBand                                              = {{ $cell.UL_FREQ_BAND }};
       #scs-SpecificCarrierList
       ul_offstToCarrier                                             = 0;
 # subcarrierSpacing
 # 0=kHz15, 1=kHz30, 2=kHz60, 3=kHz120
-      ul_subcarrierSpacing                                          = {{ .UL_SCS }};
-      ul_carrierBandwidth                                           = {{ .UL_CARRIER_BW }};
+      ul_subcarrierSpacing                                          = {{ $cell.UL_SCS }};
+      ul_carrierBandwidth                                           = {{ $cell.UL_CARRIER_BW }};
       pMax                                                          = 20;
      #initialUplinkBWP
       #genericParameters
@@ -348,14 +376,18 @@ gNBs =
         SCTP_OUTSTREAMS = 2;
     };
   }
+{{- end }}
 );
 
 MACRLCs = (
   {
-    num_cc           = 1;
+    num_cc           = {{ len .CELLS }};
     tr_s_preference  = "local_L1";
     tr_n_preference  = "f1";
     local_n_address = {{ .F1C_DU_IP }};
+{{- if .F1U_DU_IP }}
+    local_n_address_f1u = {{ .F1U_DU_IP }};
+{{- end }}
     remote_n_address = {{ .F1C_CU_IP }};
     local_n_portc   = 500;
     local_n_portd   = 2152;
@@ -368,7 +400,7 @@ MACRLCs = (
 
 L1s = (
 {
-  num_cc = 1;
+  num_cc = {{ len .CELLS }};
   tr_n_preference = "local_mac";
   prach_dtx_threshold = 200;
   pucch0_dtx_threshold = 150;
@@ -379,17 +411,20 @@ L1s = (
 RUs = (
     {     
     local_rf       = "yes"
-    nb_tx          = 1
-    nb_rx          = 1
-    att_tx         = 0
-    att_rx         = 0;
-    bands          = [78];
+    nb_tx          = {{ .RU_NB_TX }}
+    nb_rx          = {{ .RU_NB_RX }}
+    att_tx         = {{ .RU_ATT_TX }}
+    att_rx         = {{ .RU_ATT_RX }};
+    bands          = [{{ range $index, $cell := .CELLS }}{{ if $index }}, {{ end }}{{ $cell.DL_FREQ_BAND }}{{ end }}];
     max_pdschReferenceSignalPower = -27;
-    max_rxgain                    = 114;
+    max_rxgain                    = {{ .RU_MAX_RXGAIN }};
     eNB_instances  = [0];
     #beamforming 1x4 matrix:
     bf_weights = [0x00007fff, 0x0000, 0x0000, 0x0000];
-    clock_src = "internal";
+{{- if .RU_SDR_ADDRS }}
+    sdr_addrs = {{ .RU_SDR_ADDRS }};
+{{- end }}
+    clock_src = {{ .RU_CLOCK_SRC }};
     }
 );
 
@@ -401,6 +436,7 @@ THREAD_STRUCT = (
     worker_config      = "WORKER_ENABLE";
   }
 );
+{{- if .RFSIM }}
 rfsimulator: {
     serveraddr = "server";
     serverport = "4043";
@@ -408,8 +444,17 @@ rfsimulator: {
     modelname = "AWGN";
     IQfile = "/tmp/rfsimulator.iqs"
 }
+{{- end }}
+
+{{ if .E2_AGENT }}e2_agent = {
+  near_ric_ip_addr = {{ .E2_AGENT.RIC_IP }};
+{{- if .E2_AGENT.RIC_PORT }}
+  port = {{ .E2_AGENT.RIC_PORT }};
+{{- end }}
+  sm_dir = {{ .E2_AGENT.SM_DIR }};
+};
 
-log_config :
+{{ end }}log_config :
 {
     global_log_level                      ="info";
     hw_log_level                          ="info";
@@ -0,0 +1,432 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	configref "github.com/nephio-project/api/references/v1alpha1"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+// CuCpNameAnnotation is set on a DU or CU-UP NFDeployment to select the CU-CP it attaches to,
+// it is only required when several CU-CPs are available in the Config refs
+const CuCpNameAnnotation = "workload.nephio.org/cucp"
+
+// AttachedDu describes a DU served by a CU-CP
+type AttachedDu struct {
+	Name string
+	// DuId is the gNB_DU_ID of the DU, 0 until the controller allocated it
+	DuId      uint64
+	F1Address string
+	Cells     []workloadnfconfig.RANCellConfig
+}
+
+// AttachedCuUp describes a CU-UP registered to a CU-CP
+type AttachedCuUp struct {
+	Name      string
+	CuUpId    uint64
+	E1Address string
+	Slices    []workloadnfconfig.NSSAI
+}
+
+func getConfigInstancesByProvider(log logr.Logger, configInstances []*configref.Config, provider string) []*workloadv1alpha1.NFDeployment {
+	var nfDeployments []*workloadv1alpha1.NFDeployment
+	for _, configRef := range configInstances {
+		nfDeployment := &workloadv1alpha1.NFDeployment{}
+		if err := json.Unmarshal(configRef.Spec.Config.Raw, nfDeployment); err != nil {
+			log.Error(err, "Cannot Unmarshal NFDeployment")
+			continue
+		}
+		if nfDeployment.Spec.Provider == provider {
+			nfDeployments = append(nfDeployments, nfDeployment)
+		}
+	}
+	return nfDeployments
+}
+
+// getCuCpDeployment returns the CU-CP a DU or CU-UP attaches to, selected by the CuCpNameAnnotation
+// or, without the annotation, the only CU-CP available in the Config refs
+func getCuCpDeployment(log logr.Logger, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) (*workloadv1alpha1.NFDeployment, error) {
+	cuCpDeployments := getConfigInstancesByProvider(log, configInfo.ConfigRefInfo["NFDeployment"], "cucp.openairinterface.org")
+	cuCpName, annotated := ranDeployment.Annotations[CuCpNameAnnotation]
+	if annotated {
+		for _, cuCpDeployment := range cuCpDeployments {
+			if cuCpDeployment.Name == cuCpName {
+				return cuCpDeployment, nil
+			}
+		}
+		return nil, fmt.Errorf("CU-CP %q not found in Config refs", cuCpName)
+	}
+
+	switch len(cuCpDeployments) {
+	case 0:
+		return nil, fmt.Errorf("no CU-CP found in Config refs")
+	case 1:
+		return cuCpDeployments[0], nil
+	default:
+		return nil, fmt.Errorf("%d CU-CPs found in Config refs, select one with the %s annotation", len(cuCpDeployments), CuCpNameAnnotation)
+	}
+}
+
+// getCellsFromConfigInfo returns the cells of the RANConfig, nil if the RANConfig is not available
+func getCellsFromConfigInfo(configInfo *ConfigInfo) ([]workloadnfconfig.RANCellConfig, error) {
+	rawRanConfig, found := configInfo.ConfigSelfInfo["RANConfig"]
+	if !found {
+		return nil, nil
+	}
+	paramsRanNf := &workloadnfconfig.RANConfig{}
+	if err := json.Unmarshal(rawRanConfig.Raw, paramsRanNf); err != nil {
+		return nil, err
+	}
+	return paramsRanNf.Spec.GetCells(), nil
+}
+
+// attachedNf is a NFDeployment attached to a CU-CP together with its resolved configs
+type attachedNf struct {
+	nfDeployment *workloadv1alpha1.NFDeployment
+	configInfo   *ConfigInfo
+}
+
+// getAttachedNfs returns the NFDeployments of the namespace with the given provider attached to the CU-CP cuCpName, sorted by name
+func (r *RANDeploymentReconciler) getAttachedNfs(ctx context.Context, namespace string, cuCpName string, provider string) ([]attachedNf, error) {
+	logger := log.FromContext(ctx).WithValues("CU-CP", types.NamespacedName{Namespace: namespace, Name: cuCpName})
+
+	nfDeploymentList := &workloadv1alpha1.NFDeploymentList{}
+	if err := r.List(ctx, nfDeploymentList, client.InNamespace(namespace)); err != nil {
+		logger.Error(err, "Cannot list the NFDeployments")
+		return nil, err
+	}
+
+	attachedNfs := []attachedNf{}
+	for index := range nfDeploymentList.Items {
+		nfDeployment := &nfDeploymentList.Items[index]
+		if nfDeployment.Spec.Provider != provider || !nfDeployment.DeletionTimestamp.IsZero() {
+			continue
+		}
+		configInfo, err := r.GetConfigs(ctx, nfDeployment)
+		if err != nil {
+			logger.Info("Skipping NFDeployment with unresolved configs", "NFDeployment", nfDeployment.Name)
+			continue
+		}
+		cuCpDeployment, err := getCuCpDeployment(logger, nfDeployment, configInfo)
+		if err != nil || cuCpDeployment.Name != cuCpName {
+			continue
+		}
+		attachedNfs = append(attachedNfs, attachedNf{nfDeployment: nfDeployment, configInfo: configInfo})
+	}
+
+	sort.Slice(attachedNfs, func(i, j int) bool { return attachedNfs[i].nfDeployment.Name < attachedNfs[j].nfDeployment.Name })
+	return attachedNfs, nil
+}
+
+// GetAttachedDus returns the DUs of the namespace attached to the CU-CP cuCpName, sorted by name
+func (r *RANDeploymentReconciler) GetAttachedDus(ctx context.Context, namespace string, cuCpName string) ([]AttachedDu, error) {
+	logger := log.FromContext(ctx).WithValues("CU-CP", types.NamespacedName{Namespace: namespace, Name: cuCpName})
+
+	attachedNfs, err := r.getAttachedNfs(ctx, namespace, cuCpName, "du.openairinterface.org")
+	if err != nil {
+		return nil, err
+	}
+	attachedDus := []AttachedDu{}
+	for _, attachedNf := range attachedNfs {
+		cells, err := getCellsFromConfigInfo(attachedNf.configInfo)
+		if err != nil {
+			logger.Info("Skipping DU with invalid RANConfig", "DU", attachedNf.nfDeployment.Name)
+			continue
+		}
+		duId, _, _ := getDuId(attachedNf.nfDeployment)
+		f1Address, _ := getFirstInterfaceIPOfProvider("du.openairinterface.org", &attachedNf.nfDeployment.Spec, "f1c", "f1")
+		attachedDus = append(attachedDus, AttachedDu{Name: attachedNf.nfDeployment.Name, DuId: duId, F1Address: f1Address, Cells: cells})
+	}
+	return attachedDus, nil
+}
+
+// GetAttachedCuUps returns the CU-UPs of the namespace attached to the CU-CP cuCpName, sorted by name
+func (r *RANDeploymentReconciler) GetAttachedCuUps(ctx context.Context, namespace string, cuCpName string) ([]AttachedCuUp, error) {
+	logger := log.FromContext(ctx).WithValues("CU-CP", types.NamespacedName{Namespace: namespace, Name: cuCpName})
+
+	attachedNfs, err := r.getAttachedNfs(ctx, namespace, cuCpName, "cuup.openairinterface.org")
+	if err != nil {
+		return nil, err
+	}
+	attachedCuUps := []AttachedCuUp{}
+	for _, attachedNf := range attachedNfs {
+		cuUpId, found, err := getCuUpId(attachedNf.nfDeployment)
+		if err != nil || !found {
+			// Not registered yet, the CU-UP is listed once the controller allocated its gNB_CU_UP_ID
+			continue
+		}
+		paramsPlmn := &workloadnfconfig.PLMN{}
+		if err := json.Unmarshal(attachedNf.configInfo.ConfigSelfInfo["PLMN"].Raw, paramsPlmn); err != nil || len(paramsPlmn.Spec.PLMNInfo) == 0 {
+			logger.Info("Skipping CU-UP with invalid PLMN", "CU-UP", attachedNf.nfDeployment.Name)
+			continue
+		}
+		slices, err := getCuUpSlices(attachedNf.nfDeployment, &paramsPlmn.Spec.PLMNInfo[0])
+		if err != nil {
+			logger.Info("Skipping CU-UP with invalid slices", "CU-UP", attachedNf.nfDeployment.Name)
+			continue
+		}
+		e1Address, _ := getInterfaceIPOfProvider("cuup.openairinterface.org", &attachedNf.nfDeployment.Spec, "e1")
+		attachedCuUps = append(attachedCuUps, AttachedCuUp{Name: attachedNf.nfDeployment.Name, CuUpId: cuUpId, E1Address: e1Address, Slices: slices})
+	}
+	return attachedCuUps, nil
+}
+
+// CheckCellsOfCuCp verifies that the cells of a DU don't reuse a cell identity or a physical cell id
+// of another DU attached to the same CU-CP
+func (r *RANDeploymentReconciler) CheckCellsOfCuCp(ctx context.Context, ranDeployment *workloadv1alpha1.NFDeployment, configInfo *ConfigInfo) error {
+	namespacedName := types.NamespacedName{Namespace: ranDeployment.Namespace, Name: ranDeployment.Name}
+	logger := log.FromContext(ctx).WithValues("RANDeployment", namespacedName)
+
+	if ranDeployment.Spec.Provider != "du.openairinterface.org" {
+		return nil
+	}
+	cells, err := getCellsFromConfigInfo(configInfo)
+	if err != nil || cells == nil {
+		// Nothing to compare, the error is reported while rendering the configuration
+		return nil
+	}
+	cuCpDeployment, err := getCuCpDeployment(logger, ranDeployment, configInfo)
+	if err != nil {
+		return nil
+	}
+
+	attachedDus, err := r.GetAttachedDus(ctx, ranDeployment.Namespace, cuCpDeployment.Name)
+	if err != nil {
+		return err
+	}
+	cellsByDu := map[string][]workloadnfconfig.RANCellConfig{ranDeployment.Name: cells}
+	for _, attachedDu := range attachedDus {
+		if attachedDu.Name != ranDeployment.Name {
+			cellsByDu[attachedDu.Name] = attachedDu.Cells
+		}
+	}
+
+	return CheckCellUniqueness(cellsByDu)
+}
+
+// describeAttachedDus renders the attached DUs for the status of the CU-CP
+func describeAttachedDus(attachedDus []AttachedDu) string {
+	if len(attachedDus) == 0 {
+		return "No DU attached"
+	}
+	duDescriptions := make([]string, 0, len(attachedDus))
+	for _, attachedDu := range attachedDus {
+		cellDescriptions := make([]string, 0, len(attachedDu.Cells))
+		for _, cell := range attachedDu.Cells {
+			cellDescriptions = append(cellDescriptions, fmt.Sprintf("%s/pci %d", cell.CellIdentity, cell.PhysicalCellID))
+		}
+		idDescription := ""
+		if attachedDu.DuId != 0 {
+			idDescription = fmt.Sprintf("id 0x%x, ", attachedDu.DuId)
+		}
+		duDescriptions = append(duDescriptions, fmt.Sprintf("%s (%sf1 %s, cells %s)", attachedDu.Name, idDescription, attachedDu.F1Address, strings.Join(cellDescriptions, " ")))
+	}
+	return strings.Join(duDescriptions, ", ")
+}
+
+// UpdateAttachedDus records the DUs attached to a CU-CP in its status
+func (r *RANDeploymentReconciler) UpdateAttachedDus(ctx context.Context, cuCpDeployment *workloadv1alpha1.NFDeployment) error {
+	attachedDus, err := r.GetAttachedDus(ctx, cuCpDeployment.Namespace, cuCpDeployment.Name)
+	if err != nil {
+		return err
+	}
+	curCondition := metav1.Condition{
+		Type:               "attachedDUs",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionTrue,
+		Reason:             "attachedDUs",
+		Message:            describeAttachedDus(attachedDus),
+	}
+	if len(attachedDus) == 0 {
+		curCondition.Status = metav1.ConditionFalse
+	}
+	return r.updateStatusIfRequired(ctx, cuCpDeployment, curCondition)
+}
+
+// AllocateCuUpId records a gNB_CU_UP_ID on a CU-UP, distinct from the ones of the other CU-UPs of the namespace
+// and hence from the other CU-UPs of its CU-CP. An already recorded gNB_CU_UP_ID is kept as long as it is not reused.
+func (r *RANDeploymentReconciler) AllocateCuUpId(ctx context.Context, cuUpDeployment *workloadv1alpha1.NFDeployment) error {
+	if cuUpDeployment.Spec.Provider != "cuup.openairinterface.org" {
+		return nil
+	}
+	cuUpId, found, err := getCuUpId(cuUpDeployment)
+	if err != nil {
+		return err
+	}
+
+	nfDeploymentList := &workloadv1alpha1.NFDeploymentList{}
+	if err := r.List(ctx, nfDeploymentList, client.InNamespace(cuUpDeployment.Namespace)); err != nil {
+		return err
+	}
+	usedIds := make(map[uint64]string)
+	for index := range nfDeploymentList.Items {
+		nfDeployment := &nfDeploymentList.Items[index]
+		if nfDeployment.Spec.Provider != "cuup.openairinterface.org" || nfDeployment.Name == cuUpDeployment.Name {
+			continue
+		}
+		if otherId, otherFound, err := getCuUpId(nfDeployment); err == nil && otherFound {
+			usedIds[otherId] = nfDeployment.Name
+		}
+	}
+
+	if found {
+		if owner, used := usedIds[cuUpId]; used {
+			return fmt.Errorf("gNB_CU_UP_ID 0x%x is already used by %q", cuUpId, owner)
+		}
+		return nil
+	}
+	if cuUpId, err = allocateCuUpId(usedIds); err != nil {
+		return err
+	}
+	if cuUpDeployment.Annotations == nil {
+		cuUpDeployment.Annotations = map[string]string{}
+	}
+	cuUpDeployment.Annotations[CuUpIdAnnotation] = fmt.Sprintf("0x%x", cuUpId)
+	if err := r.Update(ctx, cuUpDeployment); err != nil {
+		r.recordEvent(cuUpDeployment, corev1.EventTypeWarning, ReasonUpdateFailed, "Cannot annotate the gNB_CU_UP_ID 0x%x: %v", cuUpId, err)
+		return err
+	}
+	r.recordEvent(cuUpDeployment, corev1.EventTypeNormal, ReasonUpdated, "Annotated the gNB_CU_UP_ID 0x%x", cuUpId)
+	return nil
+}
+
+// AllocateDuId records a gNB_DU_ID on a DU, distinct from the ones of the other DUs of the namespace and hence from
+// the other DUs of its CU-CP, which rejects the F1 Setup of a DU reusing one. An already recorded gNB_DU_ID is kept
+// as long as it is not reused.
+func (r *RANDeploymentReconciler) AllocateDuId(ctx context.Context, duDeployment *workloadv1alpha1.NFDeployment) error {
+	if duDeployment.Spec.Provider != "du.openairinterface.org" {
+		return nil
+	}
+	duId, found, err := getDuId(duDeployment)
+	if err != nil {
+		return err
+	}
+
+	nfDeploymentList := &workloadv1alpha1.NFDeploymentList{}
+	if err := r.List(ctx, nfDeploymentList, client.InNamespace(duDeployment.Namespace)); err != nil {
+		return err
+	}
+	usedIds := make(map[uint64]string)
+	for index := range nfDeploymentList.Items {
+		nfDeployment := &nfDeploymentList.Items[index]
+		if nfDeployment.Spec.Provider != "du.openairinterface.org" || nfDeployment.Name == duDeployment.Name {
+			continue
+		}
+		if otherId, otherFound, err := getDuId(nfDeployment); err == nil && otherFound {
+			usedIds[otherId] = nfDeployment.Name
+		}
+	}
+
+	if found {
+		if owner, used := usedIds[duId]; used {
+			return fmt.Errorf("gNB_DU_ID 0x%x is already used by %q", duId, owner)
+		}
+		return nil
+	}
+	if duId, err = allocateDuId(usedIds); err != nil {
+		return err
+	}
+	if duDeployment.Annotations == nil {
+		duDeployment.Annotations = map[string]string{}
+	}
+	duDeployment.Annotations[DuIdAnnotation] = fmt.Sprintf("0x%x", duId)
+	if err := r.Update(ctx, duDeployment); err != nil {
+		r.recordEvent(duDeployment, corev1.EventTypeWarning, ReasonUpdateFailed, "Cannot annotate the gNB_DU_ID 0x%x: %v", duId, err)
+		return err
+	}
+	r.recordEvent(duDeployment, corev1.EventTypeNormal, ReasonUpdated, "Annotated the gNB_DU_ID 0x%x", duId)
+	return nil
+}
+
+// describeAttachedCuUps renders the registered CU-UPs for the status of the CU-CP
+func describeAttachedCuUps(attachedCuUps []AttachedCuUp) string {
+	if len(attachedCuUps) == 0 {
+		return "No CU-UP registered"
+	}
+	cuUpDescriptions := make([]string, 0, len(attachedCuUps))
+	for _, attachedCuUp := range attachedCuUps {
+		sliceDescriptions := make([]string, 0, len(attachedCuUp.Slices))
+		for _, nssai := range attachedCuUp.Slices {
+			sliceDescriptions = append(sliceDescriptions, formatSlice(nssai))
+		}
+		cuUpDescriptions = append(cuUpDescriptions, fmt.Sprintf("%s (id 0x%x, e1 %s, slices %s)", attachedCuUp.Name, attachedCuUp.CuUpId, attachedCuUp.E1Address, strings.Join(sliceDescriptions, " ")))
+	}
+	return strings.Join(cuUpDescriptions, ", ")
+}
+
+// UpdateAttachedCuUps records the CU-UPs registered to a CU-CP in its status
+func (r *RANDeploymentReconciler) UpdateAttachedCuUps(ctx context.Context, cuCpDeployment *workloadv1alpha1.NFDeployment) error {
+	attachedCuUps, err := r.GetAttachedCuUps(ctx, cuCpDeployment.Namespace, cuCpDeployment.Name)
+	if err != nil {
+		return err
+	}
+	curCondition := metav1.Condition{
+		Type:               "attachedCUUPs",
+		LastTransitionTime: metav1.Time{Time: time.Now()},
+		Status:             metav1.ConditionTrue,
+		Reason:             "attachedCUUPs",
+		Message:            describeAttachedCuUps(attachedCuUps),
+	}
+	if len(attachedCuUps) == 0 {
+		curCondition.Status = metav1.ConditionFalse
+	}
+	return r.updateStatusIfRequired(ctx, cuCpDeployment, curCondition)
+}
+
+// findCuCpOfNf maps a DU or CU-UP event to a reconcile request of its CU-CP, so that the CU-CP status follows its DUs and CU-UPs.
+// When the CU-CP can't be resolved anymore (e.g. the NF configs are gone) every CU-CP of the namespace is refreshed.
+func (r *RANDeploymentReconciler) findCuCpOfNf(ctx context.Context, object client.Object) []reconcile.Request {
+	attachedDeployment, ok := object.(*workloadv1alpha1.NFDeployment)
+	if !ok || (attachedDeployment.Spec.Provider != "du.openairinterface.org" && attachedDeployment.Spec.Provider != "cuup.openairinterface.org") {
+		return nil
+	}
+	logger := log.FromContext(ctx).WithValues("NFDeployment", types.NamespacedName{Namespace: attachedDeployment.Namespace, Name: attachedDeployment.Name})
+
+	if configInfo, err := r.GetConfigs(ctx, attachedDeployment); err == nil {
+		if cuCpDeployment, err := getCuCpDeployment(logger, attachedDeployment, configInfo); err == nil {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: attachedDeployment.Namespace, Name: cuCpDeployment.Name}}}
+		}
+	}
+
+	nfDeploymentList := &workloadv1alpha1.NFDeploymentList{}
+	if err := r.List(ctx, nfDeploymentList, client.InNamespace(attachedDeployment.Namespace)); err != nil {
+		logger.Error(err, "Cannot list the NFDeployments")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, nfDeployment := range nfDeploymentList.Items {
+		if nfDeployment.Spec.Provider == "cucp.openairinterface.org" {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: nfDeployment.Namespace, Name: nfDeployment.Name}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2023 The Nephio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	context "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	configref "github.com/nephio-project/api/references/v1alpha1"
+	workloadv1alpha1 "github.com/nephio-project/api/workload/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	workloadnfconfig "workload.nephio.org/ran_deployment/api/v1alpha1"
+)
+
+func cuCpConfigRef(cuCpName string) *configref.Config {
+	return &configref.Config{Spec: configref.ConfigSpec{Config: runtime.RawExtension{Raw: marshalJsonReturnByteOnly(workloadv1alpha1.NFDeployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "NFDeployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: cuCpName},
+		Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "cucp.openairinterface.org"},
+	})}}}
+}
+
+func TestGetCuCpDeployment(t *testing.T) {
+	cases := map[string]struct {
+		annotations  map[string]string
+		cuCpNames    []string
+		expectedCuCp string
+		wantsError   bool
+	}{
+		"Single CU-CP without annotation":    {cuCpNames: []string{"cucp-a"}, expectedCuCp: "cucp-a"},
+		"Several CU-CPs with annotation":     {annotations: map[string]string{CuCpNameAnnotation: "cucp-b"}, cuCpNames: []string{"cucp-a", "cucp-b"}, expectedCuCp: "cucp-b"},
+		"Several CU-CPs without annotation":  {cuCpNames: []string{"cucp-a", "cucp-b"}, wantsError: true},
+		"Annotation selecting a missing one": {annotations: map[string]string{CuCpNameAnnotation: "cucp-c"}, cuCpNames: []string{"cucp-a"}, wantsError: true},
+		"No CU-CP":                           {wantsError: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			configInfo := NewConfigInfo()
+			for _, cuCpName := range tc.cuCpNames {
+				configInfo.ConfigRefInfo["NFDeployment"] = append(configInfo.ConfigRefInfo["NFDeployment"], cuCpConfigRef(cuCpName))
+			}
+			duDeployment := &workloadv1alpha1.NFDeployment{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+
+			cuCpDeployment, err := getCuCpDeployment(logr.Discard(), duDeployment, configInfo)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("getCuCpDeployment returned %v, expecting an error", cuCpDeployment.Name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getCuCpDeployment returned error %v", err)
+			}
+			if cuCpDeployment.Name != tc.expectedCuCp {
+				t.Errorf("getCuCpDeployment returned %v, expected %v", cuCpDeployment.Name, tc.expectedCuCp)
+			}
+		})
+	}
+}
+
+func TestDescribeAttachedDus(t *testing.T) {
+	cases := map[string]struct {
+		attachedDus []AttachedDu
+		expected    string
+	}{
+		"No DU": {expected: "No DU attached"},
+		"Two DUs": {
+			attachedDus: []AttachedDu{
+				{Name: "du-a", DuId: 0xe00, F1Address: "10.0.0.1", Cells: []workloadnfconfig.RANCellConfig{{CellIdentity: "12345678L", PhysicalCellID: 0}, {CellIdentity: "12345679L", PhysicalCellID: 1}}},
+				{Name: "du-b", F1Address: "10.0.0.2", Cells: []workloadnfconfig.RANCellConfig{{CellIdentity: "12345680L", PhysicalCellID: 2}}},
+			},
+			expected: "du-a (id 0xe00, f1 10.0.0.1, cells 12345678L/pci 0 12345679L/pci 1), du-b (f1 10.0.0.2, cells 12345680L/pci 2)",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := describeAttachedDus(tc.attachedDus); got != tc.expected {
+				t.Errorf("describeAttachedDus returned %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+/*
+Every DU references a NFConfig named "<du>-config" carrying its RANConfig and a Config named "<du>-cucp" carrying its CU-CP
+*/
+func nfConfigWithPci(pci uint32) *workloadv1alpha1.NFConfig {
+	return &workloadv1alpha1.NFConfig{Spec: workloadv1alpha1.NFConfigSpec{
+		ConfigRefs: []runtime.RawExtension{
+			{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "PLMN", "spec": map[string]any{"PLMNInfo": []any{map[string]any{"nssai": []any{
+				map[string]any{"sst": 1, "sd": "000001"},
+				map[string]any{"sst": 1, "sd": "001001"},
+			}}}}})},
+			{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "OAIConfig"})},
+			{Raw: marshalJsonReturnByteOnly(map[string]any{"kind": "RANConfig", "spec": map[string]any{"cellIdentity": fmt.Sprintf("%dL", 1000+pci), "physicalCellID": pci}})},
+		},
+	}}
+}
+
+func duDeploymentAttachedTo(name string) workloadv1alpha1.NFDeployment {
+	return workloadv1alpha1.NFDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "myns"},
+		Spec: workloadv1alpha1.NFDeploymentSpec{
+			Provider: "du.openairinterface.org",
+			ParametersRefs: []workloadv1alpha1.ObjectReference{
+				{APIVersion: "workload.nephio.org/v1alpha1", Name: ptr.To(name + "-config")},
+				{APIVersion: "ref.nephio.org/v1alpha1", Name: ptr.To(name + "-cucp")},
+			},
+		},
+	}
+}
+
+// mockAttachedNf makes the configs of the DU or CU-UP nfName resolvable, attaching it to cuCpName ("" leaves the CU-CP unresolved)
+func mockAttachedNf(clientMock *MockClient, nfName string, pci uint32, cuCpName string) {
+	clientMock.On("Get", context.TODO(), types.NamespacedName{Namespace: "myns", Name: nfName + "-config"}, mock.AnythingOfType("*v1alpha1.NFConfig")).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(2).(*workloadv1alpha1.NFConfig) = *nfConfigWithPci(pci)
+	})
+	if cuCpName == "" {
+		clientMock.On("Get", context.TODO(), types.NamespacedName{Namespace: "myns", Name: nfName + "-cucp"}, mock.AnythingOfType("*v1alpha1.Config")).Return(errors.New("Requested Object Not Found"))
+	} else {
+		clientMock.On("Get", context.TODO(), types.NamespacedName{Namespace: "myns", Name: nfName + "-cucp"}, mock.AnythingOfType("*v1alpha1.Config")).Return(nil).Run(func(args mock.Arguments) {
+			*args.Get(2).(*configref.Config) = *cuCpConfigRef(cuCpName)
+		})
+	}
+}
+
+func TestCheckCellsOfCuCp(t *testing.T) {
+	cases := map[string]struct {
+		otherPci   uint32
+		otherCuCp  string
+		wantsError bool
+	}{
+		"Distinct PCI on the same CU-CP":  {otherPci: 2, otherCuCp: "cucp-a", wantsError: false},
+		"Same PCI on the same CU-CP":      {otherPci: 1, otherCuCp: "cucp-a", wantsError: true},
+		"Same PCI on a different CU-CP":   {otherPci: 1, otherCuCp: "cucp-b", wantsError: false},
+		"Other DU with unresolved config": {otherPci: 1, otherCuCp: "", wantsError: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeploymentList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				list := args.Get(1).(*workloadv1alpha1.NFDeploymentList)
+				list.Items = []workloadv1alpha1.NFDeployment{duDeploymentAttachedTo("du-a"), duDeploymentAttachedTo("du-b")}
+			})
+			mockAttachedNf(clientMock, "du-a", 1, "cucp-a")
+			mockAttachedNf(clientMock, "du-b", tc.otherPci, tc.otherCuCp)
+
+			ranReconcilerObj := RANDeploymentReconciler{
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
+			}
+
+			ownDeployment := duDeploymentAttachedTo("du-a")
+			ownConfigInfo := NewConfigInfo()
+			for _, raw := range nfConfigWithPci(1).Spec.ConfigRefs {
+				var result map[string]any
+				_ = json.Unmarshal(raw.Raw, &result)
+				ownConfigInfo.ConfigSelfInfo[result["kind"].(string)] = raw
+			}
+			ownConfigInfo.ConfigRefInfo["NFDeployment"] = []*configref.Config{cuCpConfigRef("cucp-a")}
+
+			err := ranReconcilerObj.CheckCellsOfCuCp(context.TODO(), &ownDeployment, ownConfigInfo)
+			if tc.wantsError && err == nil {
+				t.Errorf("CheckCellsOfCuCp returned nil, expecting a cell conflict")
+			}
+			if !tc.wantsError && err != nil {
+				t.Errorf("CheckCellsOfCuCp returned error %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateAttachedDus(t *testing.T) {
+	cases := map[string]struct {
+		duCuCps         map[string]string
+		expectedStatus  metav1.ConditionStatus
+		expectedMessage string
+	}{
+		"Two DUs attached": {
+			duCuCps:         map[string]string{"du-a": "cucp-a", "du-b": "cucp-a"},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "du-a (f1 , cells 1001L/pci 1), du-b (f1 , cells 1002L/pci 2)",
+		},
+		"One DU on another CU-CP": {
+			duCuCps:         map[string]string{"du-a": "cucp-a", "du-b": "cucp-b"},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedMessage: "du-a (f1 , cells 1001L/pci 1)",
+		},
+		"No DU attached": {
+			duCuCps:         map[string]string{"du-a": "cucp-b", "du-b": ""},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedMessage: "No DU attached",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeploymentList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				list := args.Get(1).(*workloadv1alpha1.NFDeploymentList)
+				list.Items = []workloadv1alpha1.NFDeployment{
+					duDeploymentAttachedTo("du-b"),
+					duDeploymentAttachedTo("du-a"),
+					{ObjectMeta: metav1.ObjectMeta{Name: "cucp-a", Namespace: "myns"}, Spec: workloadv1alpha1.NFDeploymentSpec{Provider: "cucp.openairinterface.org"}},
+				}
+			})
+			mockAttachedNf(clientMock, "du-a", 1, tc.duCuCps["du-a"])
+			mockAttachedNf(clientMock, "du-b", 2, tc.duCuCps["du-b"])
+			statusWriterMock := new(MockStatusWriter)
+			statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+			clientMock.On("Status").Return(statusWriterMock)
+
+			ranReconcilerObj := RANDeploymentReconciler{
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
+			}
+
+			cuCpDeployment := &workloadv1alpha1.NFDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "cucp-a", Namespace: "myns"},
+				Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "cucp.openairinterface.org"},
+			}
+			if err := ranReconcilerObj.UpdateAttachedDus(context.TODO(), cuCpDeployment); err != nil {
+				t.Fatalf("UpdateAttachedDus returned error %v", err)
+			}
+			if len(cuCpDeployment.Status.Conditions) != 1 {
+				t.Fatalf("UpdateAttachedDus set %d conditions, expected 1", len(cuCpDeployment.Status.Conditions))
+			}
+			condition := cuCpDeployment.Status.Conditions[0]
+			if condition.Type != "attachedDUs" || condition.Status != tc.expectedStatus || condition.Message != tc.expectedMessage {
+				t.Errorf("UpdateAttachedDus set condition %v/%v %q, expected attachedDUs/%v %q", condition.Type, condition.Status, condition.Message, tc.expectedStatus, tc.expectedMessage)
+			}
+		})
+	}
+}
+
+func cuUpDeploymentWithId(name string, cuUpId string) workloadv1alpha1.NFDeployment {
+	cuUpDeployment := duDeploymentAttachedTo(name)
+	cuUpDeployment.Spec.Provider = "cuup.openairinterface.org"
+	if cuUpId != "" {
+		cuUpDeployment.Annotations = map[string]string{CuUpIdAnnotation: cuUpId}
+	}
+	return cuUpDeployment
+}
+
+func TestAllocateCuUpIdOfReconciler(t *testing.T) {
+	cases := map[string]struct {
+		ownId      string
+		otherIds   []string
+		expectedId string
+		wantsError bool
+	}{
+		"First CU-UP":           {expectedId: "0xe00"},
+		"Next To Other CU-UPs":  {otherIds: []string{"0xe00", "0xe01"}, expectedId: "0xe02"},
+		"Already Allocated":     {ownId: "0xe05", otherIds: []string{"0xe00"}, expectedId: "0xe05"},
+		"Already Used By Other": {ownId: "0xe00", otherIds: []string{"0xe00"}, wantsError: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeploymentList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				list := args.Get(1).(*workloadv1alpha1.NFDeploymentList)
+				for index, otherId := range tc.otherIds {
+					list.Items = append(list.Items, cuUpDeploymentWithId(fmt.Sprintf("cuup-other-%d", index), otherId))
+				}
+			})
+			clientMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+
+			ranReconcilerObj := RANDeploymentReconciler{
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
+			}
+
+			cuUpDeployment := cuUpDeploymentWithId("cuup-own", tc.ownId)
+			err := ranReconcilerObj.AllocateCuUpId(context.TODO(), &cuUpDeployment)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("AllocateCuUpId returned nil, expecting a gNB_CU_UP_ID conflict")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AllocateCuUpId returned error %v", err)
+			}
+			if got := cuUpDeployment.Annotations[CuUpIdAnnotation]; got != tc.expectedId {
+				t.Errorf("AllocateCuUpId recorded %v, expected %v", got, tc.expectedId)
+			}
+		})
+	}
+}
+
+func TestAllocateDuIdOfReconciler(t *testing.T) {
+	cases := map[string]struct {
+		ownId      string
+		otherIds   []string
+		expectedId string
+		wantsError bool
+	}{
+		"First DU":              {expectedId: "0xe00"},
+		"Next To Other DUs":     {otherIds: []string{"0xe00", "0xe01"}, expectedId: "0xe02"},
+		"Already Allocated":     {ownId: "0xe05", otherIds: []string{"0xe00"}, expectedId: "0xe05"},
+		"Already Used By Other": {ownId: "0xe00", otherIds: []string{"0xe00"}, wantsError: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			clientMock := new(MockClient)
+			clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeploymentList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				list := args.Get(1).(*workloadv1alpha1.NFDeploymentList)
+				for index, otherId := range tc.otherIds {
+					other := duDeploymentAttachedTo(fmt.Sprintf("du-other-%d", index))
+					other.Annotations = map[string]string{DuIdAnnotation: otherId}
+					list.Items = append(list.Items, other)
+				}
+			})
+			clientMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+
+			ranReconcilerObj := RANDeploymentReconciler{
+				Client: clientMock,
+				Scheme: runtime.NewScheme(),
+			}
+
+			duDeployment := duDeploymentAttachedTo("du-own")
+			if tc.ownId != "" {
+				duDeployment.Annotations = map[string]string{DuIdAnnotation: tc.ownId}
+			}
+			err := ranReconcilerObj.AllocateDuId(context.TODO(), &duDeployment)
+			if tc.wantsError {
+				if err == nil {
+					t.Errorf("AllocateDuId returned nil, expecting a gNB_DU_ID conflict")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AllocateDuId returned error %v", err)
+			}
+			if got := duDeployment.Annotations[DuIdAnnotation]; got != tc.expectedId {
+				t.Errorf("AllocateDuId recorded %v, expected %v", got, tc.expectedId)
+			}
+		})
+	}
+}
+
+func TestUpdateAttachedCuUps(t *testing.T) {
+	clientMock := new(MockClient)
+	clientMock.On("List", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeploymentList"), mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		list := args.Get(1).(*workloadv1alpha1.NFDeploymentList)
+		list.Items = []workloadv1alpha1.NFDeployment{
+			cuUpDeploymentWithId("cuup-b", "0xe01"),
+			cuUpDeploymentWithId("cuup-a", "0xe00"),
+			// Not registered until its gNB_CU_UP_ID is allocated
+			cuUpDeploymentWithId("cuup-c", ""),
+		}
+		list.Items[0].Annotations[SlicesAnnotation] = "1-001001"
+	})
+	for _, cuUpName := range []string{"cuup-a", "cuup-b", "cuup-c"} {
+		mockAttachedNf(clientMock, cuUpName, 0, "cucp-a")
+	}
+	statusWriterMock := new(MockStatusWriter)
+	statusWriterMock.On("Update", context.TODO(), mock.AnythingOfType("*v1alpha1.NFDeployment")).Return(nil)
+	clientMock.On("Status").Return(statusWriterMock)
+
+	ranReconcilerObj := RANDeploymentReconciler{
+		Client: clientMock,
+		Scheme: runtime.NewScheme(),
+	}
+
+	cuCpDeployment := &workloadv1alpha1.NFDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cucp-a", Namespace: "myns"},
+		Spec:       workloadv1alpha1.NFDeploymentSpec{Provider: "cucp.openairinterface.org"},
+	}
+	if err := ranReconcilerObj.UpdateAttachedCuUps(context.TODO(), cuCpDeployment); err != nil {
+		t.Fatalf("UpdateAttachedCuUps returned error %v", err)
+	}
+	expectedMessage := "cuup-a (id 0xe00, e1 , slices 1-000001 1-001001), cuup-b (id 0xe01, e1 , slices 1-001001)"
+	condition := cuCpDeployment.Status.Conditions[0]
+	if condition.Type != "attachedCUUPs" || condition.Status != metav1.ConditionTrue || condition.Message != expectedMessage {
+		t.Errorf("UpdateAttachedCuUps set condition %v/%v %q, expected attachedCUUPs/True %q", condition.Type, condition.Status, condition.Message, expectedMessage)
+	}
+}